@@ -3,6 +3,7 @@ package binarysort
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -16,6 +17,7 @@ func TestOrdering(t *testing.T) {
 		{"uint64", 0, 1000, func(buf []byte, i int) []byte { return AppendUint64(buf, uint64(i)) }},
 		{"int64", -1000, 1000, func(buf []byte, i int) []byte { return AppendInt64(buf, int64(i)) }},
 		{"float64", -1000, 1000, func(buf []byte, i int) []byte { return AppendFloat64(buf, float64(i)) }},
+		{"time", -1000, 1000, func(buf []byte, i int) []byte { return AppendTime(buf, time.Unix(0, int64(i)*1e8)) }},
 		{"text", -1000, 1000, func(buf []byte, i int) []byte {
 			b, err := AppendBase64(nil, AppendInt64(buf, int64(i)))
 			require.NoError(t, err)
@@ -63,6 +65,10 @@ func TestTwoWays(t *testing.T) {
 			func(buf []byte, v interface{}) []byte { return AppendFloat64(buf, v.(float64)) },
 			func(buf []byte) (interface{}, error) { return DecodeFloat64(buf) },
 		},
+		{"time", time.Date(1900, 1, 2, 3, 4, 5, 6, time.UTC),
+			func(buf []byte, v interface{}) []byte { return AppendTime(buf, v.(time.Time)) },
+			func(buf []byte) (interface{}, error) { return DecodeTime(buf) },
+		},
 		{"base64", []byte("hello"),
 			func(buf []byte, v interface{}) []byte { res, _ := AppendBase64(buf, v.([]byte)); return res },
 			func(buf []byte) (interface{}, error) { return DecodeBase64(buf) },
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"math"
+	"time"
 )
 
 // Default Base64 encoder string doesn't preserve lexicographic order. This alternative
@@ -90,6 +91,31 @@ func DecodeFloat64(buf []byte) (float64, error) {
 	return math.Float64frombits(x), nil
 }
 
+// AppendTime takes a time and returns its binary representation.
+// The seconds since the unix epoch are encoded as an int64,
+// followed by the nanoseconds as a uint32.
+func AppendTime(buf []byte, t time.Time) []byte {
+	buf = AppendInt64(buf, t.Unix())
+
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(t.Nanosecond()))
+	return append(buf, b[:]...)
+}
+
+// DecodeTime takes a byte slice and decodes it into a UTC time.
+func DecodeTime(buf []byte) (time.Time, error) {
+	if len(buf) < 12 {
+		return time.Time{}, errors.New("cannot decode buffer to time")
+	}
+
+	sec, err := DecodeInt64(buf)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(sec, int64(binary.BigEndian.Uint32(buf[8:]))).UTC(), nil
+}
+
 // AppendBase64 encodes data into a custom base64 encoding. The resulting slice respects
 // natural sort-ordering.
 func AppendBase64(buf []byte, data []byte) ([]byte, error) {
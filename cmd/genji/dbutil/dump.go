@@ -70,6 +70,12 @@ func dumpTable(tx *genji.Tx, w io.Writer, tableName string) error {
 		return err
 	}
 
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+	fcs := t.Info().FieldConstraints
+
 	q := fmt.Sprintf("SELECT * FROM %s", tableName)
 	res, err := tx.Query(q)
 	if err != nil {
@@ -80,7 +86,25 @@ func dumpTable(tx *genji.Tx, w io.Writer, tableName string) error {
 	// Inserts statements.
 	insert := fmt.Sprintf("INSERT INTO %s VALUES", tableName)
 	return res.Iterate(func(d document.Document) error {
-		data, err := document.MarshalJSON(d)
+		// Generated fields are computed again when the document is inserted.
+		var fb document.FieldBuffer
+		err := fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		for _, fc := range fcs {
+			if !fc.IsGenerated() {
+				continue
+			}
+
+			err = fb.Delete(fc.Path)
+			if err != nil && err != document.ErrFieldNotFound {
+				return err
+			}
+		}
+
+		data, err := document.MarshalJSON(&fb)
 		if err != nil {
 			return err
 		}
@@ -149,7 +173,7 @@ func dumpSchema(tx *genji.Tx, w io.Writer, tableName string) error {
 
 	fcs := ti.FieldConstraints
 	// Fields constraints should be displayed between parenthesis.
-	if len(fcs) > 0 {
+	if len(fcs) > 0 || len(ti.TableConstraints) > 0 {
 		_, err = fmt.Fprintln(w, " (")
 		if err != nil {
 			return err
@@ -179,19 +203,39 @@ func dumpSchema(tx *genji.Tx, w io.Writer, tableName string) error {
 			f += " NOT NULL"
 		}
 
+		if fc.IsUnique {
+			f += " UNIQUE"
+		}
+
 		if fc.HasDefaultValue() {
-			if _, err := fmt.Fprintf(w, "%s DEFAULT %s", f, fc.DefaultValue.String()); err != nil {
-				return err
-			}
-		} else {
-			if _, err := fmt.Fprintf(w, f); err != nil {
+			f += " DEFAULT " + fc.DefaultValue.String()
+		}
+
+		if fc.IsGenerated() {
+			f += " AS (" + fc.GeneratedExpr.String() + ") STORED"
+		}
+
+		if _, err := fmt.Fprint(w, f); err != nil {
+			return err
+		}
+	}
+
+	// Table constraints are displayed after the field constraints.
+	for i, tc := range ti.TableConstraints {
+		if i > 0 || len(fcs) > 0 {
+			_, err = fmt.Fprintln(w, ",")
+			if err != nil {
 				return err
 			}
 		}
+
+		if _, err := fmt.Fprintf(w, " %s", tc); err != nil {
+			return err
+		}
 	}
 
 	// Fields constraints close parenthesis.
-	if len(fcs) > 0 {
+	if len(fcs) > 0 || len(ti.TableConstraints) > 0 {
 		if _, err := fmt.Fprintln(w, "\n);"); err != nil {
 			return err
 		}
@@ -205,6 +249,13 @@ func dumpSchema(tx *genji.Tx, w io.Writer, tableName string) error {
 	indexes := t.Indexes()
 
 	for _, index := range indexes {
+		// Indexes created by UNIQUE field constraints are recreated with the table.
+		if index.Info.Unique && len(index.Info.Paths) == 1 {
+			if fc := fcs.Get(index.Info.Paths[0]); fc != nil && fc.IsUnique {
+				continue
+			}
+		}
+
 		u := ""
 		if index.Info.Unique {
 			u = " UNIQUE"
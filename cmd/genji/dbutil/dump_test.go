@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/tie/genji-release-test"
+	"github.com/tie/genji-release-test/document"
 	"github.com/stretchr/testify/require"
 )
 
@@ -155,3 +156,75 @@ func TestDumpSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestDumpSchemaConstraints(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE test (a INTEGER UNIQUE CHECK (a > 0), b TEXT, c DOUBLE DEFAULT (a + 1), CONSTRAINT b_not_empty CHECK (b != ""))`)
+	require.NoError(t, err)
+
+	want := `CREATE TABLE test (
+ a INTEGER UNIQUE,
+ b TEXT,
+ c DOUBLE DEFAULT (a + 1),
+ CONSTRAINT test_a_check CHECK (a > 0),
+ CONSTRAINT b_not_empty CHECK (b != "")
+);
+`
+
+	var got bytes.Buffer
+	err = DumpSchema(context.Background(), db, &got)
+	require.NoError(t, err)
+	require.Equal(t, want, got.String())
+
+	// the dumped schema must be valid
+	db2, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db2.Close()
+
+	err = db2.Exec(got.String())
+	require.NoError(t, err)
+}
+
+func TestDumpGeneratedFields(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (price DOUBLE, qty INTEGER, total DOUBLE AS (price * qty) STORED);
+		INSERT INTO test (price, qty) VALUES (2.5, 4);
+	`)
+	require.NoError(t, err)
+
+	want := `BEGIN TRANSACTION;
+CREATE TABLE test (
+ price DOUBLE,
+ qty INTEGER,
+ total DOUBLE AS (price * qty) STORED
+);
+INSERT INTO test VALUES {"price": 2.5, "qty": 4};
+COMMIT;
+`
+
+	var got bytes.Buffer
+	err = Dump(context.Background(), db, &got)
+	require.NoError(t, err)
+	require.Equal(t, want, got.String())
+
+	// the dump must be restorable
+	db2, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db2.Close()
+
+	err = db2.Exec(got.String())
+	require.NoError(t, err)
+
+	d, err := db2.QueryDocument("SELECT total FROM test")
+	require.NoError(t, err)
+	v, err := d.GetByField("total")
+	require.NoError(t, err)
+	require.Equal(t, document.NewDoubleValue(10), v)
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"errors"
+	"sort"
 	"strings"
 	"sync"
 
@@ -125,6 +126,16 @@ func (c *Catalog) CreateTable(tx *Transaction, tableName string, info *TableInfo
 		return err
 	}
 
+	err = info.TableConstraints.generateNames(tableName)
+	if err != nil {
+		return err
+	}
+
+	err = c.resolveForeignKeys(info)
+	if err != nil {
+		return err
+	}
+
 	err = c.cache.AddTable(tx, info)
 	if err != nil {
 		return err
@@ -143,8 +154,68 @@ func (c *Catalog) CreateTable(tx *Transaction, tableName string, info *TableInfo
 	return nil
 }
 
+// resolveForeignKeys ensures the fields referenced by the foreign keys of info
+// can be looked up using a primary key or a unique index.
+// If a foreign key doesn't specify the referenced field, the primary key
+// of the referenced table is used.
+func (c *Catalog) resolveForeignKeys(info *TableInfo) error {
+	for _, tc := range info.TableConstraints {
+		fk := tc.ForeignKey
+		if fk == nil {
+			continue
+		}
+
+		ref := info
+		if fk.Table != info.tableName {
+			var err error
+			ref, err = c.cache.GetTable(fk.Table)
+			if err != nil {
+				return stringutil.Errorf("foreign key %q references table %q: %w", tc.Name, fk.Table, err)
+			}
+		}
+
+		pk := ref.GetPrimaryKey()
+		if fk.Path == nil {
+			if pk == nil {
+				return stringutil.Errorf("foreign key %q references table %q which has no primary key", tc.Name, fk.Table)
+			}
+
+			fk.Path = pk.Path
+			continue
+		}
+
+		if pk != nil && pk.Path.IsEqual(fk.Path) {
+			continue
+		}
+
+		if fc := ref.FieldConstraints.Get(fk.Path); fc != nil && fc.IsUnique {
+			continue
+		}
+
+		var found bool
+		for _, idx := range c.cache.GetTableIndexes(fk.Table) {
+			if idx.Unique && equalPaths(idx.Paths, []document.Path{fk.Path}) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return stringutil.Errorf("foreign key %q references %s(%s) which is neither a primary key nor unique", tc.Name, fk.Table, fk.Path)
+		}
+	}
+
+	return nil
+}
+
 // DropTable deletes a table from the database.
+// Tables referenced by foreign keys of other tables cannot be dropped.
 func (c *Catalog) DropTable(tx *Transaction, tableName string) error {
+	for _, ref := range c.cache.GetReferences(tableName) {
+		if ref.TableName != tableName {
+			return stringutil.Errorf("cannot drop table %q: it is referenced by foreign key %q of table %q", tableName, ref.Constraint.Name, ref.TableName)
+		}
+	}
+
 	ti, removedIndexes, err := c.cache.DeleteTable(tx, tableName)
 	if err != nil {
 		return err
@@ -230,8 +301,23 @@ func (c *Catalog) ListIndexes(tableName string) []string {
 }
 
 // DropIndex deletes an index from the database.
+// Indexes enforcing a UNIQUE field constraint or used to look up
+// the documents referenced by a foreign key cannot be dropped.
 func (c *Catalog) DropIndex(tx *Transaction, name string) error {
-	_, err := c.cache.DeleteIndex(tx, name)
+	info, err := c.cache.GetIndex(name)
+	if err != nil {
+		return err
+	}
+
+	if fc := c.uniqueConstraintOf(info); fc != nil {
+		return stringutil.Errorf("cannot drop index %q: it enforces the UNIQUE constraint on %q", name, fc.Path)
+	}
+
+	if ref := c.foreignKeyUsing(info); ref != nil {
+		return stringutil.Errorf("cannot drop index %q: foreign key %q of table %q depends on it", name, ref.Constraint.Name, ref.TableName)
+	}
+
+	_, err = c.cache.DeleteIndex(tx, name)
 	if err != nil {
 		return err
 	}
@@ -239,6 +325,64 @@ func (c *Catalog) DropIndex(tx *Transaction, name string) error {
 	return c.dropIndex(tx, name)
 }
 
+// uniqueConstraintOf returns the UNIQUE field constraint enforced by the given index,
+// or nil if the index doesn't enforce any or if another unique index does it as well.
+func (c *Catalog) uniqueConstraintOf(info *IndexInfo) *FieldConstraint {
+	if !info.Unique || len(info.Paths) != 1 {
+		return nil
+	}
+
+	ti, err := c.cache.GetTable(info.TableName)
+	if err != nil {
+		return nil
+	}
+
+	fc := ti.FieldConstraints.Get(info.Paths[0])
+	if fc == nil || !fc.IsUnique {
+		return nil
+	}
+
+	for _, idx := range c.cache.GetTableIndexes(info.TableName) {
+		if idx.IndexName != info.IndexName && idx.Unique && equalPaths(idx.Paths, info.Paths) {
+			return nil
+		}
+	}
+
+	return fc
+}
+
+// foreignKeyUsing returns a foreign key whose referenced documents are looked up
+// using the given index, or nil if there is none or if the primary key
+// or another unique index can be used instead.
+func (c *Catalog) foreignKeyUsing(info *IndexInfo) *tableReference {
+	if !info.Unique || len(info.Paths) != 1 {
+		return nil
+	}
+
+	ti, err := c.cache.GetTable(info.TableName)
+	if err != nil {
+		return nil
+	}
+
+	if pk := ti.GetPrimaryKey(); pk != nil && pk.Path.IsEqual(info.Paths[0]) {
+		return nil
+	}
+
+	for _, idx := range c.cache.GetTableIndexes(info.TableName) {
+		if idx.IndexName != info.IndexName && idx.Unique && equalPaths(idx.Paths, info.Paths) {
+			return nil
+		}
+	}
+
+	for _, ref := range c.cache.GetReferences(info.TableName) {
+		if ref.Constraint.ForeignKey.Path.IsEqual(info.Paths[0]) {
+			return &ref
+		}
+	}
+
+	return nil
+}
+
 func (c *Catalog) dropIndex(tx *Transaction, name string) error {
 	indexStore := tx.getIndexStore()
 
@@ -273,6 +417,7 @@ func (c *Catalog) AddFieldConstraint(tx *Transaction, tableName string, fc Field
 func (c *Catalog) RenameTable(tx *Transaction, oldName, newName string) error {
 	newTi, newIdxs, err := c.cache.updateTable(tx, oldName, func(clone *TableInfo) error {
 		clone.tableName = newName
+		clone.TableConstraints.renameReferences(oldName, newName)
 		return nil
 	})
 	if err != nil {
@@ -281,6 +426,22 @@ func (c *Catalog) RenameTable(tx *Transaction, oldName, newName string) error {
 
 	tableStore := tx.getTableStore()
 
+	// update the foreign keys of the tables referencing the renamed table.
+	for _, ref := range c.cache.GetReferences(oldName) {
+		refTi, _, err := c.cache.updateTable(tx, ref.TableName, func(clone *TableInfo) error {
+			clone.TableConstraints.renameReferences(oldName, newName)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tableStore.Replace(tx, ref.TableName, refTi)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Insert the TableInfo keyed by the newName name.
 	err = tableStore.Insert(tx, newName, newTi)
 	if err != nil {
@@ -602,6 +763,34 @@ func (c *catalogCache) GetTableIndexes(tableName string) []*IndexInfo {
 	return c.indexesPerTables[tableName]
 }
 
+// A tableReference is a foreign key of a table referencing another table.
+type tableReference struct {
+	TableName  string
+	Constraint *TableConstraint
+}
+
+// GetReferences returns the foreign keys referencing the given table,
+// sorted by table name.
+func (c *catalogCache) GetReferences(tableName string) []tableReference {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var refs []tableReference
+	for name, ti := range c.tables {
+		for _, tc := range ti.TableConstraints {
+			if tc.ForeignKey != nil && tc.ForeignKey.Table == tableName {
+				refs = append(refs, tableReference{TableName: name, Constraint: tc})
+			}
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].TableName < refs[j].TableName
+	})
+
+	return refs
+}
+
 func (c *catalogCache) updateTable(tx *Transaction, tableName string, fn func(clone *TableInfo) error) (*TableInfo, []*IndexInfo, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -2,12 +2,17 @@ package database_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/tie/genji-release-test"
 	"github.com/tie/genji-release-test/database"
 	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/document/encoding/msgpack"
+	"github.com/tie/genji-release-test/engine/memoryengine"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/sql/parser"
 	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -265,6 +270,89 @@ func TestCatalogCreate(t *testing.T) {
 		check()
 	})
 
+	t.Run("Table constraints are persisted", func(t *testing.T) {
+		ng := memoryengine.NewEngine()
+		db, err := database.New(context.Background(), ng, database.Options{Codec: msgpack.NewCodec()})
+		require.NoError(t, err)
+
+		update(t, db, func(tx *database.Transaction) error {
+			return db.Catalog().CreateTable(tx, "test", &database.TableInfo{
+				FieldConstraints: []*database.FieldConstraint{
+					{Path: parsePath(t, "foo"), Type: document.IntegerValue, IsUnique: true},
+					{Path: parsePath(t, "bar"), Type: document.IntegerValue, GeneratedExpr: expr.Constraint(parser.MustParseExpr("foo * 2"))},
+					{Path: parsePath(t, "baz"), DefaultValue: expr.Constraint(parser.MustParseExpr("(foo + 1)"))},
+				},
+				TableConstraints: []*database.TableConstraint{
+					{Check: expr.Constraint(parser.MustParseExpr("foo > 0"))},
+					{Check: expr.Constraint(parser.MustParseExpr("foo < 10"))},
+				},
+			})
+		})
+
+		// reopen the database to reload the catalog
+		db, err = database.New(context.Background(), ng, database.Options{Codec: msgpack.NewCodec()})
+		require.NoError(t, err)
+		defer db.Close()
+
+		update(t, db, func(tx *database.Transaction) error {
+			tb, err := tx.GetTable("test")
+			require.NoError(t, err)
+
+			info := tb.Info()
+			require.True(t, info.FieldConstraints[0].IsUnique)
+			require.Equal(t, expr.Constraint(parser.MustParseExpr("foo * 2")), info.FieldConstraints[1].GeneratedExpr)
+			require.Equal(t, expr.Constraint(parser.MustParseExpr("(foo + 1)")), info.FieldConstraints[2].DefaultValue)
+			require.Equal(t, database.TableConstraints{
+				{Name: "test_check", Check: expr.Constraint(parser.MustParseExpr("foo > 0"))},
+				{Name: "test_check1", Check: expr.Constraint(parser.MustParseExpr("foo < 10"))},
+			}, info.TableConstraints)
+			return nil
+		})
+	})
+
+	t.Run("Constraint expressions are reloaded unchanged", func(t *testing.T) {
+		ng := memoryengine.NewEngine()
+		db, err := database.New(context.Background(), ng, database.Options{Codec: msgpack.NewCodec()})
+		require.NoError(t, err)
+
+		checks := []string{"NOT d IS NULL", "`we ird` > 0", "`select` IS NOT NULL", "`a.b`.c != 'x'"}
+		generated := "`we ird` * 2"
+		def := "`select` + 1"
+
+		update(t, db, func(tx *database.Transaction) error {
+			info := database.TableInfo{
+				FieldConstraints: []*database.FieldConstraint{
+					{Path: document.NewPath("gen"), GeneratedExpr: expr.Constraint(parser.MustParseExpr(generated))},
+					{Path: document.NewPath("def"), DefaultValue: expr.Constraint(parser.MustParseExpr(def))},
+				},
+			}
+			for _, c := range checks {
+				info.TableConstraints = append(info.TableConstraints, &database.TableConstraint{Check: expr.Constraint(parser.MustParseExpr(c))})
+			}
+
+			return db.Catalog().CreateTable(tx, "test", &info)
+		})
+
+		// reopen the database to reload the catalog
+		db, err = database.New(context.Background(), ng, database.Options{Codec: msgpack.NewCodec()})
+		require.NoError(t, err)
+		defer db.Close()
+
+		update(t, db, func(tx *database.Transaction) error {
+			tb, err := tx.GetTable("test")
+			require.NoError(t, err)
+
+			info := tb.Info()
+			require.Equal(t, expr.Constraint(parser.MustParseExpr(generated)), info.FieldConstraints[0].GeneratedExpr)
+			require.Equal(t, expr.Constraint(parser.MustParseExpr(def)), info.FieldConstraints[1].DefaultValue)
+			require.Len(t, info.TableConstraints, len(checks))
+			for i, c := range checks {
+				require.Equal(t, expr.Constraint(parser.MustParseExpr(c)), info.TableConstraints[i].Check)
+			}
+			return nil
+		})
+	})
+
 	t.Run("Invalid constraints", func(t *testing.T) {
 		db, cleanup := newTestDB(t)
 		defer cleanup()
@@ -466,6 +554,74 @@ func TestTxDropIndex(t *testing.T) {
 			return nil
 		})
 	})
+
+	t.Run("Should fail if it enforces a unique constraint", func(t *testing.T) {
+		db, cleanup := newTestDB(t)
+		defer cleanup()
+		catalog := db.Catalog()
+
+		update(t, db, func(tx *database.Transaction) error {
+			err := catalog.CreateTable(tx, "test", &database.TableInfo{
+				FieldConstraints: []*database.FieldConstraint{
+					{Path: parsePath(t, "foo"), IsUnique: true},
+				},
+			})
+			require.NoError(t, err)
+			err = catalog.CreateIndex(tx, &database.IndexInfo{
+				IndexName: "idxFoo", TableName: "test", Paths: []document.Path{parsePath(t, "foo")}, Unique: true,
+			})
+			require.NoError(t, err)
+			err = catalog.CreateIndex(tx, &database.IndexInfo{
+				IndexName: "idxFoo2", TableName: "test", Paths: []document.Path{parsePath(t, "foo")}, Unique: true,
+			})
+			require.NoError(t, err)
+			return nil
+		})
+
+		update(t, db, func(tx *database.Transaction) error {
+			// another unique index still enforces the constraint
+			err := catalog.DropIndex(tx, "idxFoo2")
+			require.NoError(t, err)
+
+			err = catalog.DropIndex(tx, "idxFoo")
+			require.Error(t, err)
+			return nil
+		})
+	})
+
+	t.Run("Should fail if a foreign key depends on it", func(t *testing.T) {
+		db, cleanup := newTestDB(t)
+		defer cleanup()
+		catalog := db.Catalog()
+
+		update(t, db, func(tx *database.Transaction) error {
+			err := catalog.CreateTable(tx, "parent", nil)
+			require.NoError(t, err)
+			err = catalog.CreateIndex(tx, &database.IndexInfo{
+				IndexName: "idxCode", TableName: "parent", Paths: []document.Path{parsePath(t, "code")}, Unique: true,
+			})
+			require.NoError(t, err)
+			err = catalog.CreateIndex(tx, &database.IndexInfo{
+				IndexName: "idxCode2", TableName: "parent", Paths: []document.Path{parsePath(t, "code")}, Unique: true,
+			})
+			require.NoError(t, err)
+			return catalog.CreateTable(tx, "child", &database.TableInfo{
+				TableConstraints: []*database.TableConstraint{
+					{Path: parsePath(t, "code"), ForeignKey: &database.ForeignKey{Table: "parent", Path: parsePath(t, "code")}},
+				},
+			})
+		})
+
+		update(t, db, func(tx *database.Transaction) error {
+			// another unique index can still be used by the foreign key
+			err := catalog.DropIndex(tx, "idxCode2")
+			require.NoError(t, err)
+
+			err = catalog.DropIndex(tx, "idxCode")
+			require.Error(t, err)
+			return nil
+		})
+	})
 }
 
 func TestCatalogReIndex(t *testing.T) {
@@ -20,6 +20,7 @@ type TableInfo struct {
 	readOnly  bool
 
 	FieldConstraints FieldConstraints
+	TableConstraints TableConstraints
 }
 
 // GetPrimaryKey returns the field constraint of the primary key.
@@ -48,6 +49,15 @@ func (ti *TableInfo) ToDocument() document.Document {
 
 	buf.Add("field_constraints", document.NewArrayValue(vbuf))
 
+	if len(ti.TableConstraints) > 0 {
+		vbuf = document.NewValueBuffer()
+		for _, tc := range ti.TableConstraints {
+			vbuf = vbuf.Append(document.NewDocumentValue(tc.ToDocument()))
+		}
+
+		buf.Add("table_constraints", document.NewArrayValue(vbuf))
+	}
+
 	buf.Add("read_only", document.NewBoolValue(ti.readOnly))
 	return buf
 }
@@ -94,6 +104,27 @@ func (ti *TableInfo) ScanDocument(d document.Document) error {
 		return err
 	}
 
+	v, err = d.GetByField("table_constraints")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		ti.TableConstraints = nil
+		err = v.V.(document.Array).Iterate(func(i int, value document.Value) error {
+			var tc TableConstraint
+			err := tc.ScanDocument(value.V.(document.Document))
+			if err != nil {
+				return err
+			}
+
+			ti.TableConstraints = append(ti.TableConstraints, &tc)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	v, err = d.GetByField("read_only")
 	if err != nil {
 		return err
@@ -108,6 +139,8 @@ func (ti *TableInfo) Clone() *TableInfo {
 	cp := *ti
 	cp.FieldConstraints = nil
 	cp.FieldConstraints = append(cp.FieldConstraints, ti.FieldConstraints...)
+	cp.TableConstraints = nil
+	cp.TableConstraints = append(cp.TableConstraints, ti.TableConstraints...)
 	return &cp
 }
 
@@ -13,10 +13,15 @@ type FieldConstraint struct {
 	Type         document.ValueType
 	IsPrimaryKey bool
 	IsNotNull    bool
-	IsUnique     bool // not stored, only set during table creation
-	DefaultValue document.Value
-	IsInferred   bool
-	InferredBy   []document.Path
+	IsUnique     bool
+	// DefaultValue is the expression evaluated to compute the value
+	// of the field when it is missing from an inserted document.
+	DefaultValue TableExpression
+	// GeneratedExpr is the expression used to compute the value
+	// of a generated field every time the document is written.
+	GeneratedExpr TableExpression
+	IsInferred    bool
+	InferredBy    []document.Path
 }
 
 // IsEqual compares f with other member by member.
@@ -38,14 +43,24 @@ func (f *FieldConstraint) IsEqual(other *FieldConstraint) (bool, error) {
 		return false, nil
 	}
 
+	if f.IsUnique != other.IsUnique {
+		return false, nil
+	}
+
 	if f.HasDefaultValue() != other.HasDefaultValue() {
 		return false, nil
 	}
 
-	if f.HasDefaultValue() {
-		if ok, err := f.DefaultValue.IsEqual(other.DefaultValue); !ok || err != nil {
-			return ok, err
-		}
+	if f.HasDefaultValue() && f.DefaultValue.String() != other.DefaultValue.String() {
+		return false, nil
+	}
+
+	if f.IsGenerated() != other.IsGenerated() {
+		return false, nil
+	}
+
+	if f.IsGenerated() && f.GeneratedExpr.String() != other.GeneratedExpr.String() {
+		return false, nil
 	}
 
 	return true, nil
@@ -63,12 +78,21 @@ func (f *FieldConstraint) String() string {
 	if f.IsPrimaryKey {
 		s.WriteString(" PRIMARY KEY")
 	}
+	if f.IsUnique {
+		s.WriteString(" UNIQUE")
+	}
 
 	if f.HasDefaultValue() {
 		s.WriteString(" DEFAULT ")
 		s.WriteString(f.DefaultValue.String())
 	}
 
+	if f.IsGenerated() {
+		s.WriteString(" AS (")
+		s.WriteString(f.GeneratedExpr.String())
+		s.WriteString(") STORED")
+	}
+
 	return s.String()
 }
 
@@ -91,7 +115,12 @@ func (f *FieldConstraint) MergeInferred(other *FieldConstraint) {
 
 // HasDefaultValue returns this field contains a default value constraint.
 func (f *FieldConstraint) HasDefaultValue() bool {
-	return f.DefaultValue.Type != 0
+	return f.DefaultValue != nil
+}
+
+// IsGenerated returns true if the value of the field is computed from an expression.
+func (f *FieldConstraint) IsGenerated() bool {
+	return f.GeneratedExpr != nil
 }
 
 // ToDocument returns a document from f.
@@ -102,8 +131,12 @@ func (f *FieldConstraint) ToDocument() document.Document {
 	buf.Add("type", document.NewIntegerValue(int64(f.Type)))
 	buf.Add("is_primary_key", document.NewBoolValue(f.IsPrimaryKey))
 	buf.Add("is_not_null", document.NewBoolValue(f.IsNotNull))
+	buf.Add("is_unique", document.NewBoolValue(f.IsUnique))
 	if f.HasDefaultValue() {
-		buf.Add("default_value", f.DefaultValue)
+		buf.Add("default_expr", document.NewTextValue(f.DefaultValue.String()))
+	}
+	if f.IsGenerated() {
+		buf.Add("generated_expr", document.NewTextValue(f.GeneratedExpr.String()))
 	}
 	buf.Add("is_inferred", document.NewBoolValue(f.IsInferred))
 	if f.IsInferred {
@@ -146,12 +179,46 @@ func (f *FieldConstraint) ScanDocument(d document.Document) error {
 	}
 	f.IsNotNull = v.V.(bool)
 
+	v, err = d.GetByField("is_unique")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.IsUnique = v.V.(bool)
+	}
+
+	v, err = d.GetByField("default_expr")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.DefaultValue, err = parseTableExpression(v.V.(string))
+		if err != nil {
+			return err
+		}
+	}
+
+	// default values used to be stored as constant values
 	v, err = d.GetByField("default_value")
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
 	}
 	if err == nil {
-		f.DefaultValue = v
+		f.DefaultValue, err = parseTableExpression(v.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	v, err = d.GetByField("generated_expr")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.GeneratedExpr, err = parseTableExpression(v.V.(string))
+		if err != nil {
+			return err
+		}
 	}
 
 	v, err = d.GetByField("is_inferred")
@@ -276,6 +343,8 @@ func (f *FieldConstraints) Add(newFc *FieldConstraint) error {
 			inferredFc.DefaultValue = nonInferredFc.DefaultValue
 			inferredFc.IsNotNull = nonInferredFc.IsNotNull
 			inferredFc.IsPrimaryKey = nonInferredFc.IsPrimaryKey
+			inferredFc.IsUnique = nonInferredFc.IsUnique
+			inferredFc.GeneratedExpr = nonInferredFc.GeneratedExpr
 
 			// safe-guard in case we add more fields to the struct
 			ok, err := c.IsEqual(newFc)
@@ -314,18 +383,16 @@ func (f *FieldConstraints) Add(newFc *FieldConstraint) error {
 		}
 	}
 
-	// convert default values to the right types
-	targetType := newFc.Type
-
-	// if there is no type constraint, numbers must be converted to double
-	if newFc.DefaultValue.Type == document.IntegerValue && newFc.Type == 0 {
-		targetType = document.DoubleValue
-	}
-	if newFc.DefaultValue.Type != 0 && targetType != 0 {
-		var err error
-		newFc.DefaultValue, err = newFc.DefaultValue.CastAs(targetType)
-		if err != nil {
-			return err
+	// default values are evaluated for every inserted document,
+	// but those that don't depend on the document can already
+	// be checked against the type of the field.
+	if newFc.HasDefaultValue() && newFc.Type != 0 {
+		v, err := newFc.DefaultValue.Eval(nil, document.NewFieldBuffer())
+		if err == nil && v.Type != document.NullValue {
+			_, err = v.CastAs(newFc.Type)
+			if err != nil {
+				return stringutil.Errorf("default value %s cannot be converted to type %q", newFc.DefaultValue, newFc.Type)
+			}
 		}
 	}
 
@@ -334,7 +401,9 @@ func (f *FieldConstraints) Add(newFc *FieldConstraint) error {
 }
 
 // ValidateDocument calls Convert then ensures the document validates against the field constraints.
-func (f FieldConstraints) ValidateDocument(d document.Document) (*document.FieldBuffer, error) {
+// Default values are evaluated against the document, in the order the fields were declared.
+// Generated fields are computed once default values have been applied.
+func (f FieldConstraints) ValidateDocument(tx *Transaction, d document.Document) (*document.FieldBuffer, error) {
 	fb, err := f.ConvertDocument(d)
 	if err != nil {
 		return nil, err
@@ -342,6 +411,10 @@ func (f FieldConstraints) ValidateDocument(d document.Document) (*document.Field
 
 	// ensure no field is missing
 	for _, fc := range f {
+		if fc.IsGenerated() {
+			continue
+		}
+
 		v, err := fc.Path.GetValueFromDocument(fb)
 		if err == nil {
 			// if field is found, it has already been converted
@@ -360,8 +433,22 @@ func (f FieldConstraints) ValidateDocument(d document.Document) (*document.Field
 
 		// if field is not found
 		// check if there is a default value
-		if fc.DefaultValue.Type != 0 {
-			err = fb.Set(fc.Path, fc.DefaultValue)
+		if fc.HasDefaultValue() {
+			v, err = fc.DefaultValue.Eval(tx, fb)
+			if err != nil {
+				return nil, err
+			}
+
+			v, err = f.ConvertValueAtPath(fc.Path, v, CastConversion)
+			if err != nil {
+				return nil, err
+			}
+
+			if v.Type == document.NullValue && fc.IsNotNull {
+				return nil, stringutil.Errorf("field %q is required and must be not null", fc.Path)
+			}
+
+			err = fb.Set(fc.Path, v)
 			if err != nil {
 				return nil, err
 			}
@@ -372,9 +459,84 @@ func (f FieldConstraints) ValidateDocument(d document.Document) (*document.Field
 		}
 	}
 
+	// compute generated fields in the order they were declared,
+	// so that a generated field may depend on the previous ones.
+	for _, fc := range f {
+		if !fc.IsGenerated() {
+			continue
+		}
+
+		v, err := fc.GeneratedExpr.Eval(tx, fb)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err = f.ConvertValueAtPath(fc.Path, v, CastConversion)
+		if err != nil {
+			return nil, err
+		}
+
+		if v.Type == document.NullValue && fc.IsNotNull {
+			return nil, stringutil.Errorf("field %q is required and must be not null", fc.Path)
+		}
+
+		err = fb.Set(fc.Path, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return fb, nil
 }
 
+// validateGeneratedFields ensures d doesn't set the value of generated fields.
+// If old is not nil, d is a new version of old and the generated fields
+// must be left untouched.
+func (f FieldConstraints) validateGeneratedFields(old, d document.Document) error {
+	for _, fc := range f {
+		if !fc.IsGenerated() {
+			continue
+		}
+
+		v, err := fc.Path.GetValueFromDocument(d)
+		if err != nil && err != document.ErrFieldNotFound {
+			return err
+		}
+		found := err == nil
+
+		if old == nil {
+			if found {
+				return stringutil.Errorf("cannot write to generated field %q", fc.Path)
+			}
+
+			continue
+		}
+
+		oldV, err := fc.Path.GetValueFromDocument(old)
+		if err != nil && err != document.ErrFieldNotFound {
+			return err
+		}
+
+		if found != (err == nil) {
+			return stringutil.Errorf("cannot write to generated field %q", fc.Path)
+		}
+
+		if !found {
+			continue
+		}
+
+		ok, err := v.IsEqual(oldV)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return stringutil.Errorf("cannot write to generated field %q", fc.Path)
+		}
+	}
+
+	return nil
+}
+
 // ConvertDocument the document using the field constraints.
 // It converts any path that has a field constraint on it into the specified type using CAST.
 // If there is no constraint on an integer field or value, it converts it into a double.
@@ -486,3 +648,316 @@ func (f FieldConstraints) convertArrayAtPath(path document.Path, a document.Arra
 
 	return vb, err
 }
+
+// TableExpression is an expression evaluated against the documents of a table,
+// such as the condition of a CHECK constraint.
+type TableExpression interface {
+	Eval(tx *Transaction, d document.Document) (document.Value, error)
+	String() string
+}
+
+// ParseTableExpression parses the textual representation of a TableExpression,
+// as stored in the catalog. It is set by the sql/parser package.
+var ParseTableExpression func(s string) (TableExpression, error)
+
+func parseTableExpression(s string) (TableExpression, error) {
+	if ParseTableExpression == nil {
+		return nil, stringutil.Errorf("cannot parse expression %q: no parser registered", s)
+	}
+
+	return ParseTableExpression(s)
+}
+
+// TableConstraint describes a constraint on a table.
+// It is either a CHECK constraint or a FOREIGN KEY constraint.
+type TableConstraint struct {
+	// Name of the constraint, used in error messages.
+	// If empty, a name is generated when the table is created.
+	Name string
+	// Path of the field the constraint was declared on,
+	// nil if it was declared at the table level.
+	// For foreign keys, it is the path of the referencing field.
+	Path       document.Path
+	Check      TableExpression
+	ForeignKey *ForeignKey
+}
+
+func (t *TableConstraint) String() string {
+	var s strings.Builder
+
+	s.WriteString("CONSTRAINT ")
+	s.WriteString(t.Name)
+
+	if t.ForeignKey != nil {
+		s.WriteString(" FOREIGN KEY (")
+		s.WriteString(t.Path.String())
+		s.WriteString(") ")
+		s.WriteString(t.ForeignKey.String())
+		return s.String()
+	}
+
+	s.WriteString(" CHECK (")
+	s.WriteString(t.Check.String())
+	s.WriteString(")")
+
+	return s.String()
+}
+
+// ToDocument returns a document from t.
+func (t *TableConstraint) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("name", document.NewTextValue(t.Name))
+	if t.Path != nil {
+		buf.Add("path", document.NewArrayValue(pathToArray(t.Path)))
+	}
+	if t.Check != nil {
+		buf.Add("check", document.NewTextValue(t.Check.String()))
+	}
+	if t.ForeignKey != nil {
+		buf.Add("foreign_key", document.NewDocumentValue(t.ForeignKey.ToDocument()))
+	}
+	return buf
+}
+
+// ScanDocument implements the document.Scanner interface.
+func (t *TableConstraint) ScanDocument(d document.Document) error {
+	v, err := d.GetByField("name")
+	if err != nil {
+		return err
+	}
+	t.Name = v.V.(string)
+
+	v, err = d.GetByField("path")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		t.Path, err = arrayToPath(v.V.(document.Array))
+		if err != nil {
+			return err
+		}
+	}
+
+	v, err = d.GetByField("check")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		t.Check, err = parseTableExpression(v.V.(string))
+		if err != nil {
+			return err
+		}
+	}
+
+	v, err = d.GetByField("foreign_key")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		t.ForeignKey = new(ForeignKey)
+		return t.ForeignKey.ScanDocument(v.V.(document.Document))
+	}
+
+	return nil
+}
+
+// Validate evaluates the CHECK expression against d and returns an error
+// naming the constraint if it evaluates to false.
+// As in SQL, a NULL result satisfies the constraint.
+// Foreign keys are not validated by this method.
+func (t *TableConstraint) Validate(tx *Transaction, d document.Document) error {
+	if t.Check == nil {
+		return nil
+	}
+
+	v, err := t.Check.Eval(tx, d)
+	if err != nil {
+		return err
+	}
+
+	if v.Type == document.NullValue {
+		return nil
+	}
+
+	ok, err := v.IsTruthy()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return stringutil.Errorf("document violates check constraint %q", t.Name)
+	}
+
+	return nil
+}
+
+// ForeignKeyAction is the action taken on the referencing documents
+// when a referenced document is deleted.
+type ForeignKeyAction uint8
+
+// List of supported foreign key actions.
+const (
+	// ForeignKeyRestrict prevents the deletion of referenced documents.
+	ForeignKeyRestrict ForeignKeyAction = iota
+	// ForeignKeyCascade deletes the referencing documents.
+	ForeignKeyCascade
+	// ForeignKeySetNull sets the referencing field to NULL.
+	ForeignKeySetNull
+)
+
+func (a ForeignKeyAction) String() string {
+	switch a {
+	case ForeignKeyCascade:
+		return "CASCADE"
+	case ForeignKeySetNull:
+		return "SET NULL"
+	}
+
+	return "RESTRICT"
+}
+
+// ForeignKey describes the field referenced by a FOREIGN KEY constraint.
+type ForeignKey struct {
+	// Name of the referenced table.
+	Table string
+	// Path of the referenced field. It must be the primary key
+	// of the referenced table or have a unique index.
+	// If nil, the primary key of the referenced table is used.
+	Path     document.Path
+	OnDelete ForeignKeyAction
+}
+
+func (f *ForeignKey) String() string {
+	var s strings.Builder
+
+	s.WriteString("REFERENCES ")
+	s.WriteString(f.Table)
+	if f.Path != nil {
+		s.WriteString(" (")
+		s.WriteString(f.Path.String())
+		s.WriteString(")")
+	}
+	s.WriteString(" ON DELETE ")
+	s.WriteString(f.OnDelete.String())
+
+	return s.String()
+}
+
+// ToDocument returns a document from f.
+func (f *ForeignKey) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("table_name", document.NewTextValue(f.Table))
+	buf.Add("path", document.NewArrayValue(pathToArray(f.Path)))
+	buf.Add("on_delete", document.NewIntegerValue(int64(f.OnDelete)))
+	return buf
+}
+
+// ScanDocument implements the document.Scanner interface.
+func (f *ForeignKey) ScanDocument(d document.Document) error {
+	v, err := d.GetByField("table_name")
+	if err != nil {
+		return err
+	}
+	f.Table = v.V.(string)
+
+	v, err = d.GetByField("path")
+	if err != nil {
+		return err
+	}
+	f.Path, err = arrayToPath(v.V.(document.Array))
+	if err != nil {
+		return err
+	}
+
+	v, err = d.GetByField("on_delete")
+	if err != nil {
+		return err
+	}
+	f.OnDelete = ForeignKeyAction(v.V.(int64))
+	return nil
+}
+
+// TableConstraints is a list of table constraints.
+type TableConstraints []*TableConstraint
+
+// Get a table constraint by name. Returns nil if not found.
+func (t TableConstraints) Get(name string) *TableConstraint {
+	for _, tc := range t {
+		if tc.Name == name {
+			return tc
+		}
+	}
+
+	return nil
+}
+
+// ValidateDocument ensures d satisfies every CHECK constraint of the list.
+func (t TableConstraints) ValidateDocument(tx *Transaction, d document.Document) error {
+	for _, tc := range t {
+		err := tc.Validate(tx, d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renameReferences makes the foreign keys referencing oldName reference newName.
+// Constraints are copied before being modified, as they may be shared with other TableInfo.
+func (t TableConstraints) renameReferences(oldName, newName string) {
+	for i, tc := range t {
+		if tc.ForeignKey == nil || tc.ForeignKey.Table != oldName {
+			continue
+		}
+
+		fk := *tc.ForeignKey
+		fk.Table = newName
+		cp := *tc
+		cp.ForeignKey = &fk
+		t[i] = &cp
+	}
+}
+
+// generateNames ensures constraint names are unique and names the constraints
+// that were declared without one. Field-level CHECK constraints are named <table>_<path>_check,
+// table-level ones <table>_check and foreign keys <table>_<path>_fkey, followed by a number
+// if the name is already taken.
+func (t TableConstraints) generateNames(tableName string) error {
+	for i, tc := range t {
+		if tc.Name == "" {
+			continue
+		}
+
+		for _, other := range t[:i] {
+			if other.Name == tc.Name {
+				return stringutil.Errorf("duplicate constraint name %q", tc.Name)
+			}
+		}
+	}
+
+	for _, tc := range t {
+		if tc.Name != "" {
+			continue
+		}
+
+		base := tableName
+		if tc.Path != nil {
+			base += "_" + tc.Path.String()
+		}
+		if tc.ForeignKey != nil {
+			base += "_fkey"
+		} else {
+			base += "_check"
+		}
+
+		name := base
+		for i := 1; t.Get(name) != nil; i++ {
+			name = stringutil.Sprintf("%s%d", base, i)
+		}
+		tc.Name = name
+	}
+
+	return nil
+}
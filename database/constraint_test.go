@@ -6,6 +6,8 @@ import (
 
 	"github.com/tie/genji-release-test/database"
 	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/sql/parser"
 	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -198,22 +200,29 @@ func TestFieldConstraintsAdd(t *testing.T) {
 			false,
 		},
 		{
-			"Default value conversion, typed constraint",
+			"Default value, typed constraint",
 			[]*database.FieldConstraint{{Path: document.NewPath("a"), Type: document.IntegerValue}},
-			database.FieldConstraint{Path: document.NewPath("b"), Type: document.IntegerValue, DefaultValue: document.NewDoubleValue(5)},
+			database.FieldConstraint{Path: document.NewPath("b"), Type: document.IntegerValue, DefaultValue: expr.Constraint(parser.MustParseExpr("5.0"))},
 			[]*database.FieldConstraint{
 				{Path: document.NewPath("a"), Type: document.IntegerValue},
-				{Path: document.NewPath("b"), Type: document.IntegerValue, DefaultValue: document.NewIntegerValue(5)},
+				{Path: document.NewPath("b"), Type: document.IntegerValue, DefaultValue: expr.Constraint(parser.MustParseExpr("5.0"))},
 			},
 			false,
 		},
 		{
-			"Default value conversion, untyped constraint",
+			"Default value, incompatible type",
 			[]*database.FieldConstraint{{Path: document.NewPath("a"), Type: document.IntegerValue}},
-			database.FieldConstraint{Path: document.NewPath("b"), DefaultValue: document.NewIntegerValue(5)},
+			database.FieldConstraint{Path: document.NewPath("b"), Type: document.BoolValue, DefaultValue: expr.Constraint(parser.MustParseExpr(`"foo"`))},
+			nil,
+			true,
+		},
+		{
+			"Default value depending on the document",
+			[]*database.FieldConstraint{{Path: document.NewPath("a"), Type: document.IntegerValue}},
+			database.FieldConstraint{Path: document.NewPath("b"), Type: document.IntegerValue, DefaultValue: expr.Constraint(parser.MustParseExpr("(a + 1)"))},
 			[]*database.FieldConstraint{
 				{Path: document.NewPath("a"), Type: document.IntegerValue},
-				{Path: document.NewPath("b"), DefaultValue: document.NewDoubleValue(5)},
+				{Path: document.NewPath("b"), Type: document.IntegerValue, DefaultValue: expr.Constraint(parser.MustParseExpr("(a + 1)"))},
 			},
 			false,
 		},
@@ -282,7 +291,7 @@ func TestFieldConstraintsConvert(t *testing.T) {
 			true,
 		},
 		{
-			database.FieldConstraints{{Path: document.NewPath("a"), DefaultValue: document.NewIntegerValue(10)}},
+			database.FieldConstraints{{Path: document.NewPath("a"), DefaultValue: expr.Constraint(parser.MustParseExpr("10"))}},
 			document.NewPath("a"),
 			document.NewTextValue("foo"),
 			document.NewTextValue("foo"),
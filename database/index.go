@@ -203,6 +203,38 @@ func (idx *Index) Set(vs []document.Value, k []byte) error {
 	return st.Put(buf, k)
 }
 
+// lookupUnique returns the key associated with the given values in a unique index.
+// It returns engine.ErrKeyNotFound if the values are not indexed.
+func (idx *Index) lookupUnique(vs []document.Value) ([]byte, error) {
+	if !idx.Info.Unique {
+		return nil, errors.New("cannot lookup values in a non unique index")
+	}
+
+	// values of the wrong type cannot be indexed by a typed index
+	for i, typ := range idx.Info.Types {
+		if !typ.IsAny() && typ != vs[i].Type {
+			return nil, engine.ErrKeyNotFound
+		}
+	}
+
+	st, err := getOrCreateStore(idx.tx, idx.storeName)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := idx.EncodeValueBuffer(document.NewValueBuffer(vs...))
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := st.Get(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(nil), k...), nil
+}
+
 // Delete all the references to the key from the index.
 func (idx *Index) Delete(vs []document.Value, k []byte) error {
 	st, err := getOrCreateStore(idx.tx, idx.storeName)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"strings"
 
 	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/document/encoding"
@@ -61,7 +62,22 @@ func (t *Table) Insert(d document.Document) (document.Document, error) {
 		return nil, errors.New("cannot write to read-only table")
 	}
 
-	fb, err := info.FieldConstraints.ValidateDocument(d)
+	err := info.FieldConstraints.validateGeneratedFields(nil, d)
+	if err != nil {
+		return nil, err
+	}
+
+	fb, err := info.FieldConstraints.ValidateDocument(t.tx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	err = info.TableConstraints.ValidateDocument(t.tx, fb)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.validateForeignKeys(info, fb)
 	if err != nil {
 		return nil, err
 	}
@@ -120,8 +136,97 @@ func (t *Table) Insert(d document.Document) (document.Document, error) {
 	}, nil
 }
 
+// Conflict returns the key of the document that prevents d from being inserted,
+// either because it has the same primary key or the same values in a unique index.
+// If paths are provided, only the primary key or the unique index defined on these paths
+// are checked, and an error is returned if there is none.
+// If there is no conflict, it returns a nil key.
+func (t *Table) Conflict(d document.Document, paths ...document.Path) ([]byte, error) {
+	info := t.Info()
+
+	err := info.FieldConstraints.validateGeneratedFields(nil, d)
+	if err != nil {
+		return nil, err
+	}
+
+	fb, err := info.FieldConstraints.ValidateDocument(t.tx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var found bool
+
+	if pk := info.GetPrimaryKey(); pk != nil && (len(paths) == 0 || equalPaths(paths, []document.Path{pk.Path})) {
+		found = true
+
+		key, err := t.generateKey(info, fb)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = t.Store.Get(key)
+		if err == nil {
+			return key, nil
+		}
+		if err != engine.ErrKeyNotFound {
+			return nil, err
+		}
+	}
+
+	for _, idx := range t.Indexes() {
+		if !idx.Info.Unique || (len(paths) > 0 && !equalPaths(paths, idx.Info.Paths)) {
+			continue
+		}
+		found = true
+
+		vs := make([]document.Value, 0, len(idx.Info.Paths))
+		for _, path := range idx.Info.Paths {
+			v, err := path.GetValueFromDocument(fb)
+			if err != nil {
+				v = document.NewNullValue()
+			}
+
+			vs = append(vs, v)
+		}
+
+		key, err := idx.lookupUnique(vs)
+		if err == nil {
+			return key, nil
+		}
+		if err != engine.ErrKeyNotFound {
+			return nil, err
+		}
+	}
+
+	if !found && len(paths) > 0 {
+		names := make([]string, len(paths))
+		for i, p := range paths {
+			names[i] = p.String()
+		}
+		return nil, stringutil.Errorf("no primary key or unique index on (%s)", strings.Join(names, ", "))
+	}
+
+	return nil, nil
+}
+
+func equalPaths(a, b []document.Path) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].IsEqual(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Delete a document by key.
 // Indexes are automatically updated.
+// Documents referencing the deleted document through a foreign key are
+// handled according to the ON DELETE action of the constraint.
 func (t *Table) Delete(key []byte) error {
 	info := t.Info()
 
@@ -134,6 +239,13 @@ func (t *Table) Delete(key []byte) error {
 		return err
 	}
 
+	// the referencing documents must be looked up
+	// before the document is deleted.
+	refs, err := t.referencingDocuments(key, d)
+	if err != nil {
+		return err
+	}
+
 	indexes := t.Indexes()
 
 	for _, idx := range indexes {
@@ -157,7 +269,19 @@ func (t *Table) Delete(key []byte) error {
 		}
 	}
 
-	return t.Store.Delete(key)
+	err = t.Store.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		err = ref.apply()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Replace a document by key.
@@ -170,19 +294,43 @@ func (t *Table) Replace(key []byte, d document.Document) error {
 		return errors.New("cannot write to read-only table")
 	}
 
-	d, err := info.FieldConstraints.ValidateDocument(d)
+	// make sure key exists
+	old, err := t.GetDocument(key)
+	if err != nil {
+		return err
+	}
+
+	// generated fields cannot be modified
+	err = info.FieldConstraints.validateGeneratedFields(old, d)
+	if err != nil {
+		return err
+	}
+
+	d, err = info.FieldConstraints.ValidateDocument(t.tx, d)
 	if err != nil {
 		return err
 	}
 
 	indexes := t.Indexes()
 
-	return t.replace(indexes, key, d)
+	return t.replace(indexes, key, old, d)
 }
 
-func (t *Table) replace(indexes []*Index, key []byte, d document.Document) error {
-	// make sure key exists
-	old, err := t.GetDocument(key)
+func (t *Table) replace(indexes []*Index, key []byte, old, d document.Document) error {
+	info := t.Info()
+
+	err := info.TableConstraints.ValidateDocument(t.tx, d)
+	if err != nil {
+		return err
+	}
+
+	err = t.validateForeignKeys(info, d)
+	if err != nil {
+		return err
+	}
+
+	// referenced values cannot be modified
+	err = t.validateReferencedValues(key, old, d)
 	if err != nil {
 		return err
 	}
@@ -244,6 +392,287 @@ func (t *Table) replace(indexes []*Index, key []byte, d document.Document) error
 	return nil
 }
 
+// validateForeignKeys ensures the documents referenced by d through
+// the foreign keys of the table exist.
+func (t *Table) validateForeignKeys(info *TableInfo, d document.Document) error {
+	for _, tc := range info.TableConstraints {
+		fk := tc.ForeignKey
+		if fk == nil {
+			continue
+		}
+
+		v, err := tc.Path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if v.Type == document.NullValue {
+			continue
+		}
+
+		// a document may reference itself
+		if fk.Table == t.name {
+			rv, err := fk.Path.GetValueFromDocument(d)
+			if err == nil {
+				ok, err := rv.IsEqual(v)
+				if err != nil {
+					return err
+				}
+				if ok {
+					continue
+				}
+			}
+		}
+
+		ref, err := t.tx.GetTable(fk.Table)
+		if err != nil {
+			return err
+		}
+
+		_, err = ref.lookup(fk.Path, v)
+		if err == engine.ErrKeyNotFound {
+			return stringutil.Errorf("document violates foreign key constraint %q", tc.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookup returns the key of the document whose value at path is v,
+// using the primary key or a unique index defined on path.
+// It returns engine.ErrKeyNotFound if there is no such document.
+func (t *Table) lookup(path document.Path, v document.Value) ([]byte, error) {
+	info := t.Info()
+
+	// convert the value as if it was stored in this table
+	v, err := info.FieldConstraints.ConvertValueAtPath(path, v, CastConversion)
+	if err != nil {
+		return nil, engine.ErrKeyNotFound
+	}
+
+	if pk := info.GetPrimaryKey(); pk != nil && pk.Path.IsEqual(path) {
+		key, err := t.encodeValueToKey(info, v)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = t.Store.Get(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return key, nil
+	}
+
+	for _, idx := range t.Indexes() {
+		if idx.Info.Unique && equalPaths(idx.Info.Paths, []document.Path{path}) {
+			return idx.lookupUnique([]document.Value{v})
+		}
+	}
+
+	return nil, stringutil.Errorf("no primary key or unique index on %q", path)
+}
+
+// referencingKeys returns the keys of the documents whose value at path is v.
+func (t *Table) referencingKeys(path document.Path, v document.Value) ([][]byte, error) {
+	var keys [][]byte
+
+	err := t.Iterate(func(d document.Document) error {
+		dv, err := path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ok, err := dv.IsEqual(v)
+		if err != nil || !ok {
+			return err
+		}
+
+		keys = append(keys, append([]byte(nil), d.(document.Keyer).RawKey()...))
+		return nil
+	})
+
+	return keys, err
+}
+
+// referencingDocuments returns the documents referencing d through foreign keys.
+// It returns an error if one of these foreign keys restricts the deletion of d.
+func (t *Table) referencingDocuments(key []byte, d document.Document) ([]*referencingDocuments, error) {
+	var list []*referencingDocuments
+
+	for _, ref := range t.tx.db.catalog.cache.GetReferences(t.name) {
+		fk := ref.Constraint.ForeignKey
+
+		v, err := fk.Path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v.Type == document.NullValue {
+			continue
+		}
+
+		child, err := t.tx.GetTable(ref.TableName)
+		if err != nil {
+			return nil, err
+		}
+
+		keys, err := child.referencingKeys(ref.Constraint.Path, v)
+		if err != nil {
+			return nil, err
+		}
+
+		// a document referencing itself doesn't prevent its deletion
+		if ref.TableName == t.name {
+			keys = removeKey(keys, key)
+		}
+
+		if len(keys) == 0 {
+			continue
+		}
+
+		if fk.OnDelete == ForeignKeyRestrict {
+			return nil, stringutil.Errorf("document is referenced by foreign key %q of table %q", ref.Constraint.Name, ref.TableName)
+		}
+
+		list = append(list, &referencingDocuments{
+			table:      child,
+			constraint: ref.Constraint,
+			keys:       keys,
+		})
+	}
+
+	return list, nil
+}
+
+// validateReferencedValues ensures that replacing old by d doesn't modify
+// values referenced by the foreign keys of other documents.
+func (t *Table) validateReferencedValues(key []byte, old, d document.Document) error {
+	for _, ref := range t.tx.db.catalog.cache.GetReferences(t.name) {
+		fk := ref.Constraint.ForeignKey
+
+		ov, err := fk.Path.GetValueFromDocument(old)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if ov.Type == document.NullValue {
+			continue
+		}
+
+		nv, err := fk.Path.GetValueFromDocument(d)
+		if err == nil {
+			ok, err := nv.IsEqual(ov)
+			if err != nil {
+				return err
+			}
+			if ok {
+				continue
+			}
+		} else if err != document.ErrFieldNotFound {
+			return err
+		}
+
+		child, err := t.tx.GetTable(ref.TableName)
+		if err != nil {
+			return err
+		}
+
+		keys, err := child.referencingKeys(ref.Constraint.Path, ov)
+		if err != nil {
+			return err
+		}
+
+		if ref.TableName == t.name {
+			keys = removeKey(keys, key)
+		}
+
+		if len(keys) > 0 {
+			return stringutil.Errorf("document is referenced by foreign key %q of table %q", ref.Constraint.Name, ref.TableName)
+		}
+	}
+
+	return nil
+}
+
+func removeKey(keys [][]byte, key []byte) [][]byte {
+	filtered := keys[:0]
+	for _, k := range keys {
+		if !bytes.Equal(k, key) {
+			filtered = append(filtered, k)
+		}
+	}
+
+	return filtered
+}
+
+// referencingDocuments are documents of a table referencing
+// a deleted document through a foreign key.
+type referencingDocuments struct {
+	table      *Table
+	constraint *TableConstraint
+	keys       [][]byte
+}
+
+// apply the ON DELETE action of the foreign key to the documents.
+func (r *referencingDocuments) apply() error {
+	for _, key := range r.keys {
+		var err error
+
+		switch r.constraint.ForeignKey.OnDelete {
+		case ForeignKeyCascade:
+			err = r.table.Delete(key)
+			// the document may have already been deleted
+			// by another cascading deletion.
+			if err == ErrDocumentNotFound {
+				err = nil
+			}
+		case ForeignKeySetNull:
+			err = r.setNull(key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *referencingDocuments) setNull(key []byte) error {
+	d, err := r.table.GetDocument(key)
+	if err == ErrDocumentNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fb := document.NewFieldBuffer()
+	err = fb.Copy(d)
+	if err != nil {
+		return err
+	}
+
+	err = fb.Set(r.constraint.Path, document.NewNullValue())
+	if err != nil {
+		return err
+	}
+
+	return r.table.Replace(key, fb)
+}
+
 type documentWithKey struct {
 	document.Document
 
@@ -12,6 +12,7 @@ import (
 	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/document/encoding/msgpack"
 	"github.com/tie/genji-release-test/engine/memoryengine"
+	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/sql/parser"
 	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
@@ -340,8 +341,8 @@ func TestTableInsert(t *testing.T) {
 
 		err := tx.CreateTable("test", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{parsePath(t, "foo"), document.DocumentValue, false, false, false, document.Value{}, true, []document.Path{parsePath(t, "foo.bar")}},
-				{parsePath(t, "foo.bar"), document.IntegerValue, false, false, false, document.Value{}, true, []document.Path{parsePath(t, "foo")}},
+				{parsePath(t, "foo"), document.DocumentValue, false, false, false, nil, nil, true, []document.Path{parsePath(t, "foo.bar")}},
+				{parsePath(t, "foo.bar"), document.IntegerValue, false, false, false, nil, nil, true, []document.Path{parsePath(t, "foo")}},
 			},
 		})
 		require.NoError(t, err)
@@ -385,7 +386,7 @@ func TestTableInsert(t *testing.T) {
 
 		err := tx.CreateTable("test", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{parsePath(t, "foo"), document.DoubleValue, false, false, false, document.Value{}, false, nil},
+				{parsePath(t, "foo"), document.DoubleValue, false, false, false, nil, nil, false, nil},
 			},
 		})
 		require.NoError(t, err)
@@ -407,7 +408,7 @@ func TestTableInsert(t *testing.T) {
 		// no enforced type, not null
 		err := tx.CreateTable("test1", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{parsePath(t, "foo"), 0, false, true, false, document.Value{}, false, nil},
+				{parsePath(t, "foo"), 0, false, true, false, nil, nil, false, nil},
 			},
 		})
 		require.NoError(t, err)
@@ -417,7 +418,7 @@ func TestTableInsert(t *testing.T) {
 		// enforced type, not null
 		err = tx.CreateTable("test2", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{parsePath(t, "foo"), document.IntegerValue, false, true, false, document.Value{}, false, nil},
+				{parsePath(t, "foo"), document.IntegerValue, false, true, false, nil, nil, false, nil},
 			},
 		})
 		require.NoError(t, err)
@@ -462,7 +463,7 @@ func TestTableInsert(t *testing.T) {
 		// no enforced type, not null
 		err := tx.CreateTable("test1", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{parsePath(t, "foo"), 0, false, true, false, document.NewIntegerValue(42), false, nil},
+				{parsePath(t, "foo"), 0, false, true, false, expr.Constraint(expr.LiteralValue(document.NewIntegerValue(42))), nil, false, nil},
 			},
 		})
 		require.NoError(t, err)
@@ -472,7 +473,7 @@ func TestTableInsert(t *testing.T) {
 		// enforced type, not null
 		err = tx.CreateTable("test2", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{parsePath(t, "foo"), document.IntegerValue, false, true, false, document.NewIntegerValue(42), false, nil},
+				{parsePath(t, "foo"), document.IntegerValue, false, true, false, expr.Constraint(expr.LiteralValue(document.NewIntegerValue(42))), nil, false, nil},
 			},
 		})
 		require.NoError(t, err)
@@ -522,13 +523,43 @@ func TestTableInsert(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("Should fail if a check constraint is not satisfied", func(t *testing.T) {
+		_, tx, cleanup := newTestTx(t)
+		defer cleanup()
+
+		err := tx.CreateTable("test1", &database.TableInfo{
+			TableConstraints: []*database.TableConstraint{
+				{Path: parsePath(t, "foo"), Check: expr.Constraint(parser.MustParseExpr("foo > 0"))},
+				{Name: "lower", Check: expr.Constraint(parser.MustParseExpr("foo < bar"))},
+			},
+		})
+		require.NoError(t, err)
+		tb, err := tx.GetTable("test1")
+		require.NoError(t, err)
+
+		_, err = tb.Insert(document.NewFieldBuffer().
+			Add("foo", document.NewIntegerValue(-1)).
+			Add("bar", document.NewIntegerValue(10)))
+		require.EqualError(t, err, `document violates check constraint "test1_foo_check"`)
+
+		_, err = tb.Insert(document.NewFieldBuffer().
+			Add("foo", document.NewIntegerValue(20)).
+			Add("bar", document.NewIntegerValue(10)))
+		require.EqualError(t, err, `document violates check constraint "lower"`)
+
+		// a NULL result satisfies the constraint
+		_, err = tb.Insert(document.NewFieldBuffer().
+			Add("foo", document.NewIntegerValue(1)))
+		require.NoError(t, err)
+	})
+
 	t.Run("Should fail if there is a not null field constraint on an array value and the value is null", func(t *testing.T) {
 		_, tx, cleanup := newTestTx(t)
 		defer cleanup()
 
 		err := tx.CreateTable("test1", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{parsePath(t, "foo[1]"), 0, false, true, false, document.Value{}, false, nil},
+				{parsePath(t, "foo[1]"), 0, false, true, false, nil, nil, false, nil},
 			},
 		})
 		require.NoError(t, err)
@@ -546,6 +577,59 @@ func TestTableInsert(t *testing.T) {
 	})
 }
 
+func TestTableConflict(t *testing.T) {
+	_, tx, cleanup := newTestTx(t)
+	defer cleanup()
+
+	err := tx.CreateTable("test", &database.TableInfo{
+		FieldConstraints: []*database.FieldConstraint{
+			{Path: parsePath(t, "a"), Type: document.IntegerValue, IsPrimaryKey: true},
+		},
+	})
+	require.NoError(t, err)
+	err = tx.CreateIndex(&database.IndexInfo{
+		Unique:    true,
+		IndexName: "idx_b",
+		TableName: "test",
+		Paths:     []document.Path{parsePath(t, "b")},
+	})
+	require.NoError(t, err)
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+
+	d, err := tb.Insert(testutil.MakeDocument(t, `{"a": 1, "b": "foo"}`))
+	require.NoError(t, err)
+	key := d.(document.Keyer).RawKey()
+
+	tests := []struct {
+		name  string
+		doc   string
+		paths []document.Path
+		key   []byte
+		fails bool
+	}{
+		{"no conflict", `{"a": 2, "b": "bar"}`, nil, nil, false},
+		{"primary key", `{"a": 1, "b": "bar"}`, nil, key, false},
+		{"unique index", `{"a": 2, "b": "foo"}`, nil, key, false},
+		{"primary key target", `{"a": 1, "b": "bar"}`, []document.Path{parsePath(t, "a")}, key, false},
+		{"other target", `{"a": 2, "b": "foo"}`, []document.Path{parsePath(t, "a")}, nil, false},
+		{"unique index target", `{"a": 2, "b": "foo"}`, []document.Path{parsePath(t, "b")}, key, false},
+		{"unknown target", `{"a": 2, "b": "foo"}`, []document.Path{parsePath(t, "c")}, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k, err := tb.Conflict(testutil.MakeDocument(t, test.doc), test.paths...)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.key, k)
+		})
+	}
+}
+
 // TestTableDelete verifies Delete behaviour.
 func TestTableDelete(t *testing.T) {
 	t.Run("Should fail if not found", func(t *testing.T) {
@@ -635,6 +719,28 @@ func TestTableReplace(t *testing.T) {
 		require.Equal(t, "c", f.V.(string))
 	})
 
+	t.Run("Should fail if a check constraint is not satisfied", func(t *testing.T) {
+		_, tx, cleanup := newTestTx(t)
+		defer cleanup()
+
+		err := tx.CreateTable("test1", &database.TableInfo{
+			TableConstraints: []*database.TableConstraint{
+				{Path: parsePath(t, "foo"), Check: expr.Constraint(parser.MustParseExpr("foo > 0"))},
+			},
+		})
+		require.NoError(t, err)
+		tb, err := tx.GetTable("test1")
+		require.NoError(t, err)
+
+		d, err := tb.Insert(document.NewFieldBuffer().
+			Add("foo", document.NewIntegerValue(1)))
+		require.NoError(t, err)
+
+		err = tb.Replace(d.(document.Keyer).RawKey(), document.NewFieldBuffer().
+			Add("foo", document.NewIntegerValue(0)))
+		require.EqualError(t, err, `document violates check constraint "test1_foo_check"`)
+	})
+
 	t.Run("Should update indexes", func(t *testing.T) {
 		_, tx, cleanup := newTestTx(t)
 		defer cleanup()
@@ -1,5 +1,9 @@
 /*
 Package genji implements a document-oriented, embedded SQL database.
 Genji supports various engines that write data on-disk, like BoltDB or Badger, and in memory.
+
+Recursive common table expressions are limited to 1000 iterations and 1000000 documents,
+see parser.DefaultMaxRecursionDepth and parser.DefaultMaxRecursionRows. These limits are
+fixed and cannot be configured through DB.
 */
 package genji
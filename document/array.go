@@ -327,7 +327,7 @@ func SortArray(a Array) (*ValueBuffer, error) {
 	var s sortableArray
 	vb, ok := a.(*ValueBuffer)
 	if !ok {
-		vb := NewValueBuffer()
+		vb = NewValueBuffer()
 		err := vb.Copy(a)
 		if err != nil {
 			return nil, err
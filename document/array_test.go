@@ -45,11 +45,19 @@ func TestSortArray(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			var arr ValueBuffer
 			require.NoError(t, arr.UnmarshalJSON([]byte(test.arr)))
-			output, err := SortArray(&arr)
+
+			// arrays that are not value buffers are copied before being sorted
+			output, err := SortArray(jsonArray{&arr})
 			require.NoError(t, err)
 			actual, err := json.Marshal(output)
 			require.NoError(t, err)
 			require.Equal(t, test.expected, string(actual))
+
+			output, err = SortArray(&arr)
+			require.NoError(t, err)
+			actual, err = json.Marshal(output)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, string(actual))
 		})
 	}
 }
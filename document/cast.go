@@ -3,6 +3,7 @@ package document
 import (
 	"encoding/base64"
 	"strconv"
+	"time"
 
 	"github.com/tie/genji-release-test/stringutil"
 )
@@ -25,6 +26,8 @@ func (v Value) CastAs(t ValueType) (Value, error) {
 		return v.CastAsInteger()
 	case DoubleValue:
 		return v.CastAsDouble()
+	case TimestampValue:
+		return v.CastAsTimestamp()
 	case BlobValue:
 		return v.CastAsBlob()
 	case TextValue:
@@ -67,6 +70,7 @@ func (v Value) CastAsBool() (Value, error) {
 // then casts it to an integer. If it fails uses strconv.ParseFloat
 // to determine the double value, then casts it to an integer
 // It fails if the text doesn't contain a valid float value.
+// Timestamp: returns the number of seconds since the unix epoch.
 // Any other type is considered an invalid cast.
 func (v Value) CastAsInteger() (Value, error) {
 	switch v.Type {
@@ -79,6 +83,8 @@ func (v Value) CastAsInteger() (Value, error) {
 		return NewIntegerValue(0), nil
 	case DoubleValue:
 		return NewIntegerValue(int64(v.V.(float64))), nil
+	case TimestampValue:
+		return NewIntegerValue(v.V.(time.Time).Unix()), nil
 	case TextValue:
 		i, err := strconv.ParseInt(v.V.(string), 10, 64)
 		if err != nil {
@@ -119,6 +125,7 @@ func (v Value) CastAsDouble() (Value, error) {
 
 // CastAsText returns a JSON representation of v.
 // If the representation is a string, it gets unquoted.
+// Timestamps are formatted using RFC 3339.
 func (v Value) CastAsText() (Value, error) {
 	if v.Type == TextValue {
 		return v, nil
@@ -131,7 +138,7 @@ func (v Value) CastAsText() (Value, error) {
 
 	s := string(d)
 
-	if v.Type == BlobValue {
+	if v.Type == BlobValue || v.Type == TimestampValue {
 		s, err = strconv.Unquote(s)
 		if err != nil {
 			return Value{}, err
@@ -141,6 +148,44 @@ func (v Value) CastAsText() (Value, error) {
 	return NewTextValue(s), nil
 }
 
+// timestampLayouts are the formats accepted when casting a text to a timestamp,
+// besides RFC 3339. Texts without a time zone are considered UTC.
+var timestampLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// CastAsTimestamp casts according to the following rules:
+// Text: parses an RFC 3339 timestamp. The T separator and the time zone can be omitted,
+// as well as the time. It fails if the text doesn't contain a valid timestamp.
+// Integer: returns the timestamp that many seconds after the unix epoch.
+// Any other type is considered an invalid cast.
+func (v Value) CastAsTimestamp() (Value, error) {
+	switch v.Type {
+	case TimestampValue:
+		return v, nil
+	case IntegerValue:
+		return NewTimestampValue(time.Unix(v.V.(int64), 0)), nil
+	case TextValue:
+		t, err := time.Parse(time.RFC3339Nano, v.V.(string))
+		if err == nil {
+			return NewTimestampValue(t), nil
+		}
+
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, v.V.(string)); err == nil {
+				return NewTimestampValue(t), nil
+			}
+		}
+
+		return Value{}, stringutil.Errorf(`cannot cast %q as timestamp: %w`, v.V, err)
+	}
+
+	return Value{}, stringutil.Errorf("cannot cast %s as timestamp", v.Type)
+}
+
 // CastAsBlob casts according to the following rules:
 // Text: decodes a base64 string, otherwise fails.
 // Any other type is considered an invalid cast.
@@ -3,6 +3,7 @@ package document
 import (
 	"bytes"
 	"strings"
+	"time"
 )
 
 type operator uint8
@@ -93,6 +94,24 @@ func compare(op operator, l, r Value) (bool, error) {
 	case l.Type.IsNumber() && r.Type.IsNumber():
 		return compareNumbers(op, l, r), nil
 
+	// compare timestamps together
+	case l.Type == TimestampValue && r.Type == TimestampValue:
+		return compareTimestamps(op, l.V.(time.Time), r.V.(time.Time)), nil
+
+	// compare timestamps with texts representing a timestamp
+	case l.Type == TimestampValue && r.Type == TextValue:
+		r, err := r.CastAsTimestamp()
+		if err != nil {
+			return false, nil
+		}
+		return compareTimestamps(op, l.V.(time.Time), r.V.(time.Time)), nil
+	case l.Type == TextValue && r.Type == TimestampValue:
+		l, err := l.CastAsTimestamp()
+		if err != nil {
+			return false, nil
+		}
+		return compareTimestamps(op, l.V.(time.Time), r.V.(time.Time)), nil
+
 	// compare arrays together
 	case l.Type == ArrayValue && r.Type == ArrayValue:
 		return compareArrays(op, l.V.(Array), r.V.(Array))
@@ -209,6 +228,23 @@ func compareNumbers(op operator, l, r Value) bool {
 	return ok
 }
 
+func compareTimestamps(op operator, l, r time.Time) bool {
+	switch op {
+	case operatorEq:
+		return l.Equal(r)
+	case operatorGt:
+		return l.After(r)
+	case operatorGte:
+		return !l.Before(r)
+	case operatorLt:
+		return l.Before(r)
+	case operatorLte:
+		return !l.After(r)
+	}
+
+	return false
+}
+
 func compareArrays(op operator, l Array, r Array) (bool, error) {
 	var i, j int
 
@@ -37,6 +37,13 @@ func toText(t testing.TB, x string) document.Value {
 	return document.NewTextValue(x)
 }
 
+func toTimestamp(t testing.TB, x string) document.Value {
+	v, err := document.NewTextValue(x).CastAsTimestamp()
+	require.NoError(t, err)
+
+	return v
+}
+
 func toBlob(t testing.TB, x string) document.Value {
 	return document.NewBlobValue([]byte(x))
 }
@@ -136,6 +143,17 @@ func TestCompare(t *testing.T) {
 		{"<=", "a", "b", true, toText},
 		{"<=", "b", "b", true, toText},
 
+		// timestamp
+		{"=", "2021-03-04T05:06:07Z", "2021-03-04T05:06:07Z", true, toTimestamp},
+		{"=", "2021-03-04T05:06:07Z", "2021-03-04T07:06:07+02:00", true, toTimestamp},
+		{"!=", "2021-03-04T05:06:07Z", "2021-03-04", true, toTimestamp},
+		{">", "2021-03-04T05:06:07Z", "2021-03-04", true, toTimestamp},
+		{">", "2021-03-04", "2021-03-04T05:06:07Z", false, toTimestamp},
+		{">=", "2021-03-04", "2021-03-04", true, toTimestamp},
+		{"<", "1900-01-01", "2021-03-04", true, toTimestamp},
+		{"<", "2021-03-04T05:06:07.1Z", "2021-03-04T05:06:07.2Z", true, toTimestamp},
+		{"<=", "2021-03-04T05:06:07Z", "2021-03-04", false, toTimestamp},
+
 		// blob
 		{"=", "b", "a", false, toBlob},
 		{"=", "b", "b", true, toBlob},
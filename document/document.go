@@ -367,6 +367,40 @@ func (fb *FieldBuffer) Clone() *FieldBuffer {
 	return &newFb
 }
 
+// Patch applies a JSON merge patch, as described in RFC 7396, to the buffer.
+// Fields of the patch set to NULL are removed from the buffer, documents
+// are merged recursively and any other value replaces the existing one.
+func (fb *FieldBuffer) Patch(patch Document) error {
+	return patch.Iterate(func(f string, v Value) error {
+		switch v.Type {
+		case NullValue:
+			err := fb.Delete(NewPath(f))
+			if err == ErrFieldNotFound {
+				return nil
+			}
+			return err
+		case DocumentValue:
+			buf := NewFieldBuffer()
+			old, err := fb.GetByField(f)
+			if err == nil && old.Type == DocumentValue {
+				err = buf.Copy(old.V.(Document))
+				if err != nil {
+					return err
+				}
+			}
+
+			err = buf.Patch(v.V.(Document))
+			if err != nil {
+				return err
+			}
+
+			v = NewDocumentValue(buf)
+		}
+
+		return fb.setFieldValue(f, v)
+	})
+}
+
 // Apply a function to all the values of the buffer.
 func (fb *FieldBuffer) Apply(fn func(p Path, v Value) (Value, error)) error {
 	path := Path{PathFragment{}}
@@ -479,6 +513,8 @@ func NewPath(fragments ...string) Path {
 type PathFragment struct {
 	FieldName  string
 	ArrayIndex int
+	// Wildcard matches every index of an array, in place of ArrayIndex.
+	Wildcard bool
 }
 
 // String representation of all the fragments of the path.
@@ -492,6 +528,8 @@ func (p Path) String() string {
 				b.WriteRune('.')
 			}
 			b.WriteString(p[i].FieldName)
+		} else if p[i].Wildcard {
+			b.WriteString("[*]")
 		} else {
 			b.WriteString("[" + strconv.Itoa(p[i].ArrayIndex) + "]")
 		}
@@ -514,7 +552,19 @@ func (p Path) IsEqual(other Path) bool {
 	return true
 }
 
+// HasWildcard returns whether one of the fragments of p is the [*] wildcard.
+func (p Path) HasWildcard() bool {
+	for i := range p {
+		if p[i].Wildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
 // GetValueFromDocument returns the value at path p from d.
+// If p contains the [*] wildcard, it returns an array of all the matching values.
 func (p Path) GetValueFromDocument(d Document) (Value, error) {
 	if len(p) == 0 {
 		return Value{}, ErrFieldNotFound
@@ -536,6 +586,7 @@ func (p Path) GetValueFromDocument(d Document) (Value, error) {
 }
 
 // GetValueFromArray returns the value at path p from a.
+// If p contains the [*] wildcard, it returns an array of all the matching values.
 func (p Path) GetValueFromArray(a Array) (Value, error) {
 	if len(p) == 0 {
 		return Value{}, ErrFieldNotFound
@@ -543,6 +594,9 @@ func (p Path) GetValueFromArray(a Array) (Value, error) {
 	if p[0].FieldName != "" {
 		return Value{}, ErrFieldNotFound
 	}
+	if p[0].Wildcard {
+		return p[1:].getValuesFromArray(a)
+	}
 
 	v, err := a.GetByIndex(p[0].ArrayIndex)
 	if err != nil {
@@ -566,6 +620,42 @@ func (p Path) Clone() Path {
 	return c
 }
 
+// getValuesFromArray returns an array of the values at path p from each value of a.
+// Values where p is not found are skipped. If p contains another wildcard,
+// the matching values are added to the array instead of the array of matches.
+func (p Path) getValuesFromArray(a Array) (Value, error) {
+	vb := NewValueBuffer()
+	flatten := p.HasWildcard()
+
+	err := a.Iterate(func(i int, v Value) error {
+		if len(p) > 0 {
+			var err error
+			v, err = p.getValueFromValue(v)
+			if err == ErrFieldNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if !flatten {
+			vb.Append(v)
+			return nil
+		}
+
+		return v.V.(Array).Iterate(func(i int, v Value) error {
+			vb.Append(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return Value{}, err
+	}
+
+	return NewArrayValue(vb), nil
+}
+
 func (p Path) getValueFromValue(v Value) (Value, error) {
 	switch v.Type {
 	case DocumentValue:
@@ -194,6 +194,42 @@ func TestFieldBuffer(t *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("Patch", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			target string
+			patch  string
+			want   string
+		}{
+			{"add", `{"a": 1}`, `{"b": 2}`, `{"a": 1, "b": 2}`},
+			{"replace", `{"a": 1}`, `{"a": "b"}`, `{"a": "b"}`},
+			{"remove", `{"a": 1, "b": 2}`, `{"a": null}`, `{"b": 2}`},
+			{"remove unknown", `{"a": 1}`, `{"b": null}`, `{"a": 1}`},
+			{"replace array", `{"a": [1, 2]}`, `{"a": [3]}`, `{"a": [3]}`},
+			{"nested", `{"a": {"b": 1, "c": 2}}`, `{"a": {"b": null, "d": 3}}`, `{"a": {"c": 2, "d": 3}}`},
+			{"nested on scalar", `{"a": 1}`, `{"a": {"b": {"c": null, "d": 1}}}`, `{"a": {"b": {"d": 1}}}`},
+			{"rfc example",
+				`{"title": "Goodbye!", "author": {"givenName": "John", "familyName": "Doe"}, "tags": ["example", "sample"], "content": "This will be unchanged"}`,
+				`{"title": "Hello!", "phoneNumber": "+01-234-567-8900", "author": {"familyName": null}, "tags": ["example"]}`,
+				`{"title": "Hello!", "author": {"givenName": "John"}, "tags": ["example"], "content": "This will be unchanged", "phoneNumber": "+01-234-567-8900"}`,
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				var fb document.FieldBuffer
+				err := fb.Copy(document.NewFromJSON([]byte(test.target)))
+				require.NoError(t, err)
+
+				err = fb.Patch(document.NewFromJSON([]byte(test.patch)))
+				require.NoError(t, err)
+				data, err := document.MarshalJSON(&fb)
+				require.NoError(t, err)
+				require.JSONEq(t, test.want, string(data))
+			})
+		}
+	})
+
 	t.Run("Apply", func(t *testing.T) {
 		d := document.NewFromJSON([]byte(`{
 			"a": "b",
@@ -423,7 +459,7 @@ func TestNewFromStruct(t *testing.T) {
 			case 27:
 				require.EqualValues(t, document.IntegerValue, v.Type)
 			case 28:
-				require.EqualValues(t, document.TextValue, v.Type)
+				require.EqualValues(t, document.TimestampValue, v.Type)
 			default:
 				require.FailNowf(t, "", "unknown field %q", f)
 			}
@@ -586,6 +622,12 @@ func TestPath(t *testing.T) {
 		{"number field", `{"a": {"0": [1, 2, 3]}}`, "a.`0`", `[1, 2, 3]`, false},
 		{"letter index", `{"a": {"b": [1, 2, 3]}}`, `a.b.c`, ``, true},
 		{"unknown path", `{"a": {"b": [1, 2, 3]}}`, `a.e.f`, ``, true},
+		{"wildcard", `{"a": {"b": [1, 2, 3]}}`, `a.b[*]`, `[1, 2, 3]`, false},
+		{"wildcard field", `{"a": [{"b": 1}, {"c": 2}, {"b": 3}]}`, `a[*].b`, `[1, 3]`, false},
+		{"wildcard index", `{"a": [[1, 2], [3], []]}`, `a[*][0]`, `[1, 3]`, false},
+		{"nested wildcards", `{"a": [{"b": [1, 2]}, {"b": 3}, {"b": [4]}]}`, `a[*].b[*]`, `[1, 2, 4]`, false},
+		{"wildcard no match", `{"a": [1, 2]}`, `a[*].b`, `[]`, false},
+		{"wildcard on document", `{"a": {"b": 1}}`, `a[*]`, ``, true},
 	}
 
 	for _, test := range tests {
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/tie/genji-release-test/binarysort"
 	"github.com/tie/genji-release-test/document"
@@ -132,6 +133,8 @@ func EncodeValue(v document.Value) ([]byte, error) {
 		return encodeInt64(v.V.(int64)), nil
 	case document.DoubleValue:
 		return binarysort.AppendFloat64(nil, v.V.(float64)), nil
+	case document.TimestampValue:
+		return binarysort.AppendTime(nil, v.V.(time.Time)), nil
 	case document.NullValue:
 		return nil, nil
 	}
@@ -342,6 +345,12 @@ func DecodeValue(t document.ValueType, data []byte) (document.Value, error) {
 			return document.Value{}, err
 		}
 		return document.NewDoubleValue(x), nil
+	case document.TimestampValue:
+		x, err := binarysort.DecodeTime(data)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return document.NewTimestampValue(x), nil
 	case document.NullValue:
 		return document.NewNullValue(), nil
 	}
@@ -312,7 +312,11 @@ func scanValue(v Value, ref reflect.Value) error {
 	// test with supported stdlib types
 	switch ref.Type().String() {
 	case "time.Time":
-		if v.Type == TextValue {
+		switch v.Type {
+		case TimestampValue:
+			ref.Set(reflect.ValueOf(v.V))
+			return nil
+		case TextValue:
 			parsed, err := time.Parse(time.RFC3339Nano, v.V.(string))
 			if err != nil {
 				return err
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"math"
 	"strconv"
+	"time"
 
 	"github.com/buger/jsonparser"
 	"github.com/tie/genji-release-test/binarysort"
@@ -42,6 +43,9 @@ const (
 	// double family: 0xA0 to 0xAF
 	DoubleValue ValueType = 0xA0
 
+	// timestamp family: 0xB0 to 0xBF
+	TimestampValue ValueType = 0xB0
+
 	// string family: 0xC0 to 0xCF
 	TextValue ValueType = 0xC0
 
@@ -65,6 +69,8 @@ func (t ValueType) String() string {
 		return "integer"
 	case DoubleValue:
 		return "double"
+	case TimestampValue:
+		return "timestamp"
 	case BlobValue:
 		return "blob"
 	case TextValue:
@@ -126,6 +132,15 @@ func NewDoubleValue(x float64) Value {
 	}
 }
 
+// NewTimestampValue encodes x and returns a value.
+// The time is converted to UTC.
+func NewTimestampValue(x time.Time) Value {
+	return Value{
+		Type: TimestampValue,
+		V:    x.UTC(),
+	}
+}
+
 // NewBlobValue encodes x and returns a value.
 func NewBlobValue(x []byte) Value {
 	return Value{
@@ -178,6 +193,8 @@ func (v Value) IsZeroValue() (bool, error) {
 		return v.V == int64(0), nil
 	case DoubleValue:
 		return v.V == float64(0), nil
+	case TimestampValue:
+		return v.V.(time.Time).IsZero(), nil
 	case BlobValue:
 		return v.V == nil, nil
 	case TextValue:
@@ -237,6 +254,8 @@ func (v Value) MarshalJSON() ([]byte, error) {
 		prec := -1
 
 		return strconv.AppendFloat(nil, v.V.(float64), fmt, prec, 64), nil
+	case TimestampValue:
+		return []byte(strconv.Quote(v.V.(time.Time).Format(time.RFC3339Nano))), nil
 	case TextValue:
 		return []byte(strconv.Quote(v.V.(string))), nil
 	case BlobValue:
@@ -284,6 +303,8 @@ func (v Value) Append(buf []byte) ([]byte, error) {
 		return binarysort.AppendInt64(buf, v.V.(int64)), nil
 	case DoubleValue:
 		return binarysort.AppendFloat64(buf, v.V.(float64)), nil
+	case TimestampValue:
+		return binarysort.AppendTime(buf, v.V.(time.Time)), nil
 	case NullValue:
 		return buf, nil
 	case ArrayValue:
@@ -313,12 +334,15 @@ func (v Value) MarshalBinary() ([]byte, error) {
 
 // Add u to v and return the result.
 // Only numeric values and booleans can be added together.
+// An integer number of nanoseconds can be added to a timestamp.
 func (v Value) Add(u Value) (res Value, err error) {
 	return calculateValues(v, u, '+')
 }
 
 // Sub calculates v - u and returns the result.
 // Only numeric values and booleans can be calculated together.
+// An integer number of nanoseconds can be subtracted from a timestamp,
+// and subtracting two timestamps returns the number of nanoseconds between them.
 func (v Value) Sub(u Value) (res Value, err error) {
 	return calculateValues(v, u, '-')
 }
@@ -373,6 +397,10 @@ func calculateValues(a, b Value, operator byte) (res Value, err error) {
 		return NewNullValue(), nil
 	}
 
+	if a.Type == TimestampValue || b.Type == TimestampValue {
+		return calculateTimestamps(a, b, operator)
+	}
+
 	if a.Type.IsNumber() && b.Type.IsNumber() {
 		if a.Type == DoubleValue || b.Type == DoubleValue {
 			return calculateFloats(a, b, operator)
@@ -503,6 +531,33 @@ func calculateFloats(a, b Value, operator byte) (res Value, err error) {
 	}
 }
 
+// calculateTimestamps adds or subtracts intervals, represented by an integer number
+// of nanoseconds, to timestamps. Any other operation returns NULL.
+func calculateTimestamps(a, b Value, operator byte) (res Value, err error) {
+	switch {
+	case a.Type == TimestampValue && b.Type == IntegerValue:
+		d := time.Duration(b.V.(int64))
+		switch operator {
+		case '+':
+			return NewTimestampValue(a.V.(time.Time).Add(d)), nil
+		case '-':
+			return NewTimestampValue(a.V.(time.Time).Add(-d)), nil
+		}
+	case a.Type == IntegerValue && b.Type == TimestampValue && operator == '+':
+		return NewTimestampValue(b.V.(time.Time).Add(time.Duration(a.V.(int64)))), nil
+	case a.Type == TimestampValue && b.Type == TimestampValue && operator == '-':
+		ta, tb := a.V.(time.Time), b.V.(time.Time)
+		d := ta.Sub(tb)
+		// Sub saturates if the difference doesn't fit in a duration
+		if !tb.Add(d).Equal(ta) {
+			return Value{}, errors.New("timestamp difference out of range")
+		}
+		return NewIntegerValue(int64(d)), nil
+	}
+
+	return NewNullValue(), nil
+}
+
 func parseJSONValue(dataType jsonparser.ValueType, data []byte) (v Value, err error) {
 	switch dataType {
 	case jsonparser.Null:
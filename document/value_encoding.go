@@ -3,6 +3,7 @@ package document
 import (
 	"errors"
 	"io"
+	"time"
 
 	"github.com/tie/genji-release-test/binarysort"
 )
@@ -72,6 +73,8 @@ func (ve *ValueEncoder) appendValue(v Value) error {
 		ve.buf = binarysort.AppendInt64(ve.buf, v.V.(int64))
 	case DoubleValue:
 		ve.buf = binarysort.AppendFloat64(ve.buf, v.V.(float64))
+	case TimestampValue:
+		ve.buf = binarysort.AppendTime(ve.buf, v.V.(time.Time))
 	default:
 		return errors.New("cannot encode type " + v.Type.String() + " as key")
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/tie/genji-release-test/binarysort"
 	"github.com/stretchr/testify/require"
@@ -18,12 +19,14 @@ func TestValueEncoder(t *testing.T) {
 		{"bool", NewBoolValue(true)},
 		{"integer", NewIntegerValue(-10)},
 		{"double", NewDoubleValue(-3.14)},
+		{"timestamp", NewTimestampValue(time.Date(2021, 5, 4, 3, 2, 1, 0, time.UTC))},
 		{"text", NewTextValue("foo")},
 		{"blob", NewBlobValue([]byte("bar"))},
 		{"array", NewArrayValue(NewValueBuffer(
 			NewBoolValue(true),
 			NewIntegerValue(55),
 			NewDoubleValue(789.58),
+			NewTimestampValue(time.Date(1969, 12, 31, 23, 59, 59, 999, time.UTC)),
 			NewArrayValue(NewValueBuffer(
 				NewBoolValue(false),
 				NewIntegerValue(100),
@@ -240,6 +243,12 @@ func decodeValue(data []byte) (Value, error) {
 			return Value{}, err
 		}
 		return NewDoubleValue(x), nil
+	case TimestampValue:
+		x, err := binarysort.DecodeTime(data)
+		if err != nil {
+			return Value{}, err
+		}
+		return NewTimestampValue(x), nil
 	case ArrayValue:
 		a, _, err := decodeArray(data)
 		if err != nil {
@@ -285,6 +294,12 @@ func decodeValueUntil(data []byte, delim, end byte) (Value, int, error) {
 		} else {
 			return Value{}, 0, errors.New("malformed " + t.String())
 		}
+	case TimestampValue:
+		if i+12 < len(data) && (data[i+12] == delim || data[i+12] == end) {
+			i += 12
+		} else {
+			return Value{}, 0, errors.New("malformed " + t.String())
+		}
 	case BlobValue, TextValue:
 		for i < len(data) && data[i] != delim && data[i] != end {
 			i++
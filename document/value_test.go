@@ -70,7 +70,7 @@ func TestNewValue(t *testing.T) {
 		{"null", nil, nil},
 		{"document", document.NewFieldBuffer().Add("a", document.NewIntegerValue(10)), document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))},
 		{"array", document.NewValueBuffer(document.NewIntegerValue(10)), document.NewValueBuffer(document.NewIntegerValue(10))},
-		{"time", now, now.Format(time.RFC3339Nano)},
+		{"time", now, now.UTC()},
 		{"bytes", myBytes("bar"), []byte("bar")},
 		{"string", myString("bar"), "bar"},
 		{"myUint", myUint(10), int64(10)},
@@ -112,6 +112,10 @@ func TestValueAdd(t *testing.T) {
 		{"int64(min)+integer(-10)", document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(-10), document.NewDoubleValue(math.MinInt64 - 10), false},
 		{"integer(120)+text('120')", document.NewIntegerValue(120), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"text('120')+text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
+		{"timestamp+integer(1h)", document.NewTimestampValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewIntegerValue(int64(time.Hour)), document.NewTimestampValue(time.Date(2021, 3, 4, 6, 6, 7, 0, time.UTC)), false},
+		{"integer(1h)+timestamp", document.NewIntegerValue(int64(time.Hour)), document.NewTimestampValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewTimestampValue(time.Date(2021, 3, 4, 6, 6, 7, 0, time.UTC)), false},
+		{"timestamp+float64(10)", document.NewTimestampValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewDoubleValue(10), document.NewNullValue(), false},
+		{"timestamp+timestamp", document.NewTimestampValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewTimestampValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewNullValue(), false},
 		{"document+document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
 		{"array+array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewNullValue(), false},
 	}
@@ -148,6 +152,10 @@ func TestValueSub(t *testing.T) {
 		{"int64(max)-integer(-10)", document.NewIntegerValue(math.MaxInt64), document.NewIntegerValue(-10), document.NewDoubleValue(math.MaxInt64 + 10), false},
 		{"integer(120)-text('120')", document.NewIntegerValue(120), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"text('120')-text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
+		{"timestamp-integer(1h)", document.NewTimestampValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewIntegerValue(int64(time.Hour)), document.NewTimestampValue(time.Date(2021, 3, 4, 4, 6, 7, 0, time.UTC)), false},
+		{"timestamp-timestamp", document.NewTimestampValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewTimestampValue(time.Date(2021, 3, 4, 5, 0, 0, 0, time.UTC)), document.NewIntegerValue(int64(6*time.Minute + 7*time.Second)), false},
+		{"integer(1h)-timestamp", document.NewIntegerValue(int64(time.Hour)), document.NewTimestampValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewNullValue(), false},
+		{"timestamp-timestamp out of range", document.NewTimestampValue(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewTimestampValue(time.Date(1021, 3, 4, 5, 6, 7, 0, time.UTC)), document.NewNullValue(), true},
 		{"document-document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
 		{"array-array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewNullValue(), false},
 	}
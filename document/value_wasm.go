@@ -1,5 +1,7 @@
 package document
 
+import "time"
+
 // NewValue creates a value from x. It only supports a few type and doesn't rely on reflection.
 func NewValue(x interface{}) (Value, error) {
 	switch v := x.(type) {
@@ -17,6 +19,8 @@ func NewValue(x interface{}) (Value, error) {
 		return NewDoubleValue(v), nil
 	case string:
 		return NewTextValue(v), nil
+	case time.Time:
+		return NewTimestampValue(v), nil
 	}
 
 	return Value{}, &ErrUnsupportedType{x, ""}
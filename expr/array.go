@@ -0,0 +1,213 @@
+package expr
+
+import (
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// Array functions never modify their arguments, they return new arrays.
+// They return NULL if any of their array arguments is NULL or not an array.
+
+// arrayArgs returns the values of args as arrays.
+// It returns false if any of them is not an array.
+func arrayArgs(args ...document.Value) ([]document.Array, bool) {
+	a := make([]document.Array, len(args))
+	for i, arg := range args {
+		if arg.Type != document.ArrayValue {
+			return nil, false
+		}
+		a[i] = arg.V.(document.Array)
+	}
+
+	return a, true
+}
+
+// arrayCopy returns a copy of a.
+func arrayCopy(a document.Array) (*document.ValueBuffer, error) {
+	vb := document.NewValueBuffer()
+	err := vb.Copy(a)
+	return vb, err
+}
+
+// arrayLength returns the number of values of an array.
+func arrayLength(args []document.Value) (document.Value, error) {
+	a, ok := arrayArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	l, err := document.ArrayLength(a[0])
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewIntegerValue(int64(l)), nil
+}
+
+// arrayContains returns whether an array contains a value.
+func arrayContains(args []document.Value) (document.Value, error) {
+	a, ok := arrayArgs(args[0])
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	found, err := document.ArrayContains(a[0], args[1])
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewBoolValue(found), nil
+}
+
+// arrayAppend returns an array with a value added at the end.
+func arrayAppend(args []document.Value) (document.Value, error) {
+	a, ok := arrayArgs(args[0])
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	vb, err := arrayCopy(a[0])
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(vb.Append(args[1])), nil
+}
+
+// arrayRemove returns an array without any of the values equal to the given value.
+func arrayRemove(args []document.Value) (document.Value, error) {
+	a, ok := arrayArgs(args[0])
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	vb := document.NewValueBuffer()
+	err := a[0].Iterate(func(i int, v document.Value) error {
+		ok, err := v.IsEqual(args[1])
+		if err != nil || ok {
+			return err
+		}
+
+		vb.Append(v)
+		return nil
+	})
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(vb), nil
+}
+
+// arraySlice returns the values starting at the given position, counting from 1.
+// A negative position counts from the end of the array. If the length is omitted,
+// the slice extends to the end of the array.
+func arraySlice(args []document.Value) (document.Value, error) {
+	a, ok := arrayArgs(args[0])
+	if !ok {
+		return nullLitteral, nil
+	}
+	vb, err := arrayCopy(a[0])
+	if err != nil {
+		return nullLitteral, err
+	}
+	l := int64(len(vb.Values))
+
+	start, ok := integerArg(args[1])
+	if !ok {
+		return nullLitteral, nil
+	}
+	switch {
+	case start > 0:
+		start--
+	case start < 0:
+		start += l
+	}
+
+	end := l
+	if len(args) > 2 {
+		n, ok := integerArg(args[2])
+		if !ok {
+			return nullLitteral, nil
+		}
+		if n < 0 {
+			return nullLitteral, stringutil.Errorf("ARRAY_SLICE() length cannot be negative, got %d", n)
+		}
+		// clamp n so that start + n cannot overflow
+		if start >= 0 && n > l-start {
+			n = l - start
+		}
+		end = start + n
+	}
+
+	start = clamp(start, 0, l)
+	end = clamp(end, start, l)
+
+	return document.NewArrayValue(document.NewValueBuffer(vb.Values[start:end]...)), nil
+}
+
+// arrayConcat returns an array containing the values of all the given arrays.
+func arrayConcat(args []document.Value) (document.Value, error) {
+	arrays, ok := arrayArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	vb := document.NewValueBuffer()
+	for _, a := range arrays {
+		err := a.Iterate(func(i int, v document.Value) error {
+			vb.Append(v)
+			return nil
+		})
+		if err != nil {
+			return nullLitteral, err
+		}
+	}
+
+	return document.NewArrayValue(vb), nil
+}
+
+// arraySort returns a sorted array, using the order of document.SortArray.
+func arraySort(args []document.Value) (document.Value, error) {
+	a, ok := arrayArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	// SortArray sorts value buffers in place
+	vb, err := arrayCopy(a[0])
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	vb, err = document.SortArray(vb)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(vb), nil
+}
+
+// arrayDistinct returns an array without duplicate values,
+// keeping the first occurrence of each value.
+func arrayDistinct(args []document.Value) (document.Value, error) {
+	a, ok := arrayArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	vb := document.NewValueBuffer()
+	err := a[0].Iterate(func(i int, v document.Value) error {
+		found, err := document.ArrayContains(vb, v)
+		if err != nil || found {
+			return err
+		}
+
+		vb.Append(v)
+		return nil
+	})
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(vb), nil
+}
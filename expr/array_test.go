@@ -0,0 +1,56 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/testutil"
+)
+
+func TestArrayFunctions(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"ARRAY_LENGTH([1, 2, 3])", document.NewIntegerValue(3), false},
+		{"ARRAY_LENGTH([])", document.NewIntegerValue(0), false},
+		{"ARRAY_LENGTH(c)", document.NewIntegerValue(3), false},
+		{"ARRAY_LENGTH('foo')", nullLitteral, false},
+		{"ARRAY_LENGTH(NULL)", nullLitteral, false},
+		{"ARRAY_CONTAINS([1, 2, 3], 2)", document.NewBoolValue(true), false},
+		{"ARRAY_CONTAINS([1, 2, 3], 2.0)", document.NewBoolValue(true), false},
+		{"ARRAY_CONTAINS([1, 2, 3], 4)", document.NewBoolValue(false), false},
+		{"ARRAY_CONTAINS(c, [1, 2])", document.NewBoolValue(true), false},
+		{"ARRAY_CONTAINS(a, 1)", nullLitteral, false},
+		{"ARRAY_APPEND([1, 2], 3)", testutil.MakeArrayValue(t, 1, 2, 3), false},
+		{"ARRAY_APPEND([], 'a')", testutil.MakeArrayValue(t, "a"), false},
+		{"ARRAY_APPEND(NULL, 'a')", nullLitteral, false},
+		{"ARRAY_REMOVE([1, 2, 1, 3], 1)", testutil.MakeArrayValue(t, 2, 3), false},
+		{"ARRAY_REMOVE([1, 2], 3)", testutil.MakeArrayValue(t, 1, 2), false},
+		{"ARRAY_REMOVE(1, 1)", nullLitteral, false},
+		{"ARRAY_SLICE([1, 2, 3, 4], 2)", testutil.MakeArrayValue(t, 2, 3, 4), false},
+		{"ARRAY_SLICE([1, 2, 3, 4], 2, 2)", testutil.MakeArrayValue(t, 2, 3), false},
+		{"ARRAY_SLICE([1, 2, 3, 4], -2)", testutil.MakeArrayValue(t, 3, 4), false},
+		{"ARRAY_SLICE([1, 2, 3, 4], 3, 10)", testutil.MakeArrayValue(t, 3, 4), false},
+		{"ARRAY_SLICE([1, 2, 3], -1, 9223372036854775807)", testutil.MakeArrayValue(t, 3), false},
+		{"ARRAY_SLICE([1, 2, 3], 2, 9223372036854775807)", testutil.MakeArrayValue(t, 2, 3), false},
+		{"ARRAY_LENGTH(ARRAY_SLICE([1, 2, 3, 4], 10))", document.NewIntegerValue(0), false},
+		{"ARRAY_SLICE([1, 2, 3, 4], 1, -1)", nullLitteral, true},
+		{"ARRAY_SLICE([1, 2, 3, 4], 'a')", nullLitteral, false},
+		{"ARRAY_CONCAT([1, 2], [3], [4, 5])", testutil.MakeArrayValue(t, 1, 2, 3, 4, 5), false},
+		{"ARRAY_CONCAT([1, 2], 3)", nullLitteral, false},
+		{"ARRAY_SORT([3, 1, 2.5])", testutil.MakeArrayValue(t, 1, 2.5, 3), false},
+		{"ARRAY_SORT(['b', 1, NULL, true, 'a'])", testutil.MakeArrayValue(t, nil, true, 1, "a", "b"), false},
+		{"ARRAY_SORT(c) = [1, [1, 2], {foo: 'bar'}]", document.NewBoolValue(true), false},
+		{"ARRAY_SORT('foo')", nullLitteral, false},
+		{"ARRAY_DISTINCT([1, 2, 1, 3, 2.0])", testutil.MakeArrayValue(t, 1, 2, 3), false},
+		{"ARRAY_DISTINCT(NULL)", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package expr
+
+import (
+	"strings"
+
+	"github.com/tie/genji-release-test/document"
+)
+
+// A WhenClause is a condition of a CASE expression
+// and the result returned if the condition is met.
+type WhenClause struct {
+	When Expr
+	Then Expr
+}
+
+// CaseExpr is a conditional expression that returns the result of the first
+// WHEN clause whose condition is met.
+// In its searched form, each condition is a boolean expression:
+//   CASE WHEN a > 10 THEN 'big' WHEN a > 5 THEN 'medium' ELSE 'small' END
+// In its simple form, the Operand is compared to each condition:
+//   CASE a WHEN 1 THEN 'one' WHEN 2 THEN 'two' END
+// If no condition is met, it returns the ELSE result or NULL.
+type CaseExpr struct {
+	// Operand is only set in the simple form.
+	Operand Expr
+	Whens   []WhenClause
+	Else    Expr
+}
+
+// Eval evaluates the conditions in order and returns the result of the first one that is met.
+func (c *CaseExpr) Eval(env *Environment) (document.Value, error) {
+	var operand document.Value
+	if c.Operand != nil {
+		var err error
+		operand, err = c.Operand.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+	}
+
+	for _, w := range c.Whens {
+		v, err := w.When.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		ok, err := c.isMet(operand, v)
+		if err != nil {
+			return nullLitteral, err
+		}
+		if ok {
+			return w.Then.Eval(env)
+		}
+	}
+
+	if c.Else == nil {
+		return nullLitteral, nil
+	}
+
+	return c.Else.Eval(env)
+}
+
+// isMet returns whether the value of a WHEN condition is met.
+// In the simple form, comparing NULL with anything never matches.
+func (c *CaseExpr) isMet(operand, when document.Value) (bool, error) {
+	if c.Operand == nil {
+		return when.IsTruthy()
+	}
+
+	if operand.Type == document.NullValue || when.Type == document.NullValue {
+		return false, nil
+	}
+
+	return operand.IsEqual(when)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c *CaseExpr) IsEqual(other Expr) bool {
+	o, ok := other.(*CaseExpr)
+	if !ok {
+		return false
+	}
+
+	if len(c.Whens) != len(o.Whens) {
+		return false
+	}
+
+	for i := range c.Whens {
+		if !Equal(c.Whens[i].When, o.Whens[i].When) || !Equal(c.Whens[i].Then, o.Whens[i].Then) {
+			return false
+		}
+	}
+
+	return equalOrNil(c.Operand, o.Operand) && equalOrNil(c.Else, o.Else)
+}
+
+func equalOrNil(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return Equal(a, b)
+}
+
+// Params returns the operand, the conditions and results, and the ELSE result, if any.
+func (c *CaseExpr) Params() []Expr {
+	var params []Expr
+	if c.Operand != nil {
+		params = append(params, c.Operand)
+	}
+	for _, w := range c.Whens {
+		params = append(params, w.When, w.Then)
+	}
+	if c.Else != nil {
+		params = append(params, c.Else)
+	}
+
+	return params
+}
+
+func (c *CaseExpr) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("CASE")
+	if c.Operand != nil {
+		sb.WriteString(" ")
+		sb.WriteString(c.Operand.String())
+	}
+	for _, w := range c.Whens {
+		sb.WriteString(" WHEN ")
+		sb.WriteString(w.When.String())
+		sb.WriteString(" THEN ")
+		sb.WriteString(w.Then.String())
+	}
+	if c.Else != nil {
+		sb.WriteString(" ELSE ")
+		sb.WriteString(c.Else.String())
+	}
+	sb.WriteString(" END")
+
+	return sb.String()
+}
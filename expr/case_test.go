@@ -0,0 +1,34 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+)
+
+func TestCaseExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"CASE WHEN a = 1 THEN 'one' END", document.NewTextValue("one"), false},
+		{"CASE WHEN a > 1 THEN 'big' END", nullLitteral, false},
+		{"CASE WHEN a > 1 THEN 'big' ELSE 'small' END", document.NewTextValue("small"), false},
+		{"CASE WHEN a > 1 THEN 'big' WHEN a > 0 THEN 'medium' ELSE 'small' END", document.NewTextValue("medium"), false},
+		{"CASE WHEN notFound THEN 1 WHEN NULL THEN 2 WHEN 0 THEN 3 ELSE 4 END", document.NewIntegerValue(4), false},
+		{"CASE WHEN a THEN b.`foo bar`[0] END", document.NewIntegerValue(1), false},
+		{"CASE a WHEN 2 THEN 'two' WHEN 1 THEN 'one' END", document.NewTextValue("one"), false},
+		{"CASE a WHEN 1.0 THEN 'one' END", document.NewTextValue("one"), false},
+		{"CASE a + 1 WHEN 1 THEN 'one' ELSE 'other' END", document.NewTextValue("other"), false},
+		{"CASE NULL WHEN NULL THEN 1 ELSE 2 END", document.NewIntegerValue(2), false},
+		{"CASE notFound WHEN 1 THEN 1 END", nullLitteral, false},
+		{"CASE WHEN a = 1 THEN 1 + 'a' END", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
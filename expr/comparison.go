@@ -1,6 +1,8 @@
 package expr
 
 import (
+	"errors"
+
 	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/sql/scanner"
 	"github.com/tie/genji-release-test/stringutil"
@@ -19,8 +21,10 @@ func newCmpOp(a, b Expr, t scanner.Token) *cmpOp {
 // Eval compares a and b together using the operator specified when constructing the CmpOp
 // and returns the result of the comparison.
 // Comparing with NULL always evaluates to NULL.
+// If one of the operands is a path containing the [*] wildcard, the operator
+// returns true if the comparison is true for any of the matching values.
 func (op *cmpOp) Eval(env *Environment) (document.Value, error) {
-	return op.simpleOperator.eval(env, func(a, b document.Value) (document.Value, error) {
+	return op.simpleOperator.evalAny(env, true, func(a, b document.Value) (document.Value, error) {
 		if a.Type == document.NullValue || b.Type == document.NullValue {
 			return nullLitteral, nil
 		}
@@ -34,6 +38,87 @@ func (op *cmpOp) Eval(env *Environment) (document.Value, error) {
 	})
 }
 
+// evalAny evaluates the operands like eval. If one of them is a path containing
+// the [*] wildcard, fn is called with every combination of the matching values and
+// evalAny returns true if any of the calls returns true, NULL if one of them returns NULL,
+// and false otherwise. The right operand is only expanded if expandB is true.
+func (op *simpleOperator) evalAny(env *Environment, expandB bool, fn func(a, b document.Value) (document.Value, error)) (document.Value, error) {
+	eb := op.b
+	if !expandB {
+		eb = nil
+	}
+
+	if !isWildcardPath(op.a) && !isWildcardPath(eb) {
+		return op.eval(env, fn)
+	}
+
+	return op.eval(env, func(a, b document.Value) (document.Value, error) {
+		return anyValue(op.a, eb, a, b, fn)
+	})
+}
+
+// anyValue calls fn with every combination of the values matched by ea and eb,
+// whose values are a and b, see wildcardValues.
+func anyValue(ea, eb Expr, a, b document.Value, fn func(a, b document.Value) (document.Value, error)) (document.Value, error) {
+	as, ok := wildcardValues(ea, a)
+	if !ok {
+		return nullLitteral, nil
+	}
+	bs, ok := wildcardValues(eb, b)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	var hasNull bool
+	for _, l := range as {
+		for _, r := range bs {
+			v, err := fn(l, r)
+			if err != nil {
+				return nullLitteral, err
+			}
+
+			switch {
+			case v.Type == document.NullValue:
+				hasNull = true
+			case v.Type == document.BoolValue && v.V.(bool):
+				return trueLitteral, nil
+			}
+		}
+	}
+
+	if hasNull {
+		return nullLitteral, nil
+	}
+
+	return falseLitteral, nil
+}
+
+// isWildcardPath returns whether e is a path containing the [*] wildcard.
+func isWildcardPath(e Expr) bool {
+	p, ok := e.(Path)
+	return ok && document.Path(p).HasWildcard()
+}
+
+// wildcardValues returns the values matched by e if it is a wildcard path,
+// or v itself otherwise. It returns false if the values of a wildcard
+// path are not an array.
+func wildcardValues(e Expr, v document.Value) ([]document.Value, bool) {
+	if !isWildcardPath(e) {
+		return []document.Value{v}, true
+	}
+	if v.Type != document.ArrayValue {
+		return nil, false
+	}
+
+	var values []document.Value
+	err := v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+		values = append(values, v)
+		return nil
+	})
+
+	return values, err == nil
+}
+
 func (op *cmpOp) compare(l, r document.Value) (bool, error) {
 	switch op.Tok {
 	case scanner.EQ:
@@ -96,28 +181,33 @@ func Between(a Expr) func(x, b Expr) Expr {
 	}
 }
 
+// Eval returns true if x is between a and b.
+// If x, a or b is a path containing the [*] wildcard, it returns true
+// if any of the matching values satisfies the condition.
 func (op *BetweenOperator) Eval(env *Environment) (document.Value, error) {
 	x, err := op.X.Eval(env)
 	if err != nil {
 		return falseLitteral, err
 	}
 
-	return op.simpleOperator.eval(env, func(a, b document.Value) (document.Value, error) {
+	return op.simpleOperator.evalAny(env, true, func(a, b document.Value) (document.Value, error) {
 		if a.Type == document.NullValue || b.Type == document.NullValue {
 			return nullLitteral, nil
 		}
 
-		ok, err := x.IsGreaterThanOrEqual(a)
-		if !ok || err != nil {
-			return falseLitteral, err
-		}
+		return anyValue(op.X, nil, x, nullLitteral, func(x, _ document.Value) (document.Value, error) {
+			ok, err := x.IsGreaterThanOrEqual(a)
+			if !ok || err != nil {
+				return falseLitteral, err
+			}
 
-		ok, err = x.IsLesserThanOrEqual(b)
-		if !ok || err != nil {
-			return falseLitteral, err
-		}
+			ok, err = x.IsLesserThanOrEqual(b)
+			if !ok || err != nil {
+				return falseLitteral, err
+			}
 
-		return trueLitteral, nil
+			return trueLitteral, nil
+		})
 	})
 }
 
@@ -126,10 +216,10 @@ func (op *BetweenOperator) String() string {
 }
 
 // IsComparisonOperator returns true if e is one of
-// =, !=, >, >=, <, <=, IS, IS NOT, IN, or NOT IN operators.
+// =, !=, >, >=, <, <=, IS, IS NOT, IN, NOT IN, ANY, ALL, LIKE, NOT LIKE, =~, !~ or BETWEEN operators.
 func IsComparisonOperator(op Operator) bool {
 	switch op.(type) {
-	case *cmpOp, *IsOperator, *IsNotOperator, *InOperator, *NotInOperator, *LikeOperator, *NotLikeOperator, *BetweenOperator:
+	case *cmpOp, *IsOperator, *IsNotOperator, *InOperator, *NotInOperator, *AnyOperator, *AllOperator, *LikeOperator, *NotLikeOperator, *RegexpOperator, *BetweenOperator:
 		return true
 	}
 
@@ -145,26 +235,31 @@ func In(a, b Expr) Expr {
 	return &InOperator{&simpleOperator{a, b, scanner.IN}}
 }
 
+// Eval returns true if a is one of the values of the b array.
+// If a is a path containing the [*] wildcard, it returns true if any of
+// the matching values is in b.
 func (op *InOperator) Eval(env *Environment) (document.Value, error) {
-	return op.simpleOperator.eval(env, func(a, b document.Value) (document.Value, error) {
-		if a.Type == document.NullValue || b.Type == document.NullValue {
-			return nullLitteral, nil
-		}
-
-		if b.Type != document.ArrayValue {
-			return falseLitteral, nil
-		}
+	return op.simpleOperator.evalAny(env, false, in)
+}
 
-		ok, err := document.ArrayContains(b.V.(document.Array), a)
-		if err != nil {
-			return nullLitteral, err
-		}
+func in(a, b document.Value) (document.Value, error) {
+	if a.Type == document.NullValue || b.Type == document.NullValue {
+		return nullLitteral, nil
+	}
 
-		if ok {
-			return trueLitteral, nil
-		}
+	if b.Type != document.ArrayValue {
 		return falseLitteral, nil
-	})
+	}
+
+	ok, err := document.ArrayContains(b.V.(document.Array), a)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if ok {
+		return trueLitteral, nil
+	}
+	return falseLitteral, nil
 }
 
 type NotInOperator struct {
@@ -176,21 +271,155 @@ func NotIn(a, b Expr) Expr {
 	return &NotInOperator{InOperator{&simpleOperator{a, b, scanner.NIN}}}
 }
 
+// Eval returns true if a is not one of the values of the b array.
+// If a is a path containing the [*] wildcard, it returns true if any of
+// the matching values is not in b.
 func (op *NotInOperator) Eval(env *Environment) (document.Value, error) {
-	return invertBoolResult(op.InOperator.Eval)(env)
+	return op.simpleOperator.evalAny(env, false, func(a, b document.Value) (document.Value, error) {
+		v, err := in(a, b)
+		return invertBool(v), err
+	})
 }
 
 func (op *NotInOperator) String() string {
 	return stringutil.Sprintf("%v NOT IN %v", op.a, op.b)
 }
 
+var errStop = errors.New("stop")
+
+// compareArray compares a with each value of the b array using the comparison operator tok.
+// It stops as soon as a comparison returns stopOn and returns stopOn.
+// If it doesn't and at least one of the values is NULL, it returns NULL, otherwise it returns !stopOn.
+// Comparing NULL or a value that is not an array always returns NULL.
+func compareArray(tok scanner.Token, a, b document.Value, stopOn bool) (document.Value, error) {
+	if a.Type == document.NullValue || b.Type != document.ArrayValue {
+		return nullLitteral, nil
+	}
+
+	op := newCmpOp(nil, nil, tok)
+	var stopped, hasNull bool
+	err := b.V.(document.Array).Iterate(func(i int, v document.Value) error {
+		if v.Type == document.NullValue {
+			hasNull = true
+			return nil
+		}
+
+		ok, err := op.compare(a, v)
+		if err != nil {
+			return err
+		}
+		if ok == stopOn {
+			stopped = true
+			return errStop
+		}
+
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nullLitteral, err
+	}
+
+	switch {
+	case stopped:
+		return document.NewBoolValue(stopOn), nil
+	case hasNull:
+		return nullLitteral, nil
+	}
+
+	return document.NewBoolValue(!stopOn), nil
+}
+
+// AnyOperator compares a value with every value of an array
+// and returns true if one of the comparisons is true.
+type AnyOperator struct {
+	*simpleOperator
+	// Op is the comparison operator.
+	Op scanner.Token
+}
+
+// Any returns a function that creates an ANY operator that
+// returns true if a op b[i] is true for any value of the b array.
+func Any(op scanner.Token) func(a, b Expr) Expr {
+	return func(a, b Expr) Expr {
+		return &AnyOperator{&simpleOperator{a, b, scanner.ANY}, op}
+	}
+}
+
+func (op *AnyOperator) Eval(env *Environment) (document.Value, error) {
+	return op.simpleOperator.eval(env, func(a, b document.Value) (document.Value, error) {
+		return compareArray(op.Op, a, b, true)
+	})
+}
+
+// Precedence returns the precedence of the comparison operator.
+func (op *AnyOperator) Precedence() int {
+	return op.Op.Precedence()
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (op *AnyOperator) IsEqual(other Expr) bool {
+	o, ok := other.(*AnyOperator)
+	if !ok {
+		return false
+	}
+
+	return op.Op == o.Op && op.simpleOperator.IsEqual(o)
+}
+
+func (op *AnyOperator) String() string {
+	return stringutil.Sprintf("%v %v ANY(%v)", op.a, op.Op, op.b)
+}
+
+// AllOperator compares a value with every value of an array
+// and returns true if all of the comparisons are true.
+type AllOperator struct {
+	*simpleOperator
+	// Op is the comparison operator.
+	Op scanner.Token
+}
+
+// All returns a function that creates an ALL operator that
+// returns true if a op b[i] is true for every value of the b array.
+func All(op scanner.Token) func(a, b Expr) Expr {
+	return func(a, b Expr) Expr {
+		return &AllOperator{&simpleOperator{a, b, scanner.ALL}, op}
+	}
+}
+
+func (op *AllOperator) Eval(env *Environment) (document.Value, error) {
+	return op.simpleOperator.eval(env, func(a, b document.Value) (document.Value, error) {
+		return compareArray(op.Op, a, b, false)
+	})
+}
+
+// Precedence returns the precedence of the comparison operator.
+func (op *AllOperator) Precedence() int {
+	return op.Op.Precedence()
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (op *AllOperator) IsEqual(other Expr) bool {
+	o, ok := other.(*AllOperator)
+	if !ok {
+		return false
+	}
+
+	return op.Op == o.Op && op.simpleOperator.IsEqual(o)
+}
+
+func (op *AllOperator) String() string {
+	return stringutil.Sprintf("%v %v ALL(%v)", op.a, op.Op, op.b)
+}
+
 type IsOperator struct {
 	*simpleOperator
 }
 
 // Is creates an expression that evaluates to the result of a IS b.
 func Is(a, b Expr) Expr {
-	return &IsOperator{&simpleOperator{a, b, scanner.IN}}
+	return &IsOperator{&simpleOperator{a, b, scanner.IS}}
 }
 
 func (op *IsOperator) Eval(env *Environment) (document.Value, error) {
@@ -89,6 +89,110 @@ func TestComparisonNOTINExpr(t *testing.T) {
 	}
 }
 
+func TestComparisonANYExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"1 = ANY([])", document.NewBoolValue(false), false},
+		{"1 = ANY([1, 2, 3])", document.NewBoolValue(true), false},
+		{"1 = ANY(1, 2, 3)", document.NewBoolValue(true), false},
+		{"2 = ANY([2.1, 2.2, 2.0])", document.NewBoolValue(true), false},
+		{"1 = ANY([2, 3])", document.NewBoolValue(false), false},
+		{"1 != ANY([1, 2])", document.NewBoolValue(true), false},
+		{"1 > ANY([2, 0])", document.NewBoolValue(true), false},
+		{"1 >= ANY([2, 3])", document.NewBoolValue(false), false},
+		{"1 < ANY([0, 2])", document.NewBoolValue(true), false},
+		{"1 <= ANY([0, -1])", document.NewBoolValue(false), false},
+		{"1 = ANY([2, NULL])", nullLitteral, false},
+		{"1 = ANY([1, NULL])", document.NewBoolValue(true), false},
+		{"a = ANY(b.`foo bar`)", document.NewBoolValue(true), false},
+		{"a = ANY(b)", nullLitteral, false},
+		{"a + 1 = ANY([2, 3]) AND true", document.NewBoolValue(true), false},
+		{"1 = ANY(1)", nullLitteral, false},
+		{"1 = ANY(NULL)", nullLitteral, false},
+		{"NULL = ANY([1, NULL])", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
+
+func TestComparisonALLExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"1 = ALL([])", document.NewBoolValue(true), false},
+		{"1 = ALL([1, 1.0])", document.NewBoolValue(true), false},
+		{"1 = ALL([1, 2])", document.NewBoolValue(false), false},
+		{"1 != ALL([2, 3])", document.NewBoolValue(true), false},
+		{"3 > ALL([1, 2])", document.NewBoolValue(true), false},
+		{"2 > ALL([1, 2])", document.NewBoolValue(false), false},
+		{"2 >= ALL([1, 2])", document.NewBoolValue(true), false},
+		{"1 < ALL([2, 3])", document.NewBoolValue(true), false},
+		{"1 <= ALL([0, 3])", document.NewBoolValue(false), false},
+		{"3 > ALL([1, NULL])", nullLitteral, false},
+		{"3 > ALL([4, NULL])", document.NewBoolValue(false), false},
+		{"1 = ALL(1)", nullLitteral, false},
+		{"NULL = ALL([1])", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
+
+func TestComparisonWildcardExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"c[*] = 1", document.NewBoolValue(true), false},
+		{"c[*] = 2", document.NewBoolValue(false), false},
+		{"1 = c[*]", document.NewBoolValue(true), false},
+		{"c[*] > 0", document.NewBoolValue(true), false},
+		{"c[*].foo = 'bar'", document.NewBoolValue(true), false},
+		{"c[*].foo != 'bar'", document.NewBoolValue(false), false},
+		{"c[*][1] = 2", document.NewBoolValue(true), false},
+		{"b.`foo bar`[*] = c[*][1]", document.NewBoolValue(true), false},
+		{"c[*].bar = 1", document.NewBoolValue(false), false},
+		{"c[*] = NULL", nullLitteral, false},
+		{"a[*] = 1", nullLitteral, false},
+		{"b[*] = 1", nullLitteral, false},
+		{"c[*] IN [1, 5]", document.NewBoolValue(true), false},
+		{"c[*] IN [5]", document.NewBoolValue(false), false},
+		{"c[*].foo IN ['bar']", document.NewBoolValue(true), false},
+		{"1 IN c[*]", document.NewBoolValue(true), false},
+		{"a[*] IN [1]", nullLitteral, false},
+		{"c[*] NOT IN [1]", document.NewBoolValue(true), false},
+		{"c[*].foo NOT IN ['bar']", document.NewBoolValue(false), false},
+		{"c[*].foo LIKE 'b%'", document.NewBoolValue(true), false},
+		{"c[*].foo LIKE 'x%'", document.NewBoolValue(false), false},
+		{"c[*].foo NOT LIKE 'b%'", document.NewBoolValue(false), false},
+		{"c[*].foo =~ '^ba'", document.NewBoolValue(true), false},
+		{"c[*].foo !~ '^ba'", document.NewBoolValue(false), false},
+		{"c[*] BETWEEN 0 AND 2", document.NewBoolValue(true), false},
+		{"c[*][1] BETWEEN 3 AND 4", document.NewBoolValue(false), false},
+		{"1 BETWEEN c[*][1] AND 3", document.NewBoolValue(false), false},
+		{"2 BETWEEN c[*][1] AND 3", document.NewBoolValue(true), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
+
 func TestComparisonISExpr(t *testing.T) {
 	tests := []struct {
 		expr  string
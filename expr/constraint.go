@@ -0,0 +1,47 @@
+package expr
+
+import (
+	"github.com/tie/genji-release-test/database"
+	"github.com/tie/genji-release-test/document"
+)
+
+// ConstraintExpr wraps an expression used by a table constraint,
+// such as a CHECK constraint. It implements the database.TableExpression interface.
+type ConstraintExpr struct {
+	Expr Expr
+}
+
+// Constraint creates a ConstraintExpr from e.
+func Constraint(e Expr) *ConstraintExpr {
+	return &ConstraintExpr{Expr: e}
+}
+
+// Eval evaluates the expression against the given document.
+func (t *ConstraintExpr) Eval(tx *database.Transaction, d document.Document) (document.Value, error) {
+	env := NewEnvironment(d)
+	env.Tx = tx
+
+	return t.Expr.Eval(env)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (t *ConstraintExpr) IsEqual(other database.TableExpression) bool {
+	if t == nil {
+		return other == nil
+	}
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ConstraintExpr)
+	if !ok {
+		return false
+	}
+
+	return Equal(t.Expr, o.Expr)
+}
+
+func (t *ConstraintExpr) String() string {
+	return t.Expr.String()
+}
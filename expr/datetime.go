@@ -0,0 +1,134 @@
+package expr
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// Date-time functions operate on timestamps, which are always stored in UTC.
+// They return NULL if any of their arguments is NULL or of an unexpected type.
+// Intervals are represented by an integer number of nanoseconds.
+
+// NowFunc represents the NOW() function.
+// It returns the current timestamp.
+type NowFunc struct{}
+
+// Eval returns the current timestamp.
+func (n *NowFunc) Eval(env *Environment) (document.Value, error) {
+	return document.NewTimestampValue(time.Now()), nil
+}
+
+func (*NowFunc) Params() []Expr { return nil }
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (n *NowFunc) IsEqual(other Expr) bool {
+	_, ok := other.(*NowFunc)
+	return ok
+}
+
+func (n *NowFunc) String() string {
+	return "NOW()"
+}
+
+// dateTruncUnits truncates a timestamp to the beginning of a given unit.
+// Weeks start on monday.
+var dateTruncUnits = map[string]func(t time.Time) time.Time{
+	"microsecond": func(t time.Time) time.Time { return t.Truncate(time.Microsecond) },
+	"millisecond": func(t time.Time) time.Time { return t.Truncate(time.Millisecond) },
+	"second":      func(t time.Time) time.Time { return t.Truncate(time.Second) },
+	"minute":      func(t time.Time) time.Time { return t.Truncate(time.Minute) },
+	"hour":        func(t time.Time) time.Time { return t.Truncate(time.Hour) },
+	"day": func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	},
+	"week": func(t time.Time) time.Time {
+		days := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, time.UTC)
+	},
+	"month": func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	},
+	"year": func(t time.Time) time.Time {
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	},
+}
+
+// dateTrunc truncates a timestamp to the unit given by the first argument.
+func dateTrunc(args []document.Value) (document.Value, error) {
+	if args[0].Type != document.TextValue || args[1].Type != document.TimestampValue {
+		return nullLitteral, nil
+	}
+
+	trunc, ok := dateTruncUnits[strings.ToLower(args[0].V.(string))]
+	if !ok {
+		return nullLitteral, stringutil.Errorf("DATE_TRUNC() unknown unit %q", args[0].V)
+	}
+
+	return document.NewTimestampValue(trunc(args[1].V.(time.Time))), nil
+}
+
+// extractFields returns a field of a timestamp.
+var extractFields = map[string]func(t time.Time) int64{
+	"YEAR":   func(t time.Time) int64 { return int64(t.Year()) },
+	"MONTH":  func(t time.Time) int64 { return int64(t.Month()) },
+	"DAY":    func(t time.Time) int64 { return int64(t.Day()) },
+	"HOUR":   func(t time.Time) int64 { return int64(t.Hour()) },
+	"MINUTE": func(t time.Time) int64 { return int64(t.Minute()) },
+	"SECOND": func(t time.Time) int64 { return int64(t.Second()) },
+	"DOW":    func(t time.Time) int64 { return int64(t.Weekday()) },
+	"DOY":    func(t time.Time) int64 { return int64(t.YearDay()) },
+	"EPOCH":  func(t time.Time) int64 { return t.Unix() },
+}
+
+// ExtractFunc represents the EXTRACT expression.
+// It returns a field of a timestamp as an integer. The day of the week (DOW)
+// starts at 0 on sunday and the EPOCH is the number of seconds since the unix epoch.
+type ExtractFunc struct {
+	Field string
+	Expr  Expr
+}
+
+// NewExtractFunc returns an EXTRACT expression. It fails if the field is unknown.
+func NewExtractFunc(field string, e Expr) (*ExtractFunc, error) {
+	field = strings.ToUpper(field)
+	if _, ok := extractFields[field]; !ok {
+		return nil, stringutil.Errorf("EXTRACT() unknown field %q", field)
+	}
+
+	return &ExtractFunc{Field: field, Expr: e}, nil
+}
+
+// Eval returns the selected field of the timestamp.
+func (e *ExtractFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := e.Expr.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.Type != document.TimestampValue {
+		return nullLitteral, nil
+	}
+
+	return document.NewIntegerValue(extractFields[e.Field](v.V.(time.Time))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (e *ExtractFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ExtractFunc)
+	if !ok {
+		return false
+	}
+
+	return e.Field == o.Field && Equal(e.Expr, o.Expr)
+}
+
+func (e *ExtractFunc) Params() []Expr { return []Expr{e.Expr} }
+
+func (e *ExtractFunc) String() string {
+	return stringutil.Sprintf("EXTRACT(%s FROM %v)", e.Field, e.Expr)
+}
@@ -0,0 +1,69 @@
+package expr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateTimeFunctions(t *testing.T) {
+	ts := func(s string) document.Value {
+		v, err := document.NewTextValue(s).CastAsTimestamp()
+		require.NoError(t, err)
+		return v
+	}
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"CAST('2021-03-04T05:06:07Z' AS TIMESTAMP)", ts("2021-03-04T05:06:07Z"), false},
+		{"CAST('2021-03-04T05:06:07+02:00' AS TIMESTAMP)", ts("2021-03-04T03:06:07Z"), false},
+		{"CAST('2021-03-04' AS TIMESTAMP)", ts("2021-03-04T00:00:00Z"), false},
+		{"CAST('foo' AS TIMESTAMP)", nullLitteral, true},
+		{"CAST(CAST(0 AS TIMESTAMP) AS TEXT)", document.NewTextValue("1970-01-01T00:00:00Z"), false},
+		{"CAST(CAST('2021-03-04' AS TIMESTAMP) AS INTEGER)", document.NewIntegerValue(1614816000), false},
+		{"CAST('2021-03-04 05:06:07' AS TIMESTAMP) + INTERVAL '1 day'", ts("2021-03-05T05:06:07Z"), false},
+		{"CAST('2021-03-04 05:06:07' AS TIMESTAMP) - INTERVAL '1h'", ts("2021-03-04T04:06:07Z"), false},
+		{"CAST('2021-03-05' AS TIMESTAMP) - CAST('2021-03-04' AS TIMESTAMP)", document.NewIntegerValue(int64(24 * time.Hour)), false},
+		{"CAST('2021-03-05' AS TIMESTAMP) > CAST('2021-03-04' AS TIMESTAMP)", document.NewBoolValue(true), false},
+		{"CAST('2021-03-05' AS TIMESTAMP) = '2021-03-05T00:00:00Z'", document.NewBoolValue(true), false},
+		{"CAST('2021-03-05' AS TIMESTAMP) * 2", nullLitteral, false},
+		{"DATE_TRUNC('hour', CAST('2021-03-04 05:06:07.89' AS TIMESTAMP))", ts("2021-03-04T05:00:00Z"), false},
+		{"DATE_TRUNC('DAY', CAST('2021-03-04 05:06:07' AS TIMESTAMP))", ts("2021-03-04T00:00:00Z"), false},
+		{"DATE_TRUNC('week', CAST('2021-03-07 05:06:07' AS TIMESTAMP))", ts("2021-03-01T00:00:00Z"), false},
+		{"DATE_TRUNC('month', CAST('2021-03-04 05:06:07' AS TIMESTAMP))", ts("2021-03-01T00:00:00Z"), false},
+		{"DATE_TRUNC('year', CAST('2021-03-04 05:06:07' AS TIMESTAMP))", ts("2021-01-01T00:00:00Z"), false},
+		{"DATE_TRUNC('foo', CAST('2021-03-04' AS TIMESTAMP))", nullLitteral, true},
+		{"DATE_TRUNC('day', a)", nullLitteral, false},
+		{"EXTRACT(YEAR FROM CAST('2021-03-04 05:06:07' AS TIMESTAMP))", document.NewIntegerValue(2021), false},
+		{"EXTRACT(month FROM CAST('2021-03-04 05:06:07' AS TIMESTAMP))", document.NewIntegerValue(3), false},
+		{"EXTRACT(DAY FROM CAST('2021-03-04 05:06:07' AS TIMESTAMP))", document.NewIntegerValue(4), false},
+		{"EXTRACT(HOUR FROM CAST('2021-03-04 05:06:07' AS TIMESTAMP))", document.NewIntegerValue(5), false},
+		{"EXTRACT(MINUTE FROM CAST('2021-03-04 05:06:07' AS TIMESTAMP))", document.NewIntegerValue(6), false},
+		{"EXTRACT(SECOND FROM CAST('2021-03-04 05:06:07' AS TIMESTAMP))", document.NewIntegerValue(7), false},
+		{"EXTRACT(DOW FROM CAST('2021-03-07' AS TIMESTAMP))", document.NewIntegerValue(0), false},
+		{"EXTRACT(DOY FROM CAST('2021-03-04' AS TIMESTAMP))", document.NewIntegerValue(63), false},
+		{"EXTRACT(EPOCH FROM CAST('2021-03-04' AS TIMESTAMP))", document.NewIntegerValue(1614816000), false},
+		{"EXTRACT(YEAR FROM a)", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
+
+func TestNowFunc(t *testing.T) {
+	before := time.Now()
+	v, err := new(expr.NowFunc).Eval(&expr.Environment{})
+	require.NoError(t, err)
+	require.Equal(t, document.TimestampValue, v.Type)
+	require.False(t, v.V.(time.Time).Before(before))
+	require.False(t, v.V.(time.Time).After(time.Now()))
+}
@@ -0,0 +1,109 @@
+package expr
+
+import (
+	"github.com/tie/genji-release-test/document"
+)
+
+// Document functions never modify their arguments, they return new documents.
+// Except for JSON_PATCH, they return NULL if any of their document arguments
+// is NULL or not a document.
+
+// documentArgs returns the values of args as documents.
+// It returns false if any of them is not a document.
+func documentArgs(args ...document.Value) ([]document.Document, bool) {
+	d := make([]document.Document, len(args))
+	for i, arg := range args {
+		if arg.Type != document.DocumentValue {
+			return nil, false
+		}
+		d[i] = arg.V.(document.Document)
+	}
+
+	return d, true
+}
+
+// documentKeys returns an array of the field names of a document.
+func documentKeys(args []document.Value) (document.Value, error) {
+	d, ok := documentArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	vb := document.NewValueBuffer()
+	err := d[0].Iterate(func(f string, v document.Value) error {
+		vb.Append(document.NewTextValue(f))
+		return nil
+	})
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(vb), nil
+}
+
+// documentValues returns an array of the values of a document.
+func documentValues(args []document.Value) (document.Value, error) {
+	d, ok := documentArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	vb := document.NewValueBuffer()
+	err := d[0].Iterate(func(f string, v document.Value) error {
+		vb.Append(v)
+		return nil
+	})
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(vb), nil
+}
+
+// documentMerge returns a document containing the fields of all the given documents.
+// If a field is present in more than one document, the last value is kept.
+func documentMerge(args []document.Value) (document.Value, error) {
+	docs, ok := documentArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	fb := document.NewFieldBuffer()
+	for _, d := range docs {
+		err := d.Iterate(func(f string, v document.Value) error {
+			if fb.Replace(f, v) == document.ErrFieldNotFound {
+				fb.Add(f, v)
+			}
+			return nil
+		})
+		if err != nil {
+			return nullLitteral, err
+		}
+	}
+
+	return document.NewDocumentValue(fb), nil
+}
+
+// jsonPatch applies a JSON merge patch, as described in RFC 7396, to a value.
+// If the patch is not a document, it replaces the value. Otherwise, if the value
+// is not a document, the patch is applied to an empty document.
+func jsonPatch(args []document.Value) (document.Value, error) {
+	if args[1].Type != document.DocumentValue {
+		return args[1], nil
+	}
+
+	fb := document.NewFieldBuffer()
+	if args[0].Type == document.DocumentValue {
+		err := fb.Copy(args[0].V.(document.Document))
+		if err != nil {
+			return nullLitteral, err
+		}
+	}
+
+	err := fb.Patch(args[1].V.(document.Document))
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewDocumentValue(fb), nil
+}
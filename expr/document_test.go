@@ -0,0 +1,41 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/testutil"
+)
+
+func TestDocumentFunctions(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"DOCUMENT_KEYS({a: 1, b: 2})", testutil.MakeArrayValue(t, "a", "b"), false},
+		{"DOCUMENT_KEYS({}) = []", document.NewBoolValue(true), false},
+		{"DOCUMENT_KEYS(b)", testutil.MakeArrayValue(t, "foo bar"), false},
+		{"DOCUMENT_KEYS(a)", nullLitteral, false},
+		{"DOCUMENT_KEYS(NULL)", nullLitteral, false},
+		{"DOCUMENT_VALUES({a: 1, b: 'c'})", testutil.MakeArrayValue(t, 1, "c"), false},
+		{"DOCUMENT_VALUES(b) = [[1, 2]]", document.NewBoolValue(true), false},
+		{"DOCUMENT_VALUES([1])", nullLitteral, false},
+		{"DOCUMENT_MERGE({a: 1, b: 2}, {b: 3, c: 4}) = {a: 1, b: 3, c: 4}", document.NewBoolValue(true), false},
+		{"DOCUMENT_MERGE({a: {b: 1}}, {a: {c: 2}}) = {a: {c: 2}}", document.NewBoolValue(true), false},
+		{"DOCUMENT_MERGE({a: 1}, {b: 2}, {a: 3}) = {a: 3, b: 2}", document.NewBoolValue(true), false},
+		{"DOCUMENT_MERGE({a: 1}, NULL)", nullLitteral, false},
+		{"JSON_PATCH({a: 1, b: 2}, {b: NULL, c: 3}) = {a: 1, c: 3}", document.NewBoolValue(true), false},
+		{"JSON_PATCH({a: {b: 1, c: 2}}, {a: {b: NULL}}) = {a: {c: 2}}", document.NewBoolValue(true), false},
+		{"JSON_PATCH(b, {`foo bar`: NULL}) = {}", document.NewBoolValue(true), false},
+		{"JSON_PATCH(NULL, {a: {b: NULL}}) = {a: {}}", document.NewBoolValue(true), false},
+		{"JSON_PATCH({a: 1}, [1, 2])", testutil.MakeArrayValue(t, 1, 2), false},
+		{"JSON_PATCH({a: 1}, NULL)", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
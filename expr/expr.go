@@ -69,21 +69,15 @@ func (p Parentheses) String() string {
 	return stringutil.Sprintf("(%v)", p.E)
 }
 
-func invertBoolResult(f func(env *Environment) (document.Value, error)) func(env *Environment) (document.Value, error) {
-	return func(env *Environment) (document.Value, error) {
-		v, err := f(env)
-
-		if err != nil {
-			return v, err
-		}
-		if v == trueLitteral {
-			return falseLitteral, nil
-		}
-		if v == falseLitteral {
-			return trueLitteral, nil
-		}
-		return v, nil
+// invertBool returns the opposite of v if it is a boolean, or v otherwise.
+func invertBool(v document.Value) document.Value {
+	if v == trueLitteral {
+		return falseLitteral
 	}
+	if v == falseLitteral {
+		return trueLitteral
+	}
+	return v
 }
 
 // NamedExpr is an expression with a name.
@@ -102,6 +96,37 @@ func (e NamedExpr) String() string {
 	return stringutil.Sprintf("%s", e.Expr)
 }
 
+// A GroupedExpr is an expression of the GROUP BY clause used after the aggregation.
+// Like aggregation functions, its value is read from the field named after the expression
+// in the document returned by the aggregation, instead of being evaluated.
+type GroupedExpr struct {
+	Expr Expr
+}
+
+// Eval returns the value of the expression for the current group.
+func (g GroupedExpr) Eval(env *Environment) (document.Value, error) {
+	d, ok := env.GetDocument()
+	if !ok {
+		return nullLitteral, stringutil.Errorf("misuse of grouped expression %s", g.Expr)
+	}
+
+	return d.GetByField(g.Expr.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (g GroupedExpr) IsEqual(other Expr) bool {
+	if o, ok := other.(GroupedExpr); ok {
+		return Equal(g.Expr, o.Expr)
+	}
+
+	return Equal(g.Expr, other)
+}
+
+func (g GroupedExpr) String() string {
+	return g.Expr.String()
+}
+
 func Walk(e Expr, fn func(Expr) bool) bool {
 	if e == nil {
 		return true
@@ -120,6 +145,24 @@ func Walk(e Expr, fn func(Expr) bool) bool {
 		}
 	case *NamedExpr:
 		return Walk(t.Expr, fn)
+	case Parentheses:
+		return Walk(t.E, fn)
+	case Exists:
+		return Walk(t.Subquery, fn)
+	case ScalarSubquery:
+		return Walk(t.Subquery, fn)
+	case LiteralExprList:
+		for _, e := range t {
+			if !Walk(e, fn) {
+				return false
+			}
+		}
+	case *KVPairs:
+		for _, kv := range t.Pairs {
+			if !Walk(kv.V, fn) {
+				return false
+			}
+		}
 	case Function:
 		for _, p := range t.Params() {
 			if !Walk(p, fn) {
@@ -128,5 +171,19 @@ func Walk(e Expr, fn func(Expr) bool) bool {
 		}
 	}
 
-	return false
+	return true
+}
+
+// A SortKey is an expression used to sort documents, in ascending or descending order.
+type SortKey struct {
+	Expr Expr
+	Desc bool
+}
+
+func (k SortKey) String() string {
+	if k.Desc {
+		return stringutil.Sprintf("%v DESC", k.Expr)
+	}
+
+	return stringutil.Sprintf("%v", k.Expr)
 }
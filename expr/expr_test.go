@@ -66,6 +66,10 @@ func TestString(t *testing.T) {
 		`{"a": "foo", "b": 10}`,
 		"pk()",
 		"CAST(10 AS integer)",
+		`CASE WHEN a > 1 THEN "x" ELSE "y" END`,
+		`CASE a WHEN 1 THEN "x" WHEN 2 THEN "y" END`,
+		"a = ANY([1, 2])",
+		"a > ALL([1, 2])",
 	}
 
 	var operators = []string{
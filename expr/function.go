@@ -2,6 +2,7 @@ package expr
 
 import (
 	"errors"
+	"math"
 	"strings"
 
 	"github.com/tie/genji-release-test/document"
@@ -60,6 +61,127 @@ func BuiltinFunctions() map[string]func(args ...Expr) (Expr, error) {
 			}
 			return &AvgFunc{Expr: args[0]}, nil
 		},
+		"coalesce": func(args ...Expr) (Expr, error) {
+			if len(args) == 0 {
+				return nil, stringutil.Errorf("COALESCE() takes at least 1 argument")
+			}
+			return &CoalesceFunc{Exprs: args}, nil
+		},
+		"ifnull": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, stringutil.Errorf("IFNULL() takes 2 arguments")
+			}
+			return &IfNullFunc{Expr: args[0], Default: args[1]}, nil
+		},
+		"nullif": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, stringutil.Errorf("NULLIF() takes 2 arguments")
+			}
+			return &NullIfFunc{Expr: args[0], Other: args[1]}, nil
+		},
+		"typeof": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, stringutil.Errorf("TYPEOF() takes 1 argument")
+			}
+			return &TypeOfFunc{Expr: args[0]}, nil
+		},
+		"lower":    scalarFunc("LOWER", 1, 1, textFunc(strings.ToLower)),
+		"upper":    scalarFunc("UPPER", 1, 1, textFunc(strings.ToUpper)),
+		"trim":     scalarFunc("TRIM", 1, 2, trimFunc(strings.TrimSpace, strings.Trim)),
+		"ltrim":    scalarFunc("LTRIM", 1, 2, trimFunc(trimLeftSpace, strings.TrimLeft)),
+		"rtrim":    scalarFunc("RTRIM", 1, 2, trimFunc(trimRightSpace, strings.TrimRight)),
+		"substr":   scalarFunc("SUBSTR", 2, 3, substr),
+		"length":   scalarFunc("LENGTH", 1, 1, length),
+		"replace":  scalarFunc("REPLACE", 3, 3, replace),
+		"instr":    scalarFunc("INSTR", 2, 2, instr),
+		"split":    scalarFunc("SPLIT", 2, 2, split),
+		"lpad":     scalarFunc("LPAD", 2, 3, padFunc("LPAD", true)),
+		"rpad":     scalarFunc("RPAD", 2, 3, padFunc("RPAD", false)),
+		"format":   scalarFunc("FORMAT", 1, -1, format),
+		"abs":      scalarFunc("ABS", 1, 1, mathFunc("ABS", absInteger, math.Abs)),
+		"sign":     scalarFunc("SIGN", 1, 1, mathFunc("SIGN", signInteger, signDouble)),
+		"floor":    scalarFunc("FLOOR", 1, 1, mathFunc("FLOOR", identity, math.Floor)),
+		"ceil":     scalarFunc("CEIL", 1, 1, mathFunc("CEIL", identity, math.Ceil)),
+		"round":    scalarFunc("ROUND", 1, 2, round),
+		"pow":      scalarFunc("POW", 2, 2, pow),
+		"sqrt":     scalarFunc("SQRT", 1, 1, sqrt),
+		"exp":      scalarFunc("EXP", 1, 1, mathFunc("EXP", nil, math.Exp)),
+		"ln":       scalarFunc("LN", 1, 1, logFunc("LN", math.Log)),
+		"log":      scalarFunc("LOG", 1, 2, logFunc("LOG", math.Log10)),
+		"greatest": scalarFunc("GREATEST", 1, -1, extremumFunc(true)),
+		"least":    scalarFunc("LEAST", 1, -1, extremumFunc(false)),
+
+		"regexp_replace": regexpFunc("REGEXP_REPLACE", 3, 3, regexpReplace),
+		"regexp_extract": regexpFunc("REGEXP_EXTRACT", 2, 3, regexpExtract),
+
+		"now": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, stringutil.Errorf("NOW() takes no arguments")
+			}
+			return new(NowFunc), nil
+		},
+		"uuid": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, stringutil.Errorf("UUID() takes no arguments")
+			}
+			return new(UUIDFunc), nil
+		},
+		"date_trunc": scalarFunc("DATE_TRUNC", 2, 2, dateTrunc),
+
+		"array_length":   scalarFunc("ARRAY_LENGTH", 1, 1, arrayLength),
+		"array_contains": scalarFunc("ARRAY_CONTAINS", 2, 2, arrayContains),
+		"array_append":   scalarFunc("ARRAY_APPEND", 2, 2, arrayAppend),
+		"array_remove":   scalarFunc("ARRAY_REMOVE", 2, 2, arrayRemove),
+		"array_slice":    scalarFunc("ARRAY_SLICE", 2, 3, arraySlice),
+		"array_concat":   scalarFunc("ARRAY_CONCAT", 2, -1, arrayConcat),
+		"array_sort":     scalarFunc("ARRAY_SORT", 1, 1, arraySort),
+		"array_distinct": scalarFunc("ARRAY_DISTINCT", 1, 1, arrayDistinct),
+
+		"document_keys":   scalarFunc("DOCUMENT_KEYS", 1, 1, documentKeys),
+		"document_values": scalarFunc("DOCUMENT_VALUES", 1, 1, documentValues),
+		"document_merge":  scalarFunc("DOCUMENT_MERGE", 2, -1, documentMerge),
+		"json_patch":      scalarFunc("JSON_PATCH", 2, 2, jsonPatch),
+
+		"row_number": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, stringutil.Errorf("ROW_NUMBER() takes no arguments")
+			}
+			return new(RowNumberFunc), nil
+		},
+		"rank": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, stringutil.Errorf("RANK() takes no arguments")
+			}
+			return new(RankFunc), nil
+		},
+		"dense_rank": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, stringutil.Errorf("DENSE_RANK() takes no arguments")
+			}
+			return new(DenseRankFunc), nil
+		},
+		"lag": func(args ...Expr) (Expr, error) {
+			if len(args) < 1 || len(args) > 3 {
+				return nil, stringutil.Errorf("LAG() takes 1 to 3 arguments")
+			}
+			var f LagFunc
+			f.Expr, f.Offset, f.Default = offsetArgs(args)
+			return &f, nil
+		},
+		"lead": func(args ...Expr) (Expr, error) {
+			if len(args) < 1 || len(args) > 3 {
+				return nil, stringutil.Errorf("LEAD() takes 1 to 3 arguments")
+			}
+			var f LeadFunc
+			f.Expr, f.Offset, f.Default = offsetArgs(args)
+			return &f, nil
+		},
+		"first_value": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, stringutil.Errorf("FIRST_VALUE() takes 1 argument")
+			}
+			return &FirstValueFunc{Expr: args[0]}, nil
+		},
 	}
 }
 
@@ -174,6 +296,39 @@ func (c CastFunc) String() string {
 	return stringutil.Sprintf("CAST(%v AS %v)", c.Expr, c.CastAs)
 }
 
+// TypeOfFunc is the TYPEOF function.
+// It returns the name of the type of the value of its argument.
+type TypeOfFunc struct {
+	Expr Expr
+}
+
+// Eval returns the name of the type of the value of the argument.
+func (t *TypeOfFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := t.Expr.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewTextValue(v.Type.String()), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (t *TypeOfFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*TypeOfFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(t.Expr, o.Expr)
+}
+
+func (t *TypeOfFunc) Params() []Expr { return []Expr{t.Expr} }
+
+func (t *TypeOfFunc) String() string {
+	return stringutil.Sprintf("TYPEOF(%v)", t.Expr)
+}
+
 var _ AggregatorBuilder = (*CountFunc)(nil)
 
 // CountFunc is the COUNT aggregator function. It counts the number of documents
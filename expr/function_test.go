@@ -24,3 +24,28 @@ func TestPkExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestTypeOfExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"typeof(a)", document.NewTextValue("integer"), false},
+		{"typeof(1.5)", document.NewTextValue("double"), false},
+		{"typeof('foo')", document.NewTextValue("text"), false},
+		{"typeof(true)", document.NewTextValue("bool"), false},
+		{"typeof(NULL)", document.NewTextValue("null"), false},
+		{"typeof(notFound)", document.NewTextValue("null"), false},
+		{"typeof(b)", document.NewTextValue("document"), false},
+		{"typeof(c)", document.NewTextValue("array"), false},
+		{"typeof(CAST('YQ==' AS BLOB))", document.NewTextValue("blob"), false},
+		{"typeof(a + 'foo')", document.NewTextValue("null"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
@@ -20,18 +20,23 @@ func Like(a, b Expr) Expr {
 	return &LikeOperator{&simpleOperator{a, b, scanner.LIKE}}
 }
 
+// Eval returns true if a matches the pattern b.
+// If a or b is a path containing the [*] wildcard, it returns true
+// if any of the matching values matches.
 func (op *LikeOperator) Eval(env *Environment) (document.Value, error) {
-	return op.simpleOperator.eval(env, func(a, b document.Value) (document.Value, error) {
-		if a.Type != document.TextValue || b.Type != document.TextValue {
-			return nullLitteral, nil
-		}
+	return op.simpleOperator.evalAny(env, true, likeValues)
+}
 
-		if like(b.V.(string), a.V.(string)) {
-			return trueLitteral, nil
-		}
+func likeValues(a, b document.Value) (document.Value, error) {
+	if a.Type != document.TextValue || b.Type != document.TextValue {
+		return nullLitteral, nil
+	}
 
-		return falseLitteral, nil
-	})
+	if like(b.V.(string), a.V.(string)) {
+		return trueLitteral, nil
+	}
+
+	return falseLitteral, nil
 }
 
 type NotLikeOperator struct {
@@ -43,8 +48,14 @@ func NotLike(a, b Expr) Expr {
 	return &NotLikeOperator{LikeOperator{&simpleOperator{a, b, scanner.LIKE}}}
 }
 
+// Eval returns true if a doesn't match the pattern b.
+// If a or b is a path containing the [*] wildcard, it returns true
+// if any of the matching values doesn't match.
 func (op *NotLikeOperator) Eval(env *Environment) (document.Value, error) {
-	return invertBoolResult(op.LikeOperator.Eval)(env)
+	return op.simpleOperator.evalAny(env, true, func(a, b document.Value) (document.Value, error) {
+		v, err := likeValues(a, b)
+		return invertBool(v), err
+	})
 }
 
 func (op *NotLikeOperator) String() string {
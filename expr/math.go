@@ -0,0 +1,322 @@
+package expr
+
+import (
+	"math"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// Math functions follow the same conversion rules as arithmetic operators:
+// if all of their arguments are integers the result is an integer, otherwise
+// the arguments are converted to doubles.
+// They return NULL if any of their arguments is NULL or not a number.
+// Unlike arithmetic operators, they return an error if the result overflows.
+
+// numberArgs returns whether all the arguments are integers.
+// It returns false if any of them is not a number.
+func numberArgs(args ...document.Value) (integers bool, ok bool) {
+	integers = true
+	for _, a := range args {
+		if !a.Type.IsNumber() {
+			return false, false
+		}
+		if a.Type == document.DoubleValue {
+			integers = false
+		}
+	}
+
+	return integers, true
+}
+
+// doubleArg returns the value of a number as a float64.
+func doubleArg(v document.Value) float64 {
+	if v.Type == document.IntegerValue {
+		return float64(v.V.(int64))
+	}
+
+	return v.V.(float64)
+}
+
+// doubleResult returns x as a double value.
+// It returns an error if x is infinite or not a number.
+func doubleResult(name string, x float64) (document.Value, error) {
+	if math.IsInf(x, 0) {
+		return nullLitteral, stringutil.Errorf("%s() value out of range", name)
+	}
+	if math.IsNaN(x) {
+		return nullLitteral, stringutil.Errorf("%s() result is not a number", name)
+	}
+
+	return document.NewDoubleValue(x), nil
+}
+
+// mathFunc returns a function that applies fi to its argument if it is an integer,
+// or fd otherwise. If fi is nil, integers are converted to doubles.
+// fi must return false if the result overflows.
+func mathFunc(name string, fi func(int64) (int64, bool), fd func(float64) float64) func(args []document.Value) (document.Value, error) {
+	return func(args []document.Value) (document.Value, error) {
+		integers, ok := numberArgs(args...)
+		if !ok {
+			return nullLitteral, nil
+		}
+
+		if integers && fi != nil {
+			x, ok := fi(args[0].V.(int64))
+			if !ok {
+				return nullLitteral, stringutil.Errorf("%s() integer overflow", name)
+			}
+			return document.NewIntegerValue(x), nil
+		}
+
+		return doubleResult(name, fd(doubleArg(args[0])))
+	}
+}
+
+func absInteger(x int64) (int64, bool) {
+	if x == math.MinInt64 {
+		return 0, false
+	}
+	if x < 0 {
+		return -x, true
+	}
+
+	return x, true
+}
+
+func signInteger(x int64) (int64, bool) {
+	switch {
+	case x > 0:
+		return 1, true
+	case x < 0:
+		return -1, true
+	}
+
+	return 0, true
+}
+
+func signDouble(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+
+	return 0
+}
+
+// identity is used by functions that return integers unchanged.
+func identity(x int64) (int64, bool) { return x, true }
+
+// round rounds a number half away from zero to the given number of decimal places,
+// which defaults to 0. A negative number of decimal places rounds to the left
+// of the decimal point.
+func round(args []document.Value) (document.Value, error) {
+	if _, ok := numberArgs(args...); !ok {
+		return nullLitteral, nil
+	}
+
+	var n int64
+	if len(args) > 1 {
+		n, _ = integerArg(args[1])
+	}
+
+	if args[0].Type == document.DoubleValue {
+		x := args[0].V.(float64)
+		// beyond that, doubles don't have enough precision to be rounded
+		if n > 308 {
+			return args[0], nil
+		}
+		if n < -308 {
+			return document.NewDoubleValue(0), nil
+		}
+
+		p := math.Pow10(int(n))
+		r := math.Round(x*p) / p
+		if math.IsInf(r, 0) || math.IsNaN(r) {
+			return args[0], nil
+		}
+
+		return doubleResult("ROUND", r)
+	}
+
+	x := args[0].V.(int64)
+	if n >= 0 {
+		return args[0], nil
+	}
+	if n < -18 {
+		return document.NewIntegerValue(0), nil
+	}
+
+	p := int64(math.Pow10(int(-n)))
+	r := x % p
+	x -= r
+	switch {
+	case r >= p-p/2:
+		x, ok := addIntegers(x, p)
+		if !ok {
+			return nullLitteral, stringutil.Errorf("ROUND() integer overflow")
+		}
+		return document.NewIntegerValue(x), nil
+	case r <= -(p - p/2):
+		x, ok := addIntegers(x, -p)
+		if !ok {
+			return nullLitteral, stringutil.Errorf("ROUND() integer overflow")
+		}
+		return document.NewIntegerValue(x), nil
+	}
+
+	return document.NewIntegerValue(x), nil
+}
+
+// addIntegers returns a + b. It returns false if the result overflows.
+func addIntegers(a, b int64) (int64, bool) {
+	r := a + b
+	if (b > 0 && r < a) || (b < 0 && r > a) {
+		return 0, false
+	}
+
+	return r, true
+}
+
+// mulIntegers returns a * b. It returns false if the result overflows.
+func mulIntegers(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	r := a * b
+	if r/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+
+	return r, true
+}
+
+// pow raises a number to the given power. The result is an integer
+// if both arguments are integers and the exponent is not negative.
+func pow(args []document.Value) (document.Value, error) {
+	integers, ok := numberArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	if !integers || args[1].V.(int64) < 0 {
+		return doubleResult("POW", math.Pow(doubleArg(args[0]), doubleArg(args[1])))
+	}
+
+	x, n := args[0].V.(int64), args[1].V.(int64)
+	switch x {
+	case 0, 1:
+		if n == 0 {
+			return document.NewIntegerValue(1), nil
+		}
+		return args[0], nil
+	case -1:
+		if n%2 == 0 {
+			return document.NewIntegerValue(1), nil
+		}
+		return args[0], nil
+	}
+
+	// any other base overflows after at most 63 multiplications
+	r := int64(1)
+	for ; n > 0; n-- {
+		r, ok = mulIntegers(r, x)
+		if !ok {
+			return nullLitteral, stringutil.Errorf("POW() integer overflow")
+		}
+	}
+
+	return document.NewIntegerValue(r), nil
+}
+
+// sqrt returns the square root of a positive number.
+func sqrt(args []document.Value) (document.Value, error) {
+	if _, ok := numberArgs(args...); !ok {
+		return nullLitteral, nil
+	}
+
+	x := doubleArg(args[0])
+	if x < 0 {
+		return nullLitteral, stringutil.Errorf("SQRT() cannot take the square root of a negative number")
+	}
+
+	return doubleResult("SQRT", math.Sqrt(x))
+}
+
+// logFunc returns a function that computes a logarithm with fn.
+// If the function receives two arguments, the first one is the base
+// and the second one the number. Both must be positive.
+func logFunc(name string, fn func(float64) float64) func(args []document.Value) (document.Value, error) {
+	return func(args []document.Value) (document.Value, error) {
+		if _, ok := numberArgs(args...); !ok {
+			return nullLitteral, nil
+		}
+
+		for _, a := range args {
+			if doubleArg(a) <= 0 {
+				return nullLitteral, stringutil.Errorf("%s() cannot take the logarithm of a negative number or zero", name)
+			}
+		}
+
+		if len(args) == 1 {
+			return doubleResult(name, fn(doubleArg(args[0])))
+		}
+
+		base := fn(doubleArg(args[0]))
+		if base == 0 {
+			return nullLitteral, stringutil.Errorf("%s() base cannot be 1", name)
+		}
+
+		return doubleResult(name, fn(doubleArg(args[1]))/base)
+	}
+}
+
+// extremumFunc returns a function that returns the greatest or the least of its arguments,
+// ignoring NULL values. Numbers are converted to doubles if any of them is a double,
+// other values must all be of the same type, otherwise the function returns NULL.
+func extremumFunc(greatest bool) func(args []document.Value) (document.Value, error) {
+	return func(args []document.Value) (document.Value, error) {
+		values := make([]document.Value, 0, len(args))
+		for _, a := range args {
+			if a.Type != document.NullValue {
+				values = append(values, a)
+			}
+		}
+		if len(values) == 0 {
+			return nullLitteral, nil
+		}
+
+		integers, numbers := numberArgs(values...)
+		if numbers && !integers {
+			for i, v := range values {
+				values[i] = document.NewDoubleValue(doubleArg(v))
+			}
+		}
+
+		res := values[0]
+		for _, v := range values[1:] {
+			if v.Type != res.Type {
+				return nullLitteral, nil
+			}
+
+			var ok bool
+			var err error
+			if greatest {
+				ok, err = v.IsGreaterThan(res)
+			} else {
+				ok, err = v.IsLesserThan(res)
+			}
+			if err != nil {
+				return nullLitteral, err
+			}
+			if ok {
+				res = v
+			}
+		}
+
+		return res, nil
+	}
+}
@@ -0,0 +1,84 @@
+package expr_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+)
+
+func TestMathFunctions(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"ABS(-10)", document.NewIntegerValue(10), false},
+		{"ABS(10)", document.NewIntegerValue(10), false},
+		{"ABS(-1.5)", document.NewDoubleValue(1.5), false},
+		{"ABS(-9223372036854775808)", nullLitteral, true},
+		{"ABS('foo')", nullLitteral, false},
+		{"ABS(NULL)", nullLitteral, false},
+		{"ABS(a)", document.NewIntegerValue(1), false},
+		{"ABS(b)", nullLitteral, false},
+		{"SIGN(-10)", document.NewIntegerValue(-1), false},
+		{"SIGN(0)", document.NewIntegerValue(0), false},
+		{"SIGN(2.5)", document.NewDoubleValue(1), false},
+		{"FLOOR(10)", document.NewIntegerValue(10), false},
+		{"FLOOR(-1.5)", document.NewDoubleValue(-2), false},
+		{"CEIL(1.2)", document.NewDoubleValue(2), false},
+		{"CEIL(true)", nullLitteral, false},
+		{"ROUND(1.5)", document.NewDoubleValue(2), false},
+		{"ROUND(-2.5)", document.NewDoubleValue(-3), false},
+		{"ROUND(3.14159, 2)", document.NewDoubleValue(3.14), false},
+		{"ROUND(1234.5, -2)", document.NewDoubleValue(1200), false},
+		{"ROUND(1.5, 400)", document.NewDoubleValue(1.5), false},
+		{"ROUND(10)", document.NewIntegerValue(10), false},
+		{"ROUND(1234, 2)", document.NewIntegerValue(1234), false},
+		{"ROUND(1250, -2)", document.NewIntegerValue(1300), false},
+		{"ROUND(-1250, -2)", document.NewIntegerValue(-1300), false},
+		{"ROUND(1249, -2)", document.NewIntegerValue(1200), false},
+		{"ROUND(1249, -20)", document.NewIntegerValue(0), false},
+		{"ROUND(9223372036854775807, -1)", nullLitteral, true},
+		{"ROUND(1.5, 'a')", nullLitteral, false},
+		{"POW(2, 10)", document.NewIntegerValue(1024), false},
+		{"POW(-3, 3)", document.NewIntegerValue(-27), false},
+		{"POW(5, 0)", document.NewIntegerValue(1), false},
+		{"POW(-1, 1000000001)", document.NewIntegerValue(-1), false},
+		{"POW(0, 0)", document.NewIntegerValue(1), false},
+		{"POW(2, 63)", nullLitteral, true},
+		{"POW(-2, 63)", document.NewIntegerValue(math.MinInt64), false},
+		{"POW(2, -1)", document.NewDoubleValue(0.5), false},
+		{"POW(2.0, 3)", document.NewDoubleValue(8), false},
+		{"POW(10.0, 400)", nullLitteral, true},
+		{"POW(-8.0, 0.5)", nullLitteral, true},
+		{"SQRT(16)", document.NewDoubleValue(4), false},
+		{"SQRT(2.25)", document.NewDoubleValue(1.5), false},
+		{"SQRT(-1)", nullLitteral, true},
+		{"EXP(0)", document.NewDoubleValue(1), false},
+		{"EXP(1000)", nullLitteral, true},
+		{"LN(1)", document.NewDoubleValue(0), false},
+		{"LN(0)", nullLitteral, true},
+		{"LOG(100)", document.NewDoubleValue(2), false},
+		{"LOG(2, 8)", document.NewDoubleValue(3), false},
+		{"LOG(1, 8)", nullLitteral, true},
+		{"LOG(-10)", nullLitteral, true},
+		{"LOG(NULL)", nullLitteral, false},
+		{"GREATEST(1, 3, 2)", document.NewIntegerValue(3), false},
+		{"GREATEST(1, 2.5, 2)", document.NewDoubleValue(2.5), false},
+		{"GREATEST(1, 3, 2.5)", document.NewDoubleValue(3), false},
+		{"GREATEST(1, NULL, 2)", document.NewIntegerValue(2), false},
+		{"GREATEST(NULL, NULL)", nullLitteral, false},
+		{"GREATEST('a', 'c', 'b')", document.NewTextValue("c"), false},
+		{"GREATEST(1, 'a')", nullLitteral, false},
+		{"LEAST(3, 1, 2)", document.NewIntegerValue(1), false},
+		{"LEAST(3, 1.5, 2)", document.NewDoubleValue(1.5), false},
+		{"LEAST(a, 1)", document.NewIntegerValue(1), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package expr
+
+import (
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// CoalesceFunc is the COALESCE function.
+// It returns the first of its arguments that is not NULL, or NULL if they are all NULL.
+// Arguments following the first non-NULL one are not evaluated.
+type CoalesceFunc struct {
+	Exprs []Expr
+}
+
+// Eval returns the value of the first argument that is not NULL.
+func (c *CoalesceFunc) Eval(env *Environment) (document.Value, error) {
+	return coalesce(env, c.Exprs...)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c *CoalesceFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*CoalesceFunc)
+	if !ok {
+		return false
+	}
+
+	return equalExprs(c.Exprs, o.Exprs)
+}
+
+func (c *CoalesceFunc) Params() []Expr { return c.Exprs }
+
+func (c *CoalesceFunc) String() string {
+	return stringutil.Sprintf("COALESCE(%s)", joinParams(c.Exprs))
+}
+
+// IfNullFunc is the IFNULL function.
+// It returns its first argument if it is not NULL, otherwise it returns the second one.
+type IfNullFunc struct {
+	Expr    Expr
+	Default Expr
+}
+
+// Eval returns the value of the first argument if it is not NULL, otherwise the value of the second one.
+func (f *IfNullFunc) Eval(env *Environment) (document.Value, error) {
+	return coalesce(env, f.Expr, f.Default)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *IfNullFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*IfNullFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(f.Expr, o.Expr) && Equal(f.Default, o.Default)
+}
+
+func (f *IfNullFunc) Params() []Expr { return []Expr{f.Expr, f.Default} }
+
+func (f *IfNullFunc) String() string {
+	return stringutil.Sprintf("IFNULL(%v, %v)", f.Expr, f.Default)
+}
+
+// coalesce evaluates the expressions in order and returns the first value that is not NULL.
+func coalesce(env *Environment, exprs ...Expr) (document.Value, error) {
+	for _, e := range exprs {
+		v, err := e.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		if v.Type != document.NullValue {
+			return v, nil
+		}
+	}
+
+	return nullLitteral, nil
+}
+
+// NullIfFunc is the NULLIF function.
+// It returns NULL if both of its arguments are equal, otherwise it returns the first one.
+type NullIfFunc struct {
+	Expr  Expr
+	Other Expr
+}
+
+// Eval returns NULL if both arguments are equal, otherwise the value of the first one.
+func (f *NullIfFunc) Eval(env *Environment) (document.Value, error) {
+	a, err := f.Expr.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if a.Type == document.NullValue {
+		return a, nil
+	}
+
+	b, err := f.Other.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if b.Type == document.NullValue {
+		return a, nil
+	}
+
+	ok, err := a.IsEqual(b)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if ok {
+		return nullLitteral, nil
+	}
+
+	return a, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *NullIfFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*NullIfFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(f.Expr, o.Expr) && Equal(f.Other, o.Other)
+}
+
+func (f *NullIfFunc) Params() []Expr { return []Expr{f.Expr, f.Other} }
+
+func (f *NullIfFunc) String() string {
+	return stringutil.Sprintf("NULLIF(%v, %v)", f.Expr, f.Other)
+}
+
+// equalExprs returns true if both lists contain the same expressions, in the same order.
+func equalExprs(a, b []Expr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !Equal(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,36 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+)
+
+func TestNullFunctions(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"COALESCE(a)", document.NewIntegerValue(1), false},
+		{"COALESCE(NULL, notFound, a, 2)", document.NewIntegerValue(1), false},
+		{"COALESCE(notFound, c[5], b.`foo bar`[0])", document.NewIntegerValue(1), false},
+		{"COALESCE(NULL, notFound)", nullLitteral, false},
+		{"IFNULL(a, 2)", document.NewIntegerValue(1), false},
+		{"IFNULL(notFound, 'foo')", document.NewTextValue("foo"), false},
+		{"IFNULL(NULL, NULL)", nullLitteral, false},
+		{"NULLIF(a, 1)", nullLitteral, false},
+		{"NULLIF(a, 1.0)", nullLitteral, false},
+		{"NULLIF(a, 2)", document.NewIntegerValue(1), false},
+		{"NULLIF(a, 'foo')", document.NewIntegerValue(1), false},
+		{"NULLIF(a, NULL)", document.NewIntegerValue(1), false},
+		{"NULLIF(notFound, 1)", nullLitteral, false},
+		{"NULLIF(b, b)", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
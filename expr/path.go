@@ -2,8 +2,11 @@ package expr
 
 import (
 	"errors"
+	"strconv"
+	"strings"
 
 	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/sql/scanner"
 )
 
 // A Path is an expression that extracts a value from a document at a given path.
@@ -29,6 +32,13 @@ func (p Path) Eval(env *Environment) (document.Value, error) {
 
 	v, err := dp.GetValueFromDocument(d)
 	if err == document.ErrFieldNotFound {
+		// if the path is not found in the current document,
+		// it might refer to the document of an enclosing query.
+		v, ok = env.Get(append(document.Path{document.PathFragment{FieldName: outerScopeVar}}, dp...))
+		if ok {
+			return v, nil
+		}
+
 		return nullLitteral, nil
 	}
 
@@ -50,8 +60,64 @@ func (p Path) IsEqual(other Expr) bool {
 	return document.Path(p).IsEqual(document.Path(o))
 }
 
+// String returns the path as it would be written in a query.
+// Field names that are not valid identifiers, or that are keywords,
+// are quoted so that the result can be parsed again.
 func (p Path) String() string {
-	return document.Path(p).String()
+	var b strings.Builder
+
+	for i := range p {
+		if p[i].FieldName != "" {
+			if i != 0 {
+				b.WriteRune('.')
+			}
+			b.WriteString(quoteIdent(p[i].FieldName))
+		} else if p[i].Wildcard {
+			b.WriteString("[*]")
+		} else {
+			b.WriteString("[" + strconv.Itoa(p[i].ArrayIndex) + "]")
+		}
+	}
+
+	return b.String()
+}
+
+// quoteIdent surrounds name with backquotes if it cannot be used
+// as a bare identifier.
+func quoteIdent(name string) string {
+	if isBareIdent(name) && scanner.Lookup(name) == scanner.IDENT {
+		return name
+	}
+
+	var b strings.Builder
+	b.WriteRune('`')
+	for _, c := range name {
+		switch c {
+		case '`', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	b.WriteRune('`')
+
+	return b.String()
+}
+
+func isBareIdent(name string) bool {
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return name != ""
 }
 
 // A Wildcard is an expression that iterates over all the fields of a document.
@@ -8,6 +8,7 @@ import (
 	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/sql/parser"
+	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,6 +35,10 @@ func TestPathExpr(t *testing.T) {
 		{"c[1].foo", document.NewTextValue("bar"), false},
 		{"c.foo", nullLitteral, false},
 		{"d", nullLitteral, false},
+		{"ARRAY_SORT(c[*]) = [1, [1, 2], {foo: 'bar'}]", document.NewBoolValue(true), false},
+		{"c[*].foo", testutil.MakeArrayValue(t, "bar"), false},
+		{"c[*][0]", testutil.MakeArrayValue(t, 1), false},
+		{"a[*]", nullLitteral, false},
 	}
 
 	d := document.NewFromJSON([]byte(`{
@@ -61,6 +66,8 @@ func TestPathIsEqual(t *testing.T) {
 		{`a`, `a`, true},
 		{`a[0].b`, `a[0].b`, true},
 		{`a[0].b`, `a[1].b`, false},
+		{`a[*].b`, `a[*].b`, true},
+		{`a[*].b`, `a[0].b`, false},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,133 @@
+package expr
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/sql/scanner"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// Regular expressions use the RE2 syntax of the Go regexp package.
+// They return NULL if any of their operands is NULL or not a text value.
+
+// A regexpCache keeps the last compiled pattern of an expression.
+// Since the pattern is usually constant, it is only compiled once per statement.
+type regexpCache struct {
+	mu      sync.Mutex
+	pattern string
+	re      *regexp.Regexp
+}
+
+// compile returns the compiled pattern, reusing the previous one
+// if the pattern didn't change.
+func (c *regexpCache) compile(pattern string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.re != nil && c.pattern == pattern {
+		return c.re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, stringutil.Errorf("invalid regular expression %q: %w", pattern, err)
+	}
+
+	c.pattern, c.re = pattern, re
+	return re, nil
+}
+
+type RegexpOperator struct {
+	*simpleOperator
+
+	cache *regexpCache
+}
+
+// Regexp creates an expression that evaluates to the result of a =~ b.
+func Regexp(a, b Expr) Expr {
+	return &RegexpOperator{&simpleOperator{a, b, scanner.EQREGEX}, new(regexpCache)}
+}
+
+// Eval returns true if the left operand matches the pattern of the right one.
+// If one of the operands is a path containing the [*] wildcard, it returns true
+// if any of the matching values matches.
+func (op *RegexpOperator) Eval(env *Environment) (document.Value, error) {
+	return op.simpleOperator.evalAny(env, true, func(a, b document.Value) (document.Value, error) {
+		if a.Type != document.TextValue || b.Type != document.TextValue {
+			return nullLitteral, nil
+		}
+
+		re, err := op.cache.compile(b.V.(string))
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		if re.MatchString(a.V.(string)) != (op.Tok == scanner.NEQREGEX) {
+			return trueLitteral, nil
+		}
+
+		return falseLitteral, nil
+	})
+}
+
+// NotRegexp creates an expression that evaluates to the result of a !~ b.
+func NotRegexp(a, b Expr) Expr {
+	return &RegexpOperator{&simpleOperator{a, b, scanner.NEQREGEX}, new(regexpCache)}
+}
+
+// regexpFunc returns a builder of scalar functions that use a regular expression
+// as their second argument. Each function gets its own cache of compiled patterns.
+func regexpFunc(name string, min, max int, fn func(re *regexp.Regexp, args []document.Value) (document.Value, error)) func(args ...Expr) (Expr, error) {
+	return func(args ...Expr) (Expr, error) {
+		cache := new(regexpCache)
+
+		return scalarFunc(name, min, max, func(args []document.Value) (document.Value, error) {
+			if _, ok := textArgs(args[:2]...); !ok {
+				return nullLitteral, nil
+			}
+
+			re, err := cache.compile(args[1].V.(string))
+			if err != nil {
+				return nullLitteral, err
+			}
+
+			return fn(re, args)
+		})(args...)
+	}
+}
+
+// regexpReplace replaces every match of the pattern by the replacement text,
+// in which $1 or ${name} are replaced by the text of the corresponding group.
+func regexpReplace(re *regexp.Regexp, args []document.Value) (document.Value, error) {
+	s, ok := textArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	return document.NewTextValue(re.ReplaceAllString(s[0], s[2])), nil
+}
+
+// regexpExtract returns the text of the first match of the pattern or, if a group number
+// is provided, of that group. It returns NULL if the pattern doesn't match.
+func regexpExtract(re *regexp.Regexp, args []document.Value) (document.Value, error) {
+	var group int64
+	if len(args) > 2 {
+		var ok bool
+		group, ok = integerArg(args[2])
+		if !ok {
+			return nullLitteral, nil
+		}
+		if group < 0 || group > int64(re.NumSubexp()) {
+			return nullLitteral, stringutil.Errorf("REGEXP_EXTRACT() invalid group %d", int(group))
+		}
+	}
+
+	m := re.FindStringSubmatchIndex(args[0].V.(string))
+	if m == nil || m[2*group] < 0 {
+		return nullLitteral, nil
+	}
+
+	return document.NewTextValue(args[0].V.(string)[m[2*group]:m[2*group+1]]), nil
+}
@@ -0,0 +1,44 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+)
+
+func TestRegexpExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"'foobar' =~ '^foo'", document.NewBoolValue(true), false},
+		{"'foobar' =~ 'baz'", document.NewBoolValue(false), false},
+		{"'FooBar' =~ '(?i)^foob'", document.NewBoolValue(true), false},
+		{"'héllo' =~ '^h.llo$'", document.NewBoolValue(true), false},
+		{"'foobar' !~ '^foo'", document.NewBoolValue(false), false},
+		{"'foobar' !~ 'baz'", document.NewBoolValue(true), false},
+		{"'foobar' =~ NULL", nullLitteral, false},
+		{"NULL !~ 'foo'", nullLitteral, false},
+		{"a =~ '1'", nullLitteral, false},
+		{"'foo' =~ '('", nullLitteral, true},
+		{"REGEXP_REPLACE('foo bar', 'o+', '0')", document.NewTextValue("f0 bar"), false},
+		{"REGEXP_REPLACE('john smith', '([a-z]+) ([a-z]+)', '$2 $1')", document.NewTextValue("smith john"), false},
+		{"REGEXP_REPLACE('foo', 'x', 'y')", document.NewTextValue("foo"), false},
+		{"REGEXP_REPLACE('foo', 'o', NULL)", nullLitteral, false},
+		{"REGEXP_REPLACE('foo', '[', 'y')", nullLitteral, true},
+		{"REGEXP_EXTRACT('abc123def456', '[0-9]+')", document.NewTextValue("123"), false},
+		{`REGEXP_EXTRACT('john@example.com', '@(.+)\\.(.+)$', 1)`, document.NewTextValue("example"), false},
+		{`REGEXP_EXTRACT('john@example.com', '@(.+)\\.(.+)$', 2)`, document.NewTextValue("com"), false},
+		{"REGEXP_EXTRACT('foo', '(x)?foo', 1)", nullLitteral, false},
+		{"REGEXP_EXTRACT('foo', '[0-9]+')", nullLitteral, false},
+		{"REGEXP_EXTRACT('foo', 'f(o)', 2)", nullLitteral, true},
+		{"REGEXP_EXTRACT(1, '1')", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package expr
+
+import (
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// A ScalarFunc is a function that computes a value using only the values of its arguments.
+// Since the result only depends on the arguments, it can be computed before running
+// the query if all the arguments are constant.
+type ScalarFunc struct {
+	Name string
+	Args []Expr
+
+	fn func(args []document.Value) (document.Value, error)
+}
+
+// NewScalarFunc returns a scalar function that evaluates its arguments and computes
+// its result with fn.
+func NewScalarFunc(name string, fn func(args []document.Value) (document.Value, error), args ...Expr) *ScalarFunc {
+	return &ScalarFunc{Name: name, Args: args, fn: fn}
+}
+
+// Eval evaluates the arguments and returns the result of the function.
+func (f *ScalarFunc) Eval(env *Environment) (document.Value, error) {
+	args := make([]document.Value, len(f.Args))
+	for i, a := range f.Args {
+		v, err := a.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+		args[i] = v
+	}
+
+	return f.fn(args)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *ScalarFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ScalarFunc)
+	if !ok {
+		return false
+	}
+
+	return f.Name == o.Name && equalExprs(f.Args, o.Args)
+}
+
+func (f *ScalarFunc) Params() []Expr { return f.Args }
+
+func (f *ScalarFunc) String() string {
+	return stringutil.Sprintf("%s(%s)", f.Name, joinParams(f.Args))
+}
+
+// scalarFunc returns a builder of scalar functions that checks the number of arguments.
+// A negative max means the function is variadic.
+func scalarFunc(name string, min, max int, fn func(args []document.Value) (document.Value, error)) func(args ...Expr) (Expr, error) {
+	return func(args ...Expr) (Expr, error) {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			return nil, stringutil.Errorf("%s() takes %s", name, argumentCount(min, max))
+		}
+
+		return NewScalarFunc(name, fn, args...), nil
+	}
+}
+
+// argumentCount describes the number of arguments accepted by a function.
+func argumentCount(min, max int) string {
+	var s string
+	switch {
+	case max < 0:
+		s = stringutil.Sprintf("at least %d", min)
+	case min == max:
+		s = stringutil.Sprintf("%d", min)
+	case min+1 == max:
+		s = stringutil.Sprintf("%d or %d", min, max)
+	default:
+		s = stringutil.Sprintf("%d to %d", min, max)
+	}
+
+	if min == 1 && (max == 1 || max < 0) {
+		return s + " argument"
+	}
+
+	return s + " arguments"
+}
@@ -0,0 +1,259 @@
+package expr
+
+import (
+	"errors"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// outerScopeVar is the name of the variable used to expose the document
+// of an enclosing query to a correlated subquery.
+const outerScopeVar = "_outer"
+
+// errStopSubquery is used to stop iterating over the documents of a subquery
+// once the result is known.
+var errStopSubquery = errors.New("stop subquery")
+
+// A SubqueryStream is a stream of documents that can be evaluated by a subquery.
+type SubqueryStream interface {
+	Iterate(in *Environment, fn func(out *Environment) error) error
+	String() string
+}
+
+// A Subquery is a SELECT statement used as an expression.
+// It evaluates to an array containing the only value of each document
+// returned by the statement, which is what the IN operator expects.
+// Other expressions use it through a ScalarSubquery.
+type Subquery struct {
+	Stream SubqueryStream
+
+	// Scope is the name under which the document of the enclosing query
+	// is made available to the subquery. If empty, each field of that document
+	// is made available instead, which is what is expected when the enclosing
+	// query uses table aliases.
+	Scope string
+
+	// Correlated indicates that the subquery refers to the document of the enclosing query
+	// by name and thus must be evaluated for each document.
+	// Subqueries that are not marked as correlated may still refer to that document
+	// using unqualified paths, see EvalUncorrelated.
+	Correlated bool
+}
+
+// EvalUncorrelated evaluates e, which contains subqueries that are not marked as correlated,
+// without any enclosing document.
+// Since paths that are not qualified by a table name may still refer to the enclosing document,
+// it reports false if any path had to be looked up there, in which case the result
+// must be discarded.
+func EvalUncorrelated(e Expr, env *Environment) (document.Value, bool, error) {
+	var probe outerProbe
+
+	newEnv := Environment{Outer: env}
+	newEnv.Set(outerScopeVar, document.NewDocumentValue(&probe))
+
+	v, err := e.Eval(&newEnv)
+	if err != nil || probe.used {
+		return nullLitteral, false, err
+	}
+
+	return v, true, nil
+}
+
+// outerProbe is an empty document that records whether it was read.
+type outerProbe struct {
+	used bool
+}
+
+func (p *outerProbe) GetByField(field string) (document.Value, error) {
+	p.used = true
+	return nullLitteral, document.ErrFieldNotFound
+}
+
+func (p *outerProbe) Iterate(fn func(field string, value document.Value) error) error {
+	p.used = true
+	return nil
+}
+
+// Eval runs the subquery and returns an array containing the value of each returned document.
+// Each document must contain exactly one field.
+func (s *Subquery) Eval(env *Environment) (document.Value, error) {
+	var vb document.ValueBuffer
+
+	err := s.Iterate(env, func(d document.Document) error {
+		v, err := onlyValue(d)
+		if err != nil {
+			return err
+		}
+
+		vb.Append(v)
+		return nil
+	})
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(&vb), nil
+}
+
+// Iterate runs the subquery and calls fn for each returned document.
+func (s *Subquery) Iterate(env *Environment, fn func(d document.Document) error) error {
+	if s.Stream == nil {
+		return nil
+	}
+
+	newEnv := Environment{Outer: env}
+	err := s.bindScope(&newEnv, env)
+	if err != nil {
+		return err
+	}
+
+	err = s.Stream.Iterate(&newEnv, func(out *Environment) error {
+		// if there is no doc in this specific environment,
+		// the last operator is not outputting anything.
+		if out.Doc == nil {
+			return nil
+		}
+
+		return fn(out.Doc)
+	})
+	if err == errStopSubquery {
+		err = nil
+	}
+	return err
+}
+
+// bindScope makes the document of the enclosing query available to the subquery.
+func (s *Subquery) bindScope(newEnv, env *Environment) error {
+	d, ok := env.GetDocument()
+	if !ok {
+		return nil
+	}
+
+	// the fields of the document are always available, which allows
+	// paths to omit the name of the table of the enclosing query.
+	var fb document.FieldBuffer
+	err := fb.ScanDocument(d)
+	if err != nil {
+		return err
+	}
+	if s.Scope != "" {
+		err = fb.Set(document.NewPath(s.Scope), document.NewDocumentValue(d))
+		if err != nil {
+			return err
+		}
+	}
+
+	newEnv.Set(outerScopeVar, document.NewDocumentValue(&fb))
+	return nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *Subquery) IsEqual(other Expr) bool {
+	o, ok := other.(*Subquery)
+	if !ok {
+		return false
+	}
+
+	return s == o
+}
+
+func (s *Subquery) String() string {
+	return stringutil.Sprintf("(%s)", s.Stream)
+}
+
+// onlyValue returns a copy of the only value of the given document.
+func onlyValue(d document.Document) (document.Value, error) {
+	var fb document.FieldBuffer
+
+	err := fb.Copy(d)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if fb.Len() != 1 {
+		return nullLitteral, stringutil.Errorf("subquery must return exactly one field, got %d", fb.Len())
+	}
+
+	return fb.GetByField(fb.Fields()[0])
+}
+
+// Exists is an expression that returns true if a subquery returns at least one document.
+type Exists struct {
+	Subquery *Subquery
+}
+
+// Eval runs the subquery until it returns a document.
+func (e Exists) Eval(env *Environment) (document.Value, error) {
+	var found bool
+
+	err := e.Subquery.Iterate(env, func(d document.Document) error {
+		found = true
+		return errStopSubquery
+	})
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewBoolValue(found), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (e Exists) IsEqual(other Expr) bool {
+	o, ok := other.(Exists)
+	if !ok {
+		return false
+	}
+
+	return e.Subquery.IsEqual(o.Subquery)
+}
+
+func (e Exists) String() string {
+	return stringutil.Sprintf("EXISTS %v", e.Subquery)
+}
+
+// A ScalarSubquery is a subquery that evaluates to a single value.
+type ScalarSubquery struct {
+	Subquery *Subquery
+}
+
+// Eval runs the subquery and returns the only value of the first returned document,
+// or NULL if the subquery doesn't return any document.
+// It returns an error if the subquery returns more than one document.
+func (s ScalarSubquery) Eval(env *Environment) (document.Value, error) {
+	var v document.Value
+	var found bool
+
+	err := s.Subquery.Iterate(env, func(d document.Document) error {
+		if found {
+			return errors.New("subquery returned more than one document")
+		}
+
+		var err error
+		v, err = onlyValue(d)
+		found = true
+		return err
+	})
+	if err != nil || !found {
+		return nullLitteral, err
+	}
+
+	return v, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s ScalarSubquery) IsEqual(other Expr) bool {
+	o, ok := other.(ScalarSubquery)
+	if !ok {
+		return false
+	}
+
+	return s.Subquery.IsEqual(o.Subquery)
+}
+
+func (s ScalarSubquery) String() string {
+	return s.Subquery.String()
+}
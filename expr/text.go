@@ -0,0 +1,329 @@
+package expr
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// Text functions operate on unicode characters rather than bytes.
+// They return NULL if any of their arguments is NULL or of an unexpected type.
+
+// textArgs returns the values of args as strings.
+// It returns false if any of them is not a text value.
+func textArgs(args ...document.Value) ([]string, bool) {
+	s := make([]string, len(args))
+	for i, a := range args {
+		if a.Type != document.TextValue {
+			return nil, false
+		}
+		s[i] = a.V.(string)
+	}
+
+	return s, true
+}
+
+// integerArg returns the value of v as an integer.
+// It returns false if v is not a number.
+func integerArg(v document.Value) (int64, bool) {
+	if !v.Type.IsNumber() {
+		return 0, false
+	}
+
+	v, err := v.CastAsInteger()
+	if err != nil {
+		return 0, false
+	}
+
+	return v.V.(int64), true
+}
+
+// textFunc returns a function that applies fn to its text argument.
+func textFunc(fn func(string) string) func(args []document.Value) (document.Value, error) {
+	return func(args []document.Value) (document.Value, error) {
+		s, ok := textArgs(args...)
+		if !ok {
+			return nullLitteral, nil
+		}
+
+		return document.NewTextValue(fn(s[0])), nil
+	}
+}
+
+func trimLeftSpace(s string) string  { return strings.TrimLeftFunc(s, unicode.IsSpace) }
+func trimRightSpace(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) }
+
+// trimFunc returns a function that removes whitespace from its text argument using spaces
+// or, if a second argument is provided, any of the characters it contains, using cutset.
+func trimFunc(spaces func(string) string, cutset func(s, cutset string) string) func(args []document.Value) (document.Value, error) {
+	return func(args []document.Value) (document.Value, error) {
+		s, ok := textArgs(args...)
+		if !ok {
+			return nullLitteral, nil
+		}
+
+		if len(s) == 1 {
+			return document.NewTextValue(spaces(s[0])), nil
+		}
+
+		return document.NewTextValue(cutset(s[0], s[1])), nil
+	}
+}
+
+// substr returns the substring starting at the given position, counting characters from 1.
+// A negative position counts from the end of the string. If the length is omitted,
+// the substring extends to the end of the string.
+func substr(args []document.Value) (document.Value, error) {
+	s, ok := textArgs(args[0])
+	if !ok {
+		return nullLitteral, nil
+	}
+	runes := []rune(s[0])
+
+	start, ok := integerArg(args[1])
+	if !ok {
+		return nullLitteral, nil
+	}
+	switch {
+	case start > 0:
+		start--
+	case start < 0:
+		start += int64(len(runes))
+	}
+
+	end := int64(len(runes))
+	if len(args) > 2 {
+		n, ok := integerArg(args[2])
+		if !ok {
+			return nullLitteral, nil
+		}
+		if n < 0 {
+			return nullLitteral, stringutil.Errorf("SUBSTR() length cannot be negative, got %d", n)
+		}
+		// clamp n so that start + n cannot overflow
+		if start >= 0 && n > int64(len(runes))-start {
+			n = int64(len(runes)) - start
+		}
+		end = start + n
+	}
+
+	start = clamp(start, 0, int64(len(runes)))
+	end = clamp(end, start, int64(len(runes)))
+
+	return document.NewTextValue(string(runes[start:end])), nil
+}
+
+func clamp(n, min, max int64) int64 {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+
+	return n
+}
+
+// length returns the number of characters of a text value or the number of bytes of a blob.
+func length(args []document.Value) (document.Value, error) {
+	switch args[0].Type {
+	case document.TextValue:
+		return document.NewIntegerValue(int64(utf8.RuneCountInString(args[0].V.(string)))), nil
+	case document.BlobValue:
+		return document.NewIntegerValue(int64(len(args[0].V.([]byte)))), nil
+	}
+
+	return nullLitteral, nil
+}
+
+// replace replaces every occurrence of a substring by another one.
+func replace(args []document.Value) (document.Value, error) {
+	s, ok := textArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	if s[1] == "" {
+		return args[0], nil
+	}
+
+	return document.NewTextValue(strings.ReplaceAll(s[0], s[1], s[2])), nil
+}
+
+// instr returns the position of the first occurrence of a substring, counting characters from 1,
+// or 0 if the substring is not found.
+func instr(args []document.Value) (document.Value, error) {
+	s, ok := textArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	i := strings.Index(s[0], s[1])
+	if i < 0 {
+		return document.NewIntegerValue(0), nil
+	}
+
+	return document.NewIntegerValue(int64(utf8.RuneCountInString(s[0][:i]) + 1)), nil
+}
+
+// split returns the array of substrings separated by a separator.
+// If the separator is empty, the string is split after each character.
+func split(args []document.Value) (document.Value, error) {
+	s, ok := textArgs(args...)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	vb := document.NewValueBuffer()
+	for _, part := range strings.Split(s[0], s[1]) {
+		vb.Append(document.NewTextValue(part))
+	}
+
+	return document.NewArrayValue(vb), nil
+}
+
+// maxPadLength is the maximum number of characters LPAD and RPAD can produce.
+const maxPadLength = 10 << 20
+
+// padFunc returns a function that pads its text argument to the given number of characters,
+// on the left or on the right, by repeating a fill string which defaults to a space.
+// Strings longer than the given number of characters are truncated.
+// The length must be between 0 and maxPadLength.
+func padFunc(name string, left bool) func(args []document.Value) (document.Value, error) {
+	return func(args []document.Value) (document.Value, error) {
+		s, ok := textArgs(args[0])
+		if !ok {
+			return nullLitteral, nil
+		}
+		runes := []rune(s[0])
+
+		n, ok := integerArg(args[1])
+		if !ok {
+			return nullLitteral, nil
+		}
+		if n < 0 {
+			return nullLitteral, stringutil.Errorf("%s() length cannot be negative, got %d", name, n)
+		}
+		if n > maxPadLength {
+			return nullLitteral, stringutil.Errorf("%s() length cannot exceed %d, got %d", name, maxPadLength, n)
+		}
+
+		fill := []rune(" ")
+		if len(args) > 2 {
+			f, ok := textArgs(args[2])
+			if !ok {
+				return nullLitteral, nil
+			}
+			fill = []rune(f[0])
+		}
+
+		if int64(len(runes)) >= n || len(fill) == 0 {
+			return document.NewTextValue(string(runes[:clamp(n, 0, int64(len(runes)))])), nil
+		}
+
+		pad := make([]rune, 0, n-int64(len(runes)))
+		for i := 0; int64(len(pad)) < n-int64(len(runes)); i++ {
+			pad = append(pad, fill[i%len(fill)])
+		}
+
+		if left {
+			return document.NewTextValue(string(pad) + s[0]), nil
+		}
+		return document.NewTextValue(s[0] + string(pad)), nil
+	}
+}
+
+// format returns the format string with each %s replaced by the text representation
+// of the following arguments, in order. NULL values are replaced by an empty string.
+// %% is replaced by a single %.
+func format(args []document.Value) (document.Value, error) {
+	f, ok := textArgs(args[0])
+	if !ok {
+		return nullLitteral, nil
+	}
+	msg := f[0]
+	args = args[1:]
+
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		if msg[i] != '%' {
+			sb.WriteByte(msg[i])
+			continue
+		}
+
+		if i+1 >= len(msg) {
+			return nullLitteral, stringutil.Errorf("FORMAT() unterminated format specifier")
+		}
+		i++
+
+		switch msg[i] {
+		case '%':
+			sb.WriteByte('%')
+		case 's':
+			if len(args) == 0 {
+				return nullLitteral, stringutil.Errorf("FORMAT() too few arguments")
+			}
+
+			if args[0].Type != document.NullValue {
+				v, err := args[0].CastAsText()
+				if err != nil {
+					return nullLitteral, err
+				}
+				sb.WriteString(v.V.(string))
+			}
+			args = args[1:]
+		default:
+			return nullLitteral, stringutil.Errorf("FORMAT() unrecognized format specifier %q", string(msg[i]))
+		}
+	}
+
+	return document.NewTextValue(sb.String()), nil
+}
+
+// UUIDFunc represents the UUID() function.
+// It returns a random (version 4) UUID as text.
+type UUIDFunc struct{}
+
+// Eval returns a new UUID.
+func (u *UUIDFunc) Eval(env *Environment) (document.Value, error) {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	// set version 4 and RFC 4122 variant
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+
+	return document.NewTextValue(string(buf[:])), nil
+}
+
+func (*UUIDFunc) Params() []Expr { return nil }
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (u *UUIDFunc) IsEqual(other Expr) bool {
+	_, ok := other.(*UUIDFunc)
+	return ok
+}
+
+func (u *UUIDFunc) String() string {
+	return "UUID()"
+}
@@ -0,0 +1,91 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextFunctions(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"LOWER('ÉCOLE Été')", document.NewTextValue("école été"), false},
+		{"LOWER(a)", nullLitteral, false},
+		{"LOWER(NULL)", nullLitteral, false},
+		{"UPPER('ça')", document.NewTextValue("ÇA"), false},
+		{"TRIM('  foo \t ')", document.NewTextValue("foo"), false},
+		{"TRIM(' foo　')", document.NewTextValue("foo"), false},
+		{"TRIM('xxfooyx', 'xy')", document.NewTextValue("foo"), false},
+		{"TRIM('ééfooé', 'é')", document.NewTextValue("foo"), false},
+		{"LTRIM('  foo  ')", document.NewTextValue("foo  "), false},
+		{"LTRIM('xxfoox', 'x')", document.NewTextValue("foox"), false},
+		{"RTRIM('  foo  ')", document.NewTextValue("  foo"), false},
+		{"RTRIM('xxfoox', 'x')", document.NewTextValue("xxfoo"), false},
+		{"TRIM('foo', NULL)", nullLitteral, false},
+		{"SUBSTR('héllo', 2)", document.NewTextValue("éllo"), false},
+		{"SUBSTR('héllo', 2, 3)", document.NewTextValue("éll"), false},
+		{"SUBSTR('héllo', -3)", document.NewTextValue("llo"), false},
+		{"SUBSTR('héllo', -3, 2)", document.NewTextValue("ll"), false},
+		{"SUBSTR('héllo', 10)", document.NewTextValue(""), false},
+		{"SUBSTR('héllo', 2, 0)", document.NewTextValue(""), false},
+		{"SUBSTR('abcdef', 2, 9223372036854775807)", document.NewTextValue("bcdef"), false},
+		{"SUBSTR('abcdef', 10, 9223372036854775807)", document.NewTextValue(""), false},
+		{"SUBSTR('héllo', 2.0, 10)", document.NewTextValue("éllo"), false},
+		{"SUBSTR('héllo', 2, -1)", nullLitteral, true},
+		{"SUBSTR('héllo', 'a')", nullLitteral, false},
+		{"LENGTH('héllo')", document.NewIntegerValue(5), false},
+		{"LENGTH('日本語')", document.NewIntegerValue(3), false},
+		{"LENGTH('')", document.NewIntegerValue(0), false},
+		{"LENGTH(CAST('YWJj' AS BLOB))", document.NewIntegerValue(3), false},
+		{"LENGTH(a)", nullLitteral, false},
+		{"REPLACE('foo bar foo', 'foo', 'baz')", document.NewTextValue("baz bar baz"), false},
+		{"REPLACE('éa', 'é', 'e')", document.NewTextValue("ea"), false},
+		{"REPLACE('foo', '', 'x')", document.NewTextValue("foo"), false},
+		{"REPLACE('foo', 'o', NULL)", nullLitteral, false},
+		{"INSTR('héllo', 'l')", document.NewIntegerValue(3), false},
+		{"INSTR('héllo', 'z')", document.NewIntegerValue(0), false},
+		{"INSTR('héllo', '')", document.NewIntegerValue(1), false},
+		{"SPLIT('a,b,,c', ',')", testutil.MakeArrayValue(t, "a", "b", "", "c"), false},
+		{"SPLIT('日本', '')", testutil.MakeArrayValue(t, "日", "本"), false},
+		{"SPLIT(NULL, ',')", nullLitteral, false},
+		{"LPAD('foo', 5)", document.NewTextValue("  foo"), false},
+		{"LPAD('foo', 6, 'éx')", document.NewTextValue("éxéfoo"), false},
+		{"LPAD('foobar', 3)", document.NewTextValue("foo"), false},
+		{"LPAD('foo', 5, '')", document.NewTextValue("foo"), false},
+		{"RPAD('foo', 5)", document.NewTextValue("foo  "), false},
+		{"RPAD('é', 3, '-')", document.NewTextValue("é--"), false},
+		{"RPAD('foo', -1)", nullLitteral, true},
+		{"LPAD('a', 1000000000, 'x')", nullLitteral, true},
+		{"FORMAT('%s is %s years old, 100%%', 'Bob', 10)", document.NewTextValue("Bob is 10 years old, 100%"), false},
+		{"FORMAT('[%s]', NULL)", document.NewTextValue("[]"), false},
+		{"FORMAT('%s', [1, 'a'])", document.NewTextValue(`[1, "a"]`), false},
+		{"FORMAT('no args')", document.NewTextValue("no args"), false},
+		{"FORMAT(NULL, 1)", nullLitteral, false},
+		{"FORMAT('%s %s', 1)", nullLitteral, true},
+		{"FORMAT('%d', 1)", nullLitteral, true},
+		{"FORMAT('50%')", nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
+
+func TestUUIDFunc(t *testing.T) {
+	v, err := new(expr.UUIDFunc).Eval(&expr.Environment{})
+	require.NoError(t, err)
+	require.Equal(t, document.TextValue, v.Type)
+	require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, v.V.(string))
+
+	other, err := new(expr.UUIDFunc).Eval(&expr.Environment{})
+	require.NoError(t, err)
+	require.NotEqual(t, v, other)
+}
@@ -0,0 +1,624 @@
+package expr
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// windowVar is the name of the variable holding the values computed
+// by window functions for the current document.
+const windowVar = "_window"
+
+// SetWindowValues stores the values computed by window functions for the document of env.
+// Each value must be named after the Over expression that computed it.
+func SetWindowValues(env *Environment, values document.Document) {
+	env.Set(windowVar, document.NewDocumentValue(values))
+}
+
+// A WindowFunction is a function that can only be evaluated over a window,
+// using the documents of the partition of the current document.
+type WindowFunction interface {
+	Function
+
+	// EvalWindow returns the value of the function for the document at position i of the partition.
+	EvalWindow(p *WindowPartition, i int) (document.Value, error)
+}
+
+// FrameBoundType is the type of a bound of a window frame.
+type FrameBoundType int
+
+// Types of frame bounds.
+const (
+	UnboundedPreceding FrameBoundType = iota + 1
+	OffsetPreceding
+	CurrentRow
+	OffsetFollowing
+	UnboundedFollowing
+)
+
+// A FrameBound is the start or the end of a window frame.
+type FrameBound struct {
+	Type FrameBoundType
+	// Offset is the number of documents preceding or following the current one.
+	// It is only used by OffsetPreceding and OffsetFollowing bounds.
+	Offset int64
+}
+
+func (b FrameBound) String() string {
+	switch b.Type {
+	case UnboundedPreceding:
+		return "UNBOUNDED PRECEDING"
+	case OffsetPreceding:
+		return stringutil.Sprintf("%d PRECEDING", b.Offset)
+	case OffsetFollowing:
+		return stringutil.Sprintf("%d FOLLOWING", b.Offset)
+	case UnboundedFollowing:
+		return "UNBOUNDED FOLLOWING"
+	}
+
+	return "CURRENT ROW"
+}
+
+// A WindowFrame is the set of documents of a partition used by aggregate
+// functions and FIRST_VALUE to compute the value of the current document.
+type WindowFrame struct {
+	// Rows indicates that the bounds are expressed in documents (ROWS),
+	// otherwise they are expressed in groups of peers (RANGE),
+	// which are documents with the same ORDER BY values.
+	Rows  bool
+	Start FrameBound
+	End   FrameBound
+}
+
+func (f *WindowFrame) String() string {
+	unit := "RANGE"
+	if f.Rows {
+		unit = "ROWS"
+	}
+
+	return stringutil.Sprintf("%s BETWEEN %s AND %s", unit, f.Start, f.End)
+}
+
+// A Window describes how the documents of a stream are partitioned and sorted
+// before evaluating window functions.
+type Window struct {
+	PartitionBy []Expr
+	OrderBy     []SortKey
+	// Frame of the window. If nil, the frame contains every document of the partition
+	// if OrderBy is empty, otherwise it contains the documents from the start of the partition
+	// to the last peer of the current document.
+	Frame *WindowFrame
+}
+
+func (w *Window) String() string {
+	var parts []string
+
+	if len(w.PartitionBy) > 0 {
+		var exprs []string
+		for _, e := range w.PartitionBy {
+			exprs = append(exprs, stringutil.Sprintf("%v", e))
+		}
+		parts = append(parts, "PARTITION BY "+strings.Join(exprs, ", "))
+	}
+
+	if len(w.OrderBy) > 0 {
+		var exprs []string
+		for _, k := range w.OrderBy {
+			exprs = append(exprs, k.String())
+		}
+		parts = append(parts, "ORDER BY "+strings.Join(exprs, ", "))
+	}
+
+	if w.Frame != nil {
+		parts = append(parts, w.Frame.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Over is a function evaluated over a window. The function is either
+// a WindowFunction or an aggregate function.
+// The values are computed by a stream operator, for every document,
+// and Over returns the one computed for the current document.
+type Over struct {
+	Func   Expr
+	Window *Window
+}
+
+// Eval returns the value computed for the current document.
+func (o *Over) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.Get(document.Path{
+		document.PathFragment{FieldName: windowVar},
+		document.PathFragment{FieldName: o.String()},
+	})
+	if !ok {
+		return nullLitteral, stringutil.Errorf("misuse of window function %s", o.Func)
+	}
+
+	return v, nil
+}
+
+// EvalWindow computes the value of the function for the document at position i of the partition.
+func (o *Over) EvalWindow(p *WindowPartition, i int) (document.Value, error) {
+	switch t := o.Func.(type) {
+	case WindowFunction:
+		return t.EvalWindow(p, i)
+	case AggregatorBuilder:
+		start, end := p.Frame(i)
+
+		agg := t.Aggregator()
+		for _, env := range p.Rows[start:end] {
+			err := agg.Aggregate(env)
+			if err != nil {
+				return nullLitteral, err
+			}
+		}
+
+		return agg.Eval(p.Rows[i])
+	}
+
+	return nullLitteral, stringutil.Errorf("%s is not a window function", o.Func)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (o *Over) IsEqual(other Expr) bool {
+	oo, ok := other.(*Over)
+	if !ok {
+		return false
+	}
+
+	return Equal(o.Func, oo.Func) && o.Window.String() == oo.Window.String()
+}
+
+// Params returns the function and the expressions of the window.
+func (o *Over) Params() []Expr {
+	params := []Expr{o.Func}
+	params = append(params, o.Window.PartitionBy...)
+	for _, ob := range o.Window.OrderBy {
+		params = append(params, ob.Expr)
+	}
+
+	return params
+}
+
+func (o *Over) String() string {
+	return stringutil.Sprintf("%v OVER (%s)", o.Func, o.Window)
+}
+
+// A WindowPartition is a set of documents sharing the same PARTITION BY values,
+// sorted using the ORDER BY clause of the window.
+type WindowPartition struct {
+	Window *Window
+	Rows   []*Environment
+
+	// peer group of each document
+	groups []int
+	// start and end position of each peer group
+	groupStart []int
+	groupEnd   []int
+}
+
+// NewWindowPartition sorts the given documents and returns a partition.
+// The order of documents with the same ORDER BY values is preserved.
+func NewWindowPartition(w *Window, rows []*Environment) (*WindowPartition, error) {
+	p := WindowPartition{
+		Window: w,
+		Rows:   rows,
+		groups: make([]int, len(rows)),
+	}
+
+	// encode the ORDER BY values of each document
+	keys := make([][][]byte, len(rows))
+	for i, env := range rows {
+		keys[i] = make([][]byte, len(w.OrderBy))
+		for j, o := range w.OrderBy {
+			v, err := o.Expr.Eval(env)
+			if err != nil && err != document.ErrFieldNotFound {
+				return nil, err
+			}
+			if err == document.ErrFieldNotFound {
+				v = nullLitteral
+			}
+
+			var buf bytes.Buffer
+			err = document.NewValueEncoder(&buf).Encode(v)
+			if err != nil {
+				return nil, err
+			}
+			keys[i][j] = buf.Bytes()
+		}
+	}
+
+	compare := func(a, b [][]byte) int {
+		for j := range a {
+			c := bytes.Compare(a[j], b[j])
+			if w.OrderBy[j].Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+
+		return 0
+	}
+
+	sort.Stable(&partitionSorter{rows: rows, keys: keys, compare: compare})
+
+	for i := range rows {
+		if i == 0 || compare(keys[i-1], keys[i]) != 0 {
+			p.groupStart = append(p.groupStart, i)
+			p.groupEnd = append(p.groupEnd, i)
+		}
+		g := len(p.groupStart) - 1
+		p.groups[i] = g
+		p.groupEnd[g] = i + 1
+	}
+
+	return &p, nil
+}
+
+// Frame returns the positions of the first document of the frame of the document
+// at position i, and of the document following the last one.
+// If the frame is empty, both positions are equal.
+func (p *WindowPartition) Frame(i int) (start, end int) {
+	f := p.Window.Frame
+	if f == nil {
+		if len(p.Window.OrderBy) == 0 {
+			return 0, len(p.Rows)
+		}
+
+		f = &WindowFrame{
+			Start: FrameBound{Type: UnboundedPreceding},
+			End:   FrameBound{Type: CurrentRow},
+		}
+	}
+
+	start = p.bound(f, f.Start, i, true)
+	end = p.bound(f, f.End, i, false)
+	if start > end {
+		start = end
+	}
+
+	return start, end
+}
+
+// bound returns the position of the given bound for the document at position i.
+func (p *WindowPartition) bound(f *WindowFrame, b FrameBound, i int, start bool) int {
+	var pos int
+
+	switch b.Type {
+	case UnboundedPreceding:
+		return 0
+	case UnboundedFollowing:
+		return len(p.Rows)
+	case CurrentRow:
+		if !f.Rows {
+			if start {
+				return p.groupStart[p.groups[i]]
+			}
+			return p.groupEnd[p.groups[i]]
+		}
+		pos = i
+	case OffsetPreceding:
+		pos = i - p.clampOffset(b.Offset)
+	case OffsetFollowing:
+		pos = i + p.clampOffset(b.Offset)
+	}
+
+	if !start {
+		pos++
+	}
+	if pos < 0 {
+		return 0
+	}
+	if pos > len(p.Rows) {
+		return len(p.Rows)
+	}
+
+	return pos
+}
+
+// clampOffset limits n to the size of the partition, so that
+// adding it to or subtracting it from a position cannot overflow.
+func (p *WindowPartition) clampOffset(n int64) int {
+	if n > int64(len(p.Rows)) {
+		return len(p.Rows)
+	}
+
+	return int(n)
+}
+
+// Rank returns the rank of the document at position i, with gaps.
+func (p *WindowPartition) Rank(i int) int {
+	return p.groupStart[p.groups[i]] + 1
+}
+
+// DenseRank returns the rank of the document at position i, without gaps.
+func (p *WindowPartition) DenseRank(i int) int {
+	return p.groups[i] + 1
+}
+
+type partitionSorter struct {
+	rows    []*Environment
+	keys    [][][]byte
+	compare func(a, b [][]byte) int
+}
+
+func (s *partitionSorter) Len() int           { return len(s.rows) }
+func (s *partitionSorter) Less(i, j int) bool { return s.compare(s.keys[i], s.keys[j]) < 0 }
+func (s *partitionSorter) Swap(i, j int) {
+	s.rows[i], s.rows[j] = s.rows[j], s.rows[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// RowNumberFunc is the ROW_NUMBER window function.
+// It returns the position of the current document in its partition, starting at 1.
+type RowNumberFunc struct{}
+
+// Eval returns an error, ROW_NUMBER can only be used with an OVER clause.
+func (*RowNumberFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, stringutil.Errorf("misuse of window function ROW_NUMBER()")
+}
+
+// EvalWindow returns the position of the document in the partition.
+func (*RowNumberFunc) EvalWindow(p *WindowPartition, i int) (document.Value, error) {
+	return document.NewIntegerValue(int64(i + 1)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (*RowNumberFunc) IsEqual(other Expr) bool {
+	_, ok := other.(*RowNumberFunc)
+	return ok
+}
+
+func (*RowNumberFunc) Params() []Expr { return nil }
+
+func (*RowNumberFunc) String() string { return "ROW_NUMBER()" }
+
+// RankFunc is the RANK window function.
+// It returns the rank of the current document in its partition, with gaps:
+// peers have the same rank and the next document's rank is its position.
+type RankFunc struct{}
+
+// Eval returns an error, RANK can only be used with an OVER clause.
+func (*RankFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, stringutil.Errorf("misuse of window function RANK()")
+}
+
+// EvalWindow returns the rank of the document in the partition.
+func (*RankFunc) EvalWindow(p *WindowPartition, i int) (document.Value, error) {
+	return document.NewIntegerValue(int64(p.Rank(i))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (*RankFunc) IsEqual(other Expr) bool {
+	_, ok := other.(*RankFunc)
+	return ok
+}
+
+func (*RankFunc) Params() []Expr { return nil }
+
+func (*RankFunc) String() string { return "RANK()" }
+
+// DenseRankFunc is the DENSE_RANK window function.
+// It returns the rank of the current document in its partition, without gaps.
+type DenseRankFunc struct{}
+
+// Eval returns an error, DENSE_RANK can only be used with an OVER clause.
+func (*DenseRankFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, stringutil.Errorf("misuse of window function DENSE_RANK()")
+}
+
+// EvalWindow returns the rank of the document in the partition.
+func (*DenseRankFunc) EvalWindow(p *WindowPartition, i int) (document.Value, error) {
+	return document.NewIntegerValue(int64(p.DenseRank(i))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (*DenseRankFunc) IsEqual(other Expr) bool {
+	_, ok := other.(*DenseRankFunc)
+	return ok
+}
+
+func (*DenseRankFunc) Params() []Expr { return nil }
+
+func (*DenseRankFunc) String() string { return "DENSE_RANK()" }
+
+// LagFunc is the LAG window function.
+// It evaluates an expression using the document located a number of documents
+// before the current one in the partition. If there is no such document,
+// it returns a default value, or NULL.
+type LagFunc struct {
+	Expr    Expr
+	Offset  Expr
+	Default Expr
+}
+
+// Eval returns an error, LAG can only be used with an OVER clause.
+func (l *LagFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, stringutil.Errorf("misuse of window function %s", l)
+}
+
+// EvalWindow evaluates the expression using the document preceding the document at position i.
+func (l *LagFunc) EvalWindow(p *WindowPartition, i int) (document.Value, error) {
+	return evalAtOffset(p, i, l.Expr, l.Offset, l.Default, -1)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (l *LagFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*LagFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(l.Expr, o.Expr) && Equal(l.Offset, o.Offset) && Equal(l.Default, o.Default)
+}
+
+func (l *LagFunc) Params() []Expr { return offsetParams(l.Expr, l.Offset, l.Default) }
+
+func (l *LagFunc) String() string {
+	return stringutil.Sprintf("LAG(%s)", joinParams(l.Params()))
+}
+
+// LeadFunc is the LEAD window function.
+// It evaluates an expression using the document located a number of documents
+// after the current one in the partition. If there is no such document,
+// it returns a default value, or NULL.
+type LeadFunc struct {
+	Expr    Expr
+	Offset  Expr
+	Default Expr
+}
+
+// Eval returns an error, LEAD can only be used with an OVER clause.
+func (l *LeadFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, stringutil.Errorf("misuse of window function %s", l)
+}
+
+// EvalWindow evaluates the expression using the document following the document at position i.
+func (l *LeadFunc) EvalWindow(p *WindowPartition, i int) (document.Value, error) {
+	return evalAtOffset(p, i, l.Expr, l.Offset, l.Default, 1)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (l *LeadFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*LeadFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(l.Expr, o.Expr) && Equal(l.Offset, o.Offset) && Equal(l.Default, o.Default)
+}
+
+func (l *LeadFunc) Params() []Expr { return offsetParams(l.Expr, l.Offset, l.Default) }
+
+func (l *LeadFunc) String() string {
+	return stringutil.Sprintf("LEAD(%s)", joinParams(l.Params()))
+}
+
+// evalAtOffset evaluates e using the document located offset documents away from
+// the document at position i, in the given direction.
+// The offset defaults to 1.
+func evalAtOffset(p *WindowPartition, i int, e, offset, def Expr, direction int) (document.Value, error) {
+	n := 1
+	if offset != nil {
+		v, err := offset.Eval(p.Rows[i])
+		if err != nil {
+			return nullLitteral, err
+		}
+		if v.Type != document.IntegerValue {
+			return nullLitteral, stringutil.Errorf("offset must be an integer, got %s", v.Type)
+		}
+		if v.V.(int64) < 0 {
+			return nullLitteral, stringutil.Errorf("offset must be positive, got %d", v.V.(int64))
+		}
+		n = p.clampOffset(v.V.(int64))
+	}
+
+	j := i + direction*n
+	if j < 0 || j >= len(p.Rows) {
+		if def == nil {
+			return nullLitteral, nil
+		}
+		return def.Eval(p.Rows[i])
+	}
+
+	v, err := e.Eval(p.Rows[j])
+	if err == document.ErrFieldNotFound {
+		return nullLitteral, nil
+	}
+	return v, err
+}
+
+// offsetArgs returns the expression, the offset and the default value
+// passed to LAG or LEAD. Missing arguments are nil.
+func offsetArgs(args []Expr) (e, offset, def Expr) {
+	e = args[0]
+	if len(args) > 1 {
+		offset = args[1]
+	}
+	if len(args) > 2 {
+		def = args[2]
+	}
+
+	return
+}
+
+func offsetParams(e, offset, def Expr) []Expr {
+	params := []Expr{e}
+	if offset != nil {
+		params = append(params, offset)
+	}
+	if def != nil {
+		params = append(params, def)
+	}
+
+	return params
+}
+
+func joinParams(params []Expr) string {
+	var sb strings.Builder
+
+	for i, p := range params {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(stringutil.Sprintf("%v", p))
+	}
+
+	return sb.String()
+}
+
+// FirstValueFunc is the FIRST_VALUE window function.
+// It evaluates an expression using the first document of the frame.
+type FirstValueFunc struct {
+	Expr Expr
+}
+
+// Eval returns an error, FIRST_VALUE can only be used with an OVER clause.
+func (f *FirstValueFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, stringutil.Errorf("misuse of window function %s", f)
+}
+
+// EvalWindow evaluates the expression using the first document of the frame
+// of the document at position i, or returns NULL if the frame is empty.
+func (f *FirstValueFunc) EvalWindow(p *WindowPartition, i int) (document.Value, error) {
+	start, end := p.Frame(i)
+	if start == end {
+		return nullLitteral, nil
+	}
+
+	v, err := f.Expr.Eval(p.Rows[start])
+	if err == document.ErrFieldNotFound {
+		return nullLitteral, nil
+	}
+	return v, err
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *FirstValueFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*FirstValueFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(f.Expr, o.Expr)
+}
+
+func (f *FirstValueFunc) Params() []Expr { return []Expr{f.Expr} }
+
+func (f *FirstValueFunc) String() string {
+	return stringutil.Sprintf("FIRST_VALUE(%v)", f.Expr)
+}
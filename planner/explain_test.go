@@ -22,14 +22,34 @@ func TestExplainStmt(t *testing.T) {
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 AND d > 20", false, `"seqScan(test) | filter(c > 10) | filter(d > 20) | project(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 OR d > 20", false, `"seqScan(test) | filter(c > 10 OR d > 20) | project(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c IN [1 + 1, 2 + 2]", false, `"seqScan(test) | filter(c IN [2, 4]) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE a = ANY([1 + 1, 3])", false, `"indexScan(\"idx_a\", 2, 3) | project(a + 1)"`},
+		{"EXPLAIN SELECT * FROM test WHERE k = ANY([1, 2, 1.0])", false, `"pkScan(\"test\", 1, 2)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE a > ANY([1 + 1, 3])", false, `"seqScan(test) | filter(a > ANY([2, 3])) | project(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10", false, `"indexScan(\"idx_a\", [10, -1, true]) | project(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE x = 10 AND y > 5", false, `"indexScan(\"idx_x_y\", [[10, 5], -1, true]) | project(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10 AND b > 20 AND c > 30", false, `"indexScan(\"idx_b\", [20, -1, true]) | filter(a > 10) | filter(c > 30) | project(a + 1)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d LIMIT 10 OFFSET 20", false, `"seqScan(test) | filter(c > 30) | project(a + 1) | sort(d) | skip(20) | take(10)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d DESC LIMIT 10 OFFSET 20", false, `"seqScan(test) | filter(c > 30) | project(a + 1) | sortReverse(d) | skip(20) | take(10)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d DESC, a + 1 LIMIT 10", false, `"seqScan(test) | filter(c > 30) | project(a + 1) | sort(d DESC, a + 1) | take(10)"`},
 		// {"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"indexScanReverse(\"idx_a\") | filter(c > 30) | project(a + 1) | skip(20) | take(10)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"seqScan(test) | filter(c > 30) | project(a + 1) | sortReverse(a) | skip(20) | take(10)"`},
 		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 GROUP BY a + 1 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"seqScan(test) | filter(c > 30) | groupBy(a + 1) | hashAggregate() | project(a + 1) | sortReverse(a) | skip(20) | take(10)"`},
+		{"EXPLAIN SELECT a, COUNT(*) FROM test WHERE c > 10 GROUP BY a HAVING a > 10 AND MAX(b) > 1", false, `"seqScan(test) | filter(c > 10) | groupBy(a) | hashAggregate(COUNT(*), MAX(b)) | filter(a > 10) | filter(MAX(b) > 1) | project(a, COUNT(*))"`},
+		{"EXPLAIN SELECT a, b.c, SUM(d) FROM test GROUP BY a, b.c", false, `"seqScan(test) | groupBy(a, b.c) | hashAggregate(SUM(d)) | project(a, b.c, SUM(d))"`},
+		{"EXPLAIN SELECT a FROM test WHERE a = CASE WHEN 1 > 2 THEN b ELSE 10 END", false, `"indexScan(\"idx_a\", 10) | project(a)"`},
+		{"EXPLAIN SELECT a FROM test WHERE a = LOWER('FOO') AND b = TRIM(c)", false, `"indexScan(\"idx_a\", \"foo\") | filter(b = TRIM(c)) | project(a)"`},
+		{"EXPLAIN SELECT * FROM test t1 JOIN test t2 ON t1.a = t2.c", false, `"seqScan(test) | alias(t1) | join(seqScan(test) AS t2, t1.a = t2.c)"`},
+		{"EXPLAIN SELECT * FROM test t1 JOIN test t2 ON t1.c = t2.a", false, `"seqScan(test) | alias(t1) | join(indexLookup(\"idx_a\", t1.c) AS t2, t1.c = t2.a)"`},
+		{"EXPLAIN SELECT * FROM test t1 LEFT JOIN test t2 ON t2.a = t1.c AND t2.b = t1.d", false, `"seqScan(test) | alias(t1) | leftJoin(indexLookup(\"idx_b\", t1.d) AS t2, t2.a = t1.c AND t2.b = t1.d)"`},
+		{"EXPLAIN SELECT * FROM test t1 JOIN test t2 ON t2.k = t1.a + 1 WHERE t1.a > 10", false, `"seqScan(test) | alias(t1) | join(pkLookup(\"test\", t1.a + 1) AS t2, t2.k = t1.a + 1) | filter(t1.a > 10)"`},
+		{"EXPLAIN SELECT * FROM test t, UNNEST(t.a) AS v WHERE v > 1 + 1", false, `"seqScan(test) | alias(t) | unnest(t.a AS v) | filter(v > 2)"`},
+		{"EXPLAIN SELECT * FROM test t1 JOIN test t2 ON t2.x = t1.a", false, `"seqScan(test) | alias(t1) | join(seqScan(test) AS t2, t2.x = t1.a)"`},
+		{"EXPLAIN SELECT * FROM test WHERE c NOT IN (SELECT a FROM test WHERE a > 10)", false, `"seqScan(test) | filter(c NOT IN [])"`},
+		{"EXPLAIN SELECT * FROM test WHERE NOT EXISTS (SELECT * FROM test t WHERE t.a = 1)", false, `"seqScan(test)"`},
+		{"EXPLAIN SELECT * FROM test WHERE EXISTS (SELECT * FROM test t WHERE t.a = test.c)", false, `"seqScan(test) | filter(EXISTS (seqScan(test) | alias(t) | filter(t.a = test.c)))"`},
+		{"EXPLAIN SELECT a FROM test WHERE a > 10 UNION SELECT c FROM test WHERE c > 1 + 1 ORDER BY a", false, `"union(indexScan(\"idx_a\", [10, -1, true]) | project(a), seqScan(test) | filter(c > 2) | project(c)) | sort(a)"`},
+		{"EXPLAIN WITH t AS (SELECT a FROM test WHERE a > 10) SELECT * FROM t", false, `"cte(t, indexScan(\"idx_a\", [10, -1, true]) | project(a))"`},
+		{"EXPLAIN WITH RECURSIVE t AS (SELECT k FROM test WHERE k = 1 UNION SELECT test.k FROM test JOIN t ON test.a = t.k) SELECT * FROM t", false, `"recursiveCte(t, pkScan(\"test\", 1) | project(k), seqScan(test) | alias(test) | join(workingTable(t) AS t, test.a = t.k) | project(test.k))"`},
 		{"EXPLAIN UPDATE test SET a = 10", false, `"seqScan(test) | set(a, 10) | tableReplace('test')"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE c > 10", false, `"seqScan(test) | filter(c > 10) | set(a, 10) | tableReplace('test')"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE a > 10", false, `"indexScan(\"idx_a\", [10, -1, true]) | set(a, 10) | tableReplace('test')"`},
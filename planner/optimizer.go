@@ -9,13 +9,21 @@ import (
 	"github.com/tie/genji-release-test/stringutil"
 )
 
-var optimizerRules = []func(s *stream.Stream, tx *database.Transaction, params []expr.Param) (*stream.Stream, error){
-	SplitANDConditionRule,
-	PrecalculateExprRule,
-	RemoveUnnecessaryFilterNodesRule,
-	RemoveUnnecessaryDistinctNodeRule,
-	RemoveUnnecessaryProjection,
-	UseIndexBasedOnFilterNodeRule,
+var optimizerRules []func(s *stream.Stream, tx *database.Transaction, params []expr.Param) (*stream.Stream, error)
+
+func init() {
+	// the rules are set during initialization because
+	// subqueries are optimized recursively by PrecalculateExprRule.
+	optimizerRules = append(optimizerRules,
+		OptimizeCompoundStreamsRule,
+		SplitANDConditionRule,
+		PrecalculateExprRule,
+		RemoveUnnecessaryFilterNodesRule,
+		RemoveUnnecessaryDistinctNodeRule,
+		RemoveUnnecessaryProjection,
+		UseIndexBasedOnFilterNodeRule,
+		UseIndexForJoinRule,
+	)
 }
 
 // Optimize takes a tree, applies a list of optimization rules
@@ -38,6 +46,48 @@ func Optimize(s *stream.Stream, tx *database.Transaction, params []expr.Param) (
 	return s, nil
 }
 
+// OptimizeCompoundStreamsRule optimizes separately each of the streams combined
+// by set operations (UNION, INTERSECT or EXCEPT) or read from common table expressions.
+func OptimizeCompoundStreamsRule(s *stream.Stream, tx *database.Transaction, params []expr.Param) (*stream.Stream, error) {
+	n := s.Op
+
+	var err error
+	for n != nil {
+		var streams []**stream.Stream
+
+		switch t := n.(type) {
+		case *stream.UnionOperator:
+			streams = append(streams, &t.Left, &t.Right)
+		case *stream.IntersectOperator:
+			streams = append(streams, &t.Left, &t.Right)
+		case *stream.ExceptOperator:
+			streams = append(streams, &t.Left, &t.Right)
+		case *stream.CTEOperator:
+			streams = append(streams, &t.Stream)
+		case *stream.RecursiveCTEOperator:
+			streams = append(streams, &t.Anchor, &t.Recursive)
+		case *stream.JoinOperator:
+			// the right side of a join is optimized by UseIndexForJoinRule,
+			// only the streams it reads from are optimized here.
+			_, err = OptimizeCompoundStreamsRule(t.Right, tx, params)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, st := range streams {
+			*st, err = Optimize(*st, tx, params)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		n = n.GetPrev()
+	}
+
+	return s, nil
+}
+
 // SplitANDConditionRule splits any filter node whose condition
 // is one or more AND operators into one or more filter nodes.
 // The condition won't be split if the expression tree contains an OR
@@ -103,24 +153,56 @@ func splitANDExpr(cond expr.Expr) (exprs []expr.Expr) {
 // Examples:
 //   3 + 4 --> 7
 //   3 + 1 > 10 - a --> 4 > 10 - a
-func PrecalculateExprRule(s *stream.Stream, _ *database.Transaction, params []expr.Param) (*stream.Stream, error) {
+//   a = LOWER('FOO') --> a = 'foo'
+// Subqueries are optimized and, if they don't refer to the enclosing query,
+// evaluated once and replaced by their result.
+//   a IN (SELECT b FROM foo) --> a IN [1, 2, 3]
+func PrecalculateExprRule(s *stream.Stream, tx *database.Transaction, params []expr.Param) (*stream.Stream, error) {
 	n := s.Op
 
 	var err error
 	for n != nil {
 		switch t := n.(type) {
 		case *stream.FilterOperator:
-			t.E, err = precalculateExpr(t.E, params)
+			t.E, err = precalculateExpr(t.E, tx, params)
 			if err != nil {
 				return nil, err
 			}
 		case *stream.ProjectOperator:
 			for i, e := range t.Exprs {
-				t.Exprs[i], err = precalculateExpr(e, params)
+				t.Exprs[i], err = precalculateExpr(e, tx, params)
 				if err != nil {
 					return nil, err
 				}
 			}
+		case *stream.JoinOperator:
+			if t.On != nil {
+				t.On, err = precalculateExpr(t.On, tx, params)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case *stream.UnnestOperator:
+			t.E, err = precalculateExpr(t.E, tx, params)
+			if err != nil {
+				return nil, err
+			}
+			if t.On != nil {
+				t.On, err = precalculateExpr(t.On, tx, params)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case *stream.SetOperator:
+			t.E, err = precalculateExpr(t.E, tx, params)
+			if err != nil {
+				return nil, err
+			}
+		case *stream.PatchOperator:
+			t.E, err = precalculateExpr(t.E, tx, params)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		n = n.GetPrev()
@@ -133,14 +215,34 @@ func PrecalculateExprRule(s *stream.Stream, _ *database.Transaction, params []ex
 // expression nodes when possible.
 // it returns a new expression with simplified nodes.
 // if no simplification is possible it returns the same expression.
-func precalculateExpr(e expr.Expr, params []expr.Param) (expr.Expr, error) {
+func precalculateExpr(e expr.Expr, tx *database.Transaction, params []expr.Param) (expr.Expr, error) {
 	switch t := e.(type) {
+	case *expr.Subquery:
+		return precalculateSubquery(t, t, tx, params)
+	case expr.Exists:
+		return precalculateSubquery(t, t.Subquery, tx, params)
+	case expr.ScalarSubquery:
+		return precalculateSubquery(t, t.Subquery, tx, params)
+	case *expr.NotOp:
+		v, err := precalculateExpr(t.LeftHand(), tx, params)
+		if err != nil {
+			return nil, err
+		}
+		t.SetLeftHandExpr(v)
+
+		if _, ok := v.(expr.LiteralValue); ok {
+			v, err := t.Eval(&expr.Environment{})
+			if err != nil {
+				return nil, err
+			}
+			return expr.LiteralValue(v), nil
+		}
 	case expr.LiteralExprList:
 		// we assume that the list of expressions contains only literals
 		// until proven wrong.
 		literalsOnly := true
 		for i, te := range t {
-			newExpr, err := precalculateExpr(te, params)
+			newExpr, err := precalculateExpr(te, tx, params)
 			if err != nil {
 				return nil, err
 			}
@@ -168,7 +270,7 @@ func precalculateExpr(e expr.Expr, params []expr.Param) (expr.Expr, error) {
 
 		var err error
 		for i, kv := range t.Pairs {
-			kv.V, err = precalculateExpr(kv.V, params)
+			kv.V, err = precalculateExpr(kv.V, tx, params)
 			if err != nil {
 				return nil, err
 			}
@@ -201,29 +303,65 @@ func precalculateExpr(e expr.Expr, params []expr.Param) (expr.Expr, error) {
 			return e, nil
 		}
 
-		lh, err := precalculateExpr(t.LeftHand(), params)
+		lh, err := precalculateExpr(t.LeftHand(), tx, params)
 		if err != nil {
 			return nil, err
 		}
-		rh, err := precalculateExpr(t.RightHand(), params)
+		rh, err := precalculateExpr(t.RightHand(), tx, params)
 		if err != nil {
 			return nil, err
 		}
 		t.SetLeftHandExpr(lh)
 		t.SetRightHandExpr(rh)
 
+		// the value compared by BETWEEN is not one of its operands
+		// and must be constant as well.
+		if b, ok := t.(*expr.BetweenOperator); ok {
+			b.X, err = precalculateExpr(b.X, tx, params)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := b.X.(expr.LiteralValue); !ok {
+				return e, nil
+			}
+		}
+
 		_, leftIsLit := lh.(expr.LiteralValue)
 		_, rightIsLit := rh.(expr.LiteralValue)
 		// if both operands are literals, we can precalculate them now
 		if leftIsLit && rightIsLit {
 			v, err := t.Eval(&expr.Environment{})
-			// any error encountered here is unexpected
 			if err != nil {
-				panic(err)
+				return nil, err
 			}
 			// we replace this expression with the result of its evaluation
 			return expr.LiteralValue(v), nil
 		}
+	case *expr.CaseExpr:
+		return precalculateCase(t, tx, params)
+	case *expr.ScalarFunc:
+		// we assume that the arguments are all literals
+		// until proven wrong.
+		literalsOnly := true
+		for i, arg := range t.Args {
+			newExpr, err := precalculateExpr(arg, tx, params)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := newExpr.(expr.LiteralValue); !ok {
+				literalsOnly = false
+			}
+			t.Args[i] = newExpr
+		}
+
+		// the result of a scalar function only depends on its arguments
+		if literalsOnly {
+			v, err := t.Eval(&expr.Environment{})
+			if err != nil {
+				return nil, err
+			}
+			return expr.LiteralValue(v), nil
+		}
 	case expr.PositionalParam, expr.NamedParam:
 		v, err := e.Eval(&expr.Environment{Params: params})
 		if err != nil {
@@ -235,6 +373,112 @@ func precalculateExpr(e expr.Expr, params []expr.Param) (expr.Expr, error) {
 	return e, nil
 }
 
+// precalculateCase simplifies the conditions of a CASE expression.
+// Constant conditions that are never met are removed and a constant condition that is always
+// met replaces the remaining ones. If there are no conditions left, the expression is replaced
+// by the ELSE result.
+//   CASE WHEN 1 > 2 THEN a WHEN b THEN c ELSE 10 END --> CASE WHEN b THEN c ELSE 10 END
+//   CASE 1 WHEN 2 THEN a WHEN 1 THEN b END --> b
+func precalculateCase(c *expr.CaseExpr, tx *database.Transaction, params []expr.Param) (expr.Expr, error) {
+	var err error
+
+	if c.Operand != nil {
+		c.Operand, err = precalculateExpr(c.Operand, tx, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, operandIsLit := c.Operand.(expr.LiteralValue)
+	if c.Operand == nil {
+		operandIsLit = true
+	}
+
+	if c.Else != nil {
+		c.Else, err = precalculateExpr(c.Else, tx, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var whens []expr.WhenClause
+	for _, w := range c.Whens {
+		w.When, err = precalculateExpr(w.When, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		w.Then, err = precalculateExpr(w.Then, tx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		when, whenIsLit := w.When.(expr.LiteralValue)
+		if !operandIsLit || !whenIsLit {
+			whens = append(whens, w)
+			continue
+		}
+
+		var ok bool
+		if c.Operand == nil {
+			ok, err = document.Value(when).IsTruthy()
+		} else {
+			var v document.Value
+			v, err = expr.Eq(c.Operand, when).Eval(&expr.Environment{})
+			ok = v.Type == document.BoolValue && v.V.(bool)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// the following conditions can never be reached
+		if ok {
+			c.Else = w.Then
+			break
+		}
+	}
+	c.Whens = whens
+
+	if len(c.Whens) > 0 {
+		return c, nil
+	}
+
+	if c.Else == nil {
+		return expr.LiteralValue(document.NewNullValue()), nil
+	}
+
+	return c.Else, nil
+}
+
+// precalculateSubquery optimizes the stream of the subquery sq used by e.
+// If the subquery is not correlated, e is evaluated and replaced by its result.
+func precalculateSubquery(e expr.Expr, sq *expr.Subquery, tx *database.Transaction, params []expr.Param) (expr.Expr, error) {
+	stmt, ok := sq.Stream.(*Statement)
+	if !ok {
+		return e, nil
+	}
+
+	st, err := Optimize(stmt.Stream, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Stream = st
+
+	if sq.Correlated {
+		return e, nil
+	}
+
+	v, ok, err := expr.EvalUncorrelated(e, &expr.Environment{Tx: tx, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	// the subquery refers to the document of the enclosing query
+	// using paths that are not qualified by a table name.
+	if !ok {
+		return e, nil
+	}
+
+	return expr.LiteralValue(v), nil
+}
+
 // RemoveUnnecessaryFilterNodesRule removes any filter node whose
 // condition is a constant expression that evaluates to a truthy value.
 // if it evaluates to a falsy value, it considers that the tree
@@ -290,11 +534,14 @@ func RemoveUnnecessaryFilterNodesRule(s *stream.Stream, _ *database.Transaction,
 
 // RemoveUnnecessaryProjection removes any project node whose
 // expression is a wildcard only.
+// Projections following a table write operator are kept: these operators
+// don't necessarily output documents, which must then be returned by the
+// projection (i.e. RETURNING *).
 func RemoveUnnecessaryProjection(s *stream.Stream, _ *database.Transaction, _ []expr.Param) (*stream.Stream, error) {
 	n := s.Op
 
 	for n != nil {
-		if p, ok := n.(*stream.ProjectOperator); ok {
+		if p, ok := n.(*stream.ProjectOperator); ok && !isTableWriteOperator(p.GetPrev()) {
 			if len(p.Exprs) == 1 {
 				if _, ok := p.Exprs[0].(expr.Wildcard); ok {
 					prev := n.GetPrev()
@@ -310,6 +557,15 @@ func RemoveUnnecessaryProjection(s *stream.Stream, _ *database.Transaction, _ []
 	return s, nil
 }
 
+func isTableWriteOperator(op stream.Operator) bool {
+	switch op.(type) {
+	case *stream.TableInsertOperator, *stream.TableReplaceOperator, *stream.TableDeleteOperator:
+		return true
+	}
+
+	return false
+}
+
 // RemoveUnnecessaryDistinctNodeRule removes any Dedup nodes
 // where projection is already unique.
 func RemoveUnnecessaryDistinctNodeRule(s *stream.Stream, tx *database.Transaction, _ []expr.Param) (*stream.Stream, error) {
@@ -388,6 +644,10 @@ type filterNode struct {
 	path document.Path
 	v    document.Value
 	f    *stream.FilterOperator
+	// operator used to select an index, which may differ
+	// from the condition of the filter, e.g. a = ANY(array) is
+	// considered as a IN array.
+	op expr.Operator
 }
 
 // UseIndexBasedOnFilterNodeRule scans the tree for filter nodes whose conditions are
@@ -428,12 +688,29 @@ func UseIndexBasedOnFilterNodeRule(s *stream.Stream, tx *database.Transaction, p
 	// then we collect all usable filter nodes, in order to see what index (or PK) can be
 	// used to replace them.
 	for n := s.Op; n != nil; n = n.GetPrev() {
+		switch n.(type) {
+		case *stream.AliasOperator, *stream.JoinOperator, *stream.UnnestOperator, *stream.HashAggregateOperator:
+			// filter nodes placed after an alias, a join or an aggregation operate
+			// on joined documents or groups, not on the documents of the table.
+			candidates = nil
+			filterNodes = nil
+			continue
+		}
+
 		if f, ok := n.(*stream.FilterOperator); ok {
 			if f.E == nil {
 				continue
 			}
 
-			op, ok := f.E.(expr.Operator)
+			e := f.E
+			// a = ANY(array) filters the same documents as a IN array.
+			// the filter itself is left untouched: it is only removed
+			// if an index or a primary key is used instead.
+			if t, ok := e.(*expr.AnyOperator); ok && t.Op == scanner.EQ {
+				e = expr.In(t.LeftHand(), t.RightHand())
+			}
+
+			op, ok := e.(expr.Operator)
 			if !ok {
 				continue
 			}
@@ -455,12 +732,12 @@ func UseIndexBasedOnFilterNodeRule(s *stream.Stream, tx *database.Transaction, p
 
 			v := document.Value(ev)
 
-			filterNodes = append(filterNodes, filterNode{path: path, v: v, f: f})
+			filterNodes = append(filterNodes, filterNode{path: path, v: v, f: f, op: op})
 
 			// check for primary keys scan while iterating on the filter nodes
 			if pk := info.GetPrimaryKey(); pk != nil && pk.Path.IsEqual(path) {
 				// if both types are different, don't select this scanner
-				v, ok, err := operandCanUseIndex(pk.Type, pk.Path, info.FieldConstraints, v)
+				v, ok, err := operatorOperandCanUseIndex(op, pk.Type, pk.Path, info.FieldConstraints, v)
 				if err != nil {
 					return nil, err
 				}
@@ -499,11 +776,11 @@ func UseIndexBasedOnFilterNodeRule(s *stream.Stream, tx *database.Transaction, p
 	}
 
 	isNodeEq := func(fno *filterNode) bool {
-		op := fno.f.E.(expr.Operator)
+		op := fno.op
 		return op.Token() == scanner.EQ || op.Token() == scanner.IN
 	}
 	isNodeComp := func(fno *filterNode) bool {
-		op := fno.f.E.(expr.Operator)
+		op := fno.op
 		return expr.IsComparisonOperator(op)
 	}
 
@@ -565,7 +842,7 @@ outer:
 				// what the index says this node type must be
 				typ := idx.Info.Types[i]
 
-				fno.v, ok, err = operandCanUseIndex(typ, fno.path, info.FieldConstraints, fno.v)
+				fno.v, ok, err = operatorOperandCanUseIndex(fno.op, typ, fno.path, info.FieldConstraints, fno.v)
 				if err != nil {
 					return nil, err
 				}
@@ -666,6 +943,146 @@ outer:
 	return s, nil
 }
 
+// UseIndexForJoinRule looks for join operators whose right stream is a sequential scan
+// and whose condition is, or contains, an equality between a path of the right table
+// and an expression that doesn't refer to the right table.
+// If that path is the primary key of the right table, or is indexed by a non-composite index,
+// the sequential scan is replaced by a lookup, evaluated for every document of the left side.
+// The join condition is left untouched.
+// Example:
+//   this:
+//     join(seqScan(b) AS b, a.id = b.aid)
+//   becomes this, if b.aid is indexed:
+//     join(indexLookup("idx_b_aid", a.id) AS b, a.id = b.aid)
+func UseIndexForJoinRule(s *stream.Stream, tx *database.Transaction, _ []expr.Param) (*stream.Stream, error) {
+	for n := s.Op; n != nil; n = n.GetPrev() {
+		j, ok := n.(*stream.JoinOperator)
+		if !ok || j.On == nil || j.Right == nil {
+			continue
+		}
+
+		st, ok := j.Right.Op.(*stream.SeqScanOperator)
+		if !ok || st.GetPrev() != nil {
+			continue
+		}
+
+		t, err := tx.GetTable(st.TableName)
+		if err != nil {
+			return nil, err
+		}
+		info := t.Info()
+		indexes := t.Indexes()
+
+		var selected stream.Operator
+		var priority int
+
+		for _, e := range splitANDExpr(j.On) {
+			op, ok := e.(expr.Operator)
+			if !ok || op.Token() != scanner.EQ {
+				continue
+			}
+
+			path, other, ok := joinOperands(op, j.Alias, leftAliases(j))
+			if !ok {
+				continue
+			}
+
+			if pk := info.GetPrimaryKey(); pk != nil && pk.Path.IsEqual(path) {
+				if priority < 3 {
+					selected, priority = stream.PkLookup(st.TableName, other), 3
+				}
+				continue
+			}
+
+			for _, idx := range indexes {
+				if idx.IsComposite() || !idx.Info.Paths[0].IsEqual(path) {
+					continue
+				}
+
+				p := 1
+				if idx.Info.Unique {
+					p = 2
+				}
+				if priority < p {
+					selected, priority = stream.IndexLookup(idx.Info.IndexName, other), p
+				}
+			}
+		}
+
+		if selected != nil {
+			j.Right = stream.New(selected)
+		}
+	}
+
+	return s, nil
+}
+
+// leftAliases returns the aliases of the documents joined before j.
+func leftAliases(j *stream.JoinOperator) []string {
+	var aliases []string
+
+	for n := j.GetPrev(); n != nil; n = n.GetPrev() {
+		switch t := n.(type) {
+		case *stream.AliasOperator:
+			aliases = append(aliases, t.Name)
+		case *stream.JoinOperator:
+			aliases = append(aliases, t.Alias)
+		case *stream.UnnestOperator:
+			aliases = append(aliases, t.Alias)
+		}
+	}
+
+	return aliases
+}
+
+// joinOperands determines if one of the operands of op is a path of the table
+// aliased by alias and if the other one only refers to the tables joined before,
+// whose aliases are given by left.
+// Since paths may omit the alias of their table, any path that isn't prefixed by one
+// of the left aliases may refer to the table and prevents the operator from being used.
+// If so, it returns the path, relative to the documents of the table, and the other operand.
+func joinOperands(op expr.Operator, alias string, left []string) (document.Path, expr.Expr, bool) {
+	isLeft := func(p expr.Path) bool {
+		for _, a := range left {
+			if len(p) > 0 && p[0].FieldName == a {
+				return true
+			}
+		}
+		return false
+	}
+
+	refersTo := func(e expr.Expr) bool {
+		var found bool
+		expr.Walk(e, func(e expr.Expr) bool {
+			switch t := e.(type) {
+			case expr.Path:
+				if !isLeft(t) {
+					found = true
+				}
+			case expr.Wildcard:
+				found = true
+			}
+			return !found
+		})
+		return found
+	}
+
+	for _, operands := range [][2]expr.Expr{{op.LeftHand(), op.RightHand()}, {op.RightHand(), op.LeftHand()}} {
+		p, ok := operands[0].(expr.Path)
+		if !ok || len(p) < 2 || p[0].FieldName != alias {
+			continue
+		}
+
+		if refersTo(operands[1]) {
+			continue
+		}
+
+		return document.Path(p[1:]), operands[1], true
+	}
+
+	return nil, nil, false
+}
+
 type candidate struct {
 	// filter operators to remove and replace by either an indexScan
 	// or pkScan operators.
@@ -738,6 +1155,40 @@ func operandCanUseIndex(indexType document.ValueType, path document.Path, fc dat
 	return converted, indexType == converted.Type, nil
 }
 
+// operatorOperandCanUseIndex calls operandCanUseIndex with the operand of op.
+// The operand of the IN operator is an array whose values are all compared with the path,
+// so each of them must be usable. Duplicate values are removed to avoid reading the same
+// documents twice.
+func operatorOperandCanUseIndex(op expr.Operator, indexType document.ValueType, path document.Path, fc database.FieldConstraints, v document.Value) (document.Value, bool, error) {
+	if op.Token() != scanner.IN {
+		return operandCanUseIndex(indexType, path, fc, v)
+	}
+
+	// operatorCanUseIndex made sure v is an array.
+	vb := document.NewValueBuffer()
+	ok := true
+	err := v.V.(document.Array).Iterate(func(i int, value document.Value) error {
+		converted, canUse, err := operandCanUseIndex(indexType, path, fc, value)
+		if err != nil {
+			return err
+		}
+
+		ok = ok && canUse
+		found, err := document.ArrayContains(vb, converted)
+		if err != nil || found {
+			return err
+		}
+
+		vb = vb.Append(converted)
+		return nil
+	})
+	if err != nil {
+		return v, false, err
+	}
+
+	return document.NewArrayValue(vb), ok, nil
+}
+
 func getRangesFromFilterNodes(fnodes []*filterNode) (stream.IndexRanges, error) {
 	var ranges stream.IndexRanges
 	vb := document.NewValueBuffer()
@@ -745,7 +1196,7 @@ func getRangesFromFilterNodes(fnodes []*filterNode) (stream.IndexRanges, error)
 	inOperands := make(map[int]document.Array)
 
 	for i, fno := range fnodes {
-		op := fno.f.E.(expr.Operator)
+		op := fno.op
 		v := fno.v
 
 		switch {
@@ -807,7 +1258,7 @@ func getRangesFromFilterNodes(fnodes []*filterNode) (stream.IndexRanges, error)
 			// the last node is the only one that can be a comparison operator, so
 			// it's the one setting the range behaviour
 			last := fnodes[len(fnodes)-1]
-			op := last.f.E.(expr.Operator)
+			op := last.op
 
 			rng := buildRange(op, newVB)
 
@@ -829,7 +1280,7 @@ func getRangesFromFilterNodes(fnodes []*filterNode) (stream.IndexRanges, error)
 	// the last node is the only one that can be a comparison operator, so
 	// it's the one setting the range behaviour
 	last := fnodes[len(fnodes)-1]
-	op := last.f.E.(expr.Operator)
+	op := last.op
 	rng := buildRange(op, vb)
 
 	return stream.IndexRanges{rng}, nil
@@ -8,6 +8,7 @@ import (
 	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/planner"
 	"github.com/tie/genji-release-test/sql/parser"
+	"github.com/tie/genji-release-test/sql/scanner"
 	st "github.com/tie/genji-release-test/stream"
 	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
@@ -169,6 +170,60 @@ func TestPrecalculateExprRule(t *testing.T) {
 			)),
 			nil,
 		},
+		{
+			"constant scalar function: a = LOWER(REPLACE(UPPER('foo'), 'O', 'U')) -> a = 'fuu'",
+			parser.MustParseExpr("a = LOWER(REPLACE(UPPER('foo'), 'O', 'U'))"),
+			parser.MustParseExpr("a = 'fuu'"),
+			nil,
+		},
+		{
+			"non-constant scalar function: SUBSTR(a, 1 + 1) -> SUBSTR(a, 2)",
+			parser.MustParseExpr("SUBSTR(a, 1 + 1)"),
+			parser.MustParseExpr("SUBSTR(a, 2)"),
+			nil,
+		},
+		{
+			"constant math function: a > POW(2, 3) + ABS(-1) -> a > 9",
+			parser.MustParseExpr("a > POW(2, 3) + ABS(-1)"),
+			parser.MustParseExpr("a > 9"),
+			nil,
+		},
+		{
+			"constant regexp: 'foo' =~ '^f' AND a !~ LOWER('B') -> true AND a !~ 'b'",
+			parser.MustParseExpr("'foo' =~ '^f' AND a !~ LOWER('B')"),
+			parser.MustParseExpr("true AND a !~ 'b'"),
+			nil,
+		},
+		{
+			"constant case: CASE WHEN 1 > 2 THEN a ELSE 1 + 1 END -> 2",
+			parser.MustParseExpr("CASE WHEN 1 > 2 THEN a ELSE 1 + 1 END"),
+			testutil.IntegerValue(2),
+			nil,
+		},
+		{
+			"constant case without else: CASE WHEN false THEN a END -> NULL",
+			parser.MustParseExpr("CASE WHEN false THEN a END"),
+			expr.LiteralValue(document.NewNullValue()),
+			nil,
+		},
+		{
+			"non-constant case: CASE WHEN 1 > 2 THEN a WHEN b THEN 1 + 1 WHEN true THEN c WHEN d THEN e END -> CASE WHEN b THEN 2 ELSE c END",
+			parser.MustParseExpr("CASE WHEN 1 > 2 THEN a WHEN b THEN 1 + 1 WHEN true THEN c WHEN d THEN e END"),
+			parser.MustParseExpr("CASE WHEN b THEN 2 ELSE c END"),
+			nil,
+		},
+		{
+			"constant simple case: CASE ? WHEN 1 THEN a WHEN 2 THEN b END -> b",
+			parser.MustParseExpr("CASE ? WHEN 1 THEN a WHEN 2 THEN b END"),
+			parser.MustParseExpr("b"),
+			[]expr.Param{{Value: 2}},
+		},
+		{
+			"non-constant simple case: CASE a WHEN 1 + 1 THEN b END -> CASE a WHEN 2 THEN b END",
+			parser.MustParseExpr("CASE a WHEN 1 + 1 THEN b END"),
+			parser.MustParseExpr("CASE a WHEN 2 THEN b END"),
+			nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -381,6 +436,64 @@ func TestUseIndexBasedOnSelectionNodeRule_Simple(t *testing.T) {
 			)),
 			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: newVB(document.NewIntegerValue(1)), Exact: true}, st.IndexRange{Min: newVB(document.NewIntegerValue(2)), Exact: true})),
 		},
+		{
+			"FROM foo WHERE a = ANY([1, 2])",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(
+				expr.Any(scanner.EQ)(
+					parser.MustParseExpr("a"),
+					testutil.ArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))),
+				),
+			)),
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: newVB(document.NewIntegerValue(1)), Exact: true}, st.IndexRange{Min: newVB(document.NewIntegerValue(2)), Exact: true})),
+		},
+		{
+			"FROM foo WHERE d = ANY([1, 2])",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(
+				expr.Any(scanner.EQ)(
+					parser.MustParseExpr("d"),
+					testutil.ArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))),
+				),
+			)),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(
+				expr.Any(scanner.EQ)(
+					parser.MustParseExpr("d"),
+					testutil.ArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))),
+				),
+			)),
+		},
+		{
+			"FROM foo WHERE a = ANY([1, 2]) AND k = 1",
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(
+					expr.Any(scanner.EQ)(
+						parser.MustParseExpr("a"),
+						testutil.ArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))),
+					),
+				)).
+				Pipe(st.Filter(parser.MustParseExpr("k = 1"))),
+			st.New(st.PkScan("foo", st.ValueRange{Min: document.NewIntegerValue(1), Exact: true})).
+				Pipe(st.Filter(
+					expr.Any(scanner.EQ)(
+						parser.MustParseExpr("a"),
+						testutil.ArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))),
+					),
+				)),
+		},
+		{
+			"FROM foo WHERE a > ALL([1, 2])",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(
+				expr.All(scanner.GT)(
+					parser.MustParseExpr("a"),
+					testutil.ArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))),
+				),
+			)),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(
+				expr.All(scanner.GT)(
+					parser.MustParseExpr("a"),
+					testutil.ArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))),
+				),
+			)),
+		},
 		{
 			"FROM foo WHERE 1 IN a",
 			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("1 IN a"))),
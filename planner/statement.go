@@ -40,6 +40,16 @@ func (s *Statement) String() string {
 	return s.Stream.String()
 }
 
+// Iterate the stream of the statement using the given environment.
+// It allows the statement to be used as a subquery.
+func (s *Statement) Iterate(in *expr.Environment, fn func(out *expr.Environment) error) error {
+	err := s.Stream.Iterate(in, fn)
+	if err == stream.ErrStreamClosed {
+		err = nil
+	}
+	return err
+}
+
 type statementIterator struct {
 	Stream *stream.Stream
 	Tx     *database.Transaction
@@ -25,9 +25,13 @@ func (stmt CreateTableStmt) Run(tx *database.Transaction, args []expr.Param) (Re
 
 	err := tx.CreateTable(stmt.TableName, &stmt.Info)
 	if stmt.IfNotExists && err == database.ErrTableAlreadyExists {
-		err = nil
+		return res, nil
+	}
+	if err != nil {
+		return res, err
 	}
 
+	// UNIQUE field constraints are enforced using unique indexes.
 	for _, fc := range stmt.Info.FieldConstraints {
 		if fc.IsUnique {
 			err = tx.CreateIndex(&database.IndexInfo{
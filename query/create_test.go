@@ -2,11 +2,14 @@ package query_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/tie/genji-release-test"
 	"github.com/tie/genji-release-test/database"
 	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/sql/parser"
+	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -224,9 +227,13 @@ func TestCreateTable(t *testing.T) {
 				constraints database.FieldConstraints
 				fails       bool
 			}{
-				{"With default, no type and integer default", "CREATE TABLE test(foo DEFAULT 10)", database.FieldConstraints{{Path: parsePath(t, "foo"), DefaultValue: document.NewDoubleValue(10)}}, false},
-				{"With default, double type and integer default", "CREATE TABLE test(foo DOUBLE DEFAULT 10)", database.FieldConstraints{{Path: parsePath(t, "foo"), Type: document.DoubleValue, DefaultValue: document.NewDoubleValue(10)}}, false},
-				{"With default, some type and compatible default", "CREATE TABLE test(foo BOOL DEFAULT 10)", database.FieldConstraints{{Path: parsePath(t, "foo"), Type: document.BoolValue, DefaultValue: document.NewBoolValue(true)}}, false},
+				{"With default, no type and integer default", "CREATE TABLE test(foo DEFAULT 10)", database.FieldConstraints{{Path: parsePath(t, "foo"), DefaultValue: expr.Constraint(parser.MustParseExpr("10"))}}, false},
+				{"With default, double type and integer default", "CREATE TABLE test(foo DOUBLE DEFAULT 10)", database.FieldConstraints{{Path: parsePath(t, "foo"), Type: document.DoubleValue, DefaultValue: expr.Constraint(parser.MustParseExpr("10"))}}, false},
+				{"With default, some type and compatible default", "CREATE TABLE test(foo BOOL DEFAULT 10)", database.FieldConstraints{{Path: parsePath(t, "foo"), Type: document.BoolValue, DefaultValue: expr.Constraint(parser.MustParseExpr("10"))}}, false},
+				{"With default expression", "CREATE TABLE test(foo DOUBLE DEFAULT (bar + 1))", database.FieldConstraints{{Path: parsePath(t, "foo"), Type: document.DoubleValue, DefaultValue: expr.Constraint(parser.MustParseExpr("(bar + 1)"))}}, false},
+				{"With default subquery", "CREATE TABLE test(foo DEFAULT (SELECT 1))", nil, true},
+				{"With default parameter", "CREATE TABLE test(foo DEFAULT (?))", nil, true},
+				{"With default named parameter", "CREATE TABLE test(foo DEFAULT ($bar + 1))", nil, true},
 				{"With default, some type and incompatible default", "CREATE TABLE test(foo BOOL DEFAULT 10.5)", nil, true},
 			}
 
@@ -255,6 +262,51 @@ func TestCreateTable(t *testing.T) {
 			}
 		})
 
+		t.Run("default expressions", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE test (a INT, b INT DEFAULT (a + 1), c TEXT NOT NULL DEFAULT uuid(), d TIMESTAMP DEFAULT NOW(), e DEFAULT 10);
+				INSERT INTO test (a) VALUES (1), (2);
+				INSERT INTO test (a, b, c) VALUES (3, 3, "c");
+			`)
+			require.NoError(t, err)
+
+			res, err := db.Query("SELECT a, b, e, typeof(e) AS t FROM test")
+			require.NoError(t, err)
+			testutil.RequireStreamEq(t, `
+				{"a": 1, "b": 2, "e": 10.0, "t": "double"}
+				{"a": 2, "b": 3, "e": 10.0, "t": "double"}
+				{"a": 3, "b": 3, "e": 10.0, "t": "double"}
+			`, res)
+			err = res.Close()
+			require.NoError(t, err)
+
+			// default values are evaluated for every document
+			res, err = db.Query("SELECT c, d FROM test WHERE a < 3")
+			require.NoError(t, err)
+			var uuids []string
+			err = res.Iterate(func(d document.Document) error {
+				var c string
+				var ts time.Time
+				err := document.Scan(d, &c, &ts)
+				require.False(t, ts.IsZero())
+				uuids = append(uuids, c)
+				return err
+			})
+			require.NoError(t, err)
+			err = res.Close()
+			require.NoError(t, err)
+			require.Len(t, uuids, 2)
+			require.Len(t, uuids[0], 36)
+			require.NotEqual(t, uuids[0], uuids[1])
+
+			err = db.Exec("INSERT INTO test (a, c) VALUES (4, NULL)")
+			require.EqualError(t, err, `field "c" is required and must be not null`)
+		})
+
 		t.Run("unique", func(t *testing.T) {
 			db, err := genji.Open(":memory:")
 			require.NoError(t, err)
@@ -303,6 +355,171 @@ func TestCreateTable(t *testing.T) {
 				return nil
 			})
 			require.NoError(t, err)
+
+			// the index enforcing the constraint cannot be dropped
+			err = db.Exec("DROP INDEX __genji_autoindex_test_1")
+			require.Error(t, err)
+		})
+
+		t.Run("with check constraints", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE test (a INT CHECK (a > 0), b INT, CONSTRAINT ordered CHECK (a < b))")
+			require.NoError(t, err)
+
+			err = db.Exec("INSERT INTO test (a, b) VALUES (0, 10)")
+			require.EqualError(t, err, `document violates check constraint "test_a_check"`)
+
+			err = db.Exec("INSERT INTO test (a, b) VALUES (1, 10)")
+			require.NoError(t, err)
+
+			err = db.Exec("UPDATE test SET b = 1")
+			require.EqualError(t, err, `document violates check constraint "ordered"`)
+		})
+
+		t.Run("with foreign keys", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE parent (id INT PRIMARY KEY, code TEXT UNIQUE);
+				CREATE TABLE child (
+					a INT REFERENCES parent ON DELETE CASCADE,
+					b TEXT,
+					c INT REFERENCES parent(id),
+					FOREIGN KEY (b) REFERENCES parent(code) ON DELETE SET NULL
+				);
+				INSERT INTO parent (id, code) VALUES (1, "a"), (2, "b"), (3, "c");
+				INSERT INTO child (a, b) VALUES (1, "b"), (2, "a"), (1, null);
+				INSERT INTO child (c) VALUES (3);
+			`)
+			require.NoError(t, err)
+
+			err = db.Exec("INSERT INTO child (a) VALUES (4)")
+			require.EqualError(t, err, `document violates foreign key constraint "child_a_fkey"`)
+
+			err = db.Exec(`UPDATE child SET b = "d"`)
+			require.EqualError(t, err, `document violates foreign key constraint "child_b_fkey"`)
+
+			err = db.Exec("UPDATE parent SET id = 10 WHERE id = 1")
+			require.EqualError(t, err, `document is referenced by foreign key "child_a_fkey" of table "child"`)
+
+			err = db.Exec("DELETE FROM parent WHERE id = 3")
+			require.EqualError(t, err, `document is referenced by foreign key "child_c_fkey" of table "child"`)
+
+			err = db.Exec("DROP TABLE parent")
+			require.Error(t, err)
+
+			err = db.Exec("DELETE FROM parent WHERE id = 1")
+			require.NoError(t, err)
+
+			res, err := db.Query("SELECT a, b FROM child WHERE c IS NULL")
+			require.NoError(t, err)
+			testutil.RequireStreamEq(t, `{"a": 2, "b": null}`, res)
+			err = res.Close()
+			require.NoError(t, err)
+
+			// foreign keys follow renamed tables
+			err = db.Exec("ALTER TABLE parent RENAME TO p; DELETE FROM p WHERE id = 2")
+			require.NoError(t, err)
+
+			err = db.Exec("DROP TABLE child; DROP TABLE p")
+			require.NoError(t, err)
+		})
+
+		t.Run("with self-referencing foreign keys", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE tree (id INT PRIMARY KEY, parent INT REFERENCES tree ON DELETE CASCADE);
+				INSERT INTO tree (id, parent) VALUES (1, null), (2, 1), (3, 2), (4, 4), (5, 1);
+				DELETE FROM tree WHERE id = 1;
+			`)
+			require.NoError(t, err)
+
+			res, err := db.Query("SELECT * FROM tree")
+			require.NoError(t, err)
+			defer res.Close()
+			testutil.RequireStreamEq(t, `{"id": 4, "parent": 4}`, res)
+		})
+
+		t.Run("with generated fields", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE test (price DOUBLE, qty INT, total DOUBLE NOT NULL AS (price * qty) STORED CHECK (total < 100));
+				CREATE INDEX test_total_idx ON test (total);
+				INSERT INTO test (price, qty) VALUES (2.5, 4), (10, 1);
+			`)
+			require.NoError(t, err)
+
+			err = db.Exec("INSERT INTO test (price, qty, total) VALUES (1, 1, 1)")
+			require.EqualError(t, err, `cannot write to generated field "total"`)
+
+			err = db.Exec("UPDATE test SET total = 1")
+			require.EqualError(t, err, `cannot write to generated field "total"`)
+
+			err = db.Exec("INSERT INTO test (price) VALUES (1)")
+			require.EqualError(t, err, `field "total" is required and must be not null`)
+
+			err = db.Exec("UPDATE test SET qty = 20 WHERE price = 10")
+			require.EqualError(t, err, `document violates check constraint "test_total_check"`)
+
+			err = db.Exec("UPDATE test SET qty = qty + 1 WHERE price = 10")
+			require.NoError(t, err)
+
+			res, err := db.Query("SELECT price, total FROM test WHERE total > 15")
+			require.NoError(t, err)
+			testutil.RequireStreamEq(t, `{"price": 10.0, "total": 20.0}`, res)
+			err = res.Close()
+			require.NoError(t, err)
+
+			err = db.Exec("ALTER TABLE test ADD FIELD other AS (price) STORED")
+			require.Error(t, err)
+		})
+
+		t.Run("with chained generated fields", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE test (a INT, c INT AS (a * 2) STORED, b INT AS (c + 1) STORED);
+				INSERT INTO test (a) VALUES (1);
+			`)
+			require.NoError(t, err)
+
+			d, err := db.QueryDocument("SELECT a, b, c FROM test")
+			require.NoError(t, err)
+			testutil.RequireDocJSONEq(t, d, `{"a": 1, "b": 3, "c": 2}`)
+
+			err = db.Exec("CREATE TABLE other (a INT, b INT AS (c + 1) STORED, c INT AS (a * 2) STORED)")
+			require.Error(t, err)
+			err = db.Exec("CREATE TABLE other (a INT AS (a + 1) STORED)")
+			require.Error(t, err)
+		})
+
+		t.Run("with invalid foreign keys", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE parent (id INT, code TEXT)")
+			require.NoError(t, err)
+
+			err = db.Exec("CREATE TABLE child (a REFERENCES unknown)")
+			require.Error(t, err)
+			err = db.Exec("CREATE TABLE child (a REFERENCES parent)")
+			require.Error(t, err)
+			err = db.Exec("CREATE TABLE child (a REFERENCES parent(code))")
+			require.Error(t, err)
 		})
 	})
 }
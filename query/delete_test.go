@@ -60,4 +60,57 @@ func TestDeleteStmt(t *testing.T) {
 			require.JSONEq(t, test.expected, buf.String())
 		})
 	}
+	t.Run("with RETURNING", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE foo; INSERT INTO foo (a) VALUES (1), (2), (3)`)
+		require.NoError(t, err)
+
+		st, err := db.Query(`DELETE FROM foo WHERE a > 1 RETURNING pk(), a`)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, st)
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+		require.JSONEq(t, `[{"pk()": 2, "a": 2}, {"pk()": 3, "a": 3}]`, buf.String())
+
+		d, err := db.QueryDocument(`SELECT COUNT(*) AS c FROM foo`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"c": 1}`)
+	})
+
+	t.Run("with RETURNING *", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE foo; INSERT INTO foo (a, b) VALUES (1, 'x'), (2, 'y')`)
+		require.NoError(t, err)
+
+		st, err := db.Query(`DELETE FROM foo WHERE pk() = 1 RETURNING *`)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, st)
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+		require.JSONEq(t, `[{"a": 1, "b": "x"}]`, buf.String())
+
+		// without RETURNING, nothing is returned
+		st, err = db.Query(`DELETE FROM foo WHERE a = 2`)
+		require.NoError(t, err)
+
+		buf.Reset()
+		err = testutil.IteratorToJSONArray(&buf, st)
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+		require.JSONEq(t, `[]`, buf.String())
+
+		d, err := db.QueryDocument(`SELECT COUNT(*) AS c FROM foo`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"c": 0}`)
+	})
 }
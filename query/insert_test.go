@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/tie/genji-release-test"
+	"github.com/tie/genji-release-test/database"
 	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -154,3 +155,86 @@ func TestInsertSelect(t *testing.T) {
 		})
 	}
 }
+
+func TestInsertOnConflict(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Primary key / No conflict clause", `INSERT INTO foo (k, a) VALUES (1, 10)`, true, ``},
+		{"Primary key / Do nothing", `INSERT INTO foo (k, a, b) VALUES (1, 10, 'z'), (3, 30, 'c') ON CONFLICT DO NOTHING`, false,
+			`[{"k":1,"a":1,"b":"a"},{"k":2,"a":2,"b":"b"},{"k":3,"a":30,"b":"c"}]`},
+		{"Primary key / Do update", `INSERT INTO foo (k, a) VALUES (1, 10) ON CONFLICT DO UPDATE SET a = a + excluded.a, c = true`, false,
+			`[{"k":1,"a":11,"b":"a","c":true},{"k":2,"a":2,"b":"b"}]`},
+		{"Primary key / Target", `INSERT INTO foo (k, a) VALUES (2, 20) ON CONFLICT (k) DO UPDATE SET a = excluded.a`, false,
+			`[{"k":1,"a":1,"b":"a"},{"k":2,"a":20,"b":"b"}]`},
+		{"Unique index / Do nothing", `INSERT INTO foo (k, a, b) VALUES (3, 30, 'a') ON CONFLICT DO NOTHING`, false,
+			`[{"k":1,"a":1,"b":"a"},{"k":2,"a":2,"b":"b"}]`},
+		{"Unique index / Do update", `INSERT INTO foo (k, a, b) VALUES (3, 30, 'b') ON CONFLICT (b) DO UPDATE SET a = excluded.a`, false,
+			`[{"k":1,"a":1,"b":"a"},{"k":2,"a":30,"b":"b"}]`},
+		{"Unique index / Other target", `INSERT INTO foo (k, a, b) VALUES (3, 30, 'b') ON CONFLICT (k) DO NOTHING`, true, ``},
+		{"Unknown target", `INSERT INTO foo (k, a) VALUES (1, 10) ON CONFLICT (a) DO NOTHING`, true, ``},
+		{"Conflict within the statement", `INSERT INTO foo (k, a) VALUES (3, 1), (3, 2) ON CONFLICT DO UPDATE SET a = a + excluded.a`, false,
+			`[{"k":1,"a":1,"b":"a"},{"k":2,"a":2,"b":"b"},{"k":3,"a":3}]`},
+		{"Update violates unique index", `INSERT INTO foo (k, a) VALUES (1, 10) ON CONFLICT DO UPDATE SET b = 'b'`, true, ``},
+		{"Select", `INSERT INTO foo SELECT * FROM bar ON CONFLICT DO UPDATE SET a = excluded.a`, false,
+			`[{"k":1,"a":1,"b":"a"},{"k":2,"a":20,"b":"b"},{"k":3,"a":30}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE foo (k INTEGER PRIMARY KEY);
+				CREATE UNIQUE INDEX idx_foo_b ON foo (b);
+				INSERT INTO foo (k, a, b) VALUES (1, 1, 'a'), (2, 2, 'b');
+				CREATE TABLE bar;
+				INSERT INTO bar (k, a) VALUES (2, 20), (3, 30);
+			`)
+			require.NoError(t, err)
+
+			err = db.Exec(test.query)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			st, err := db.Query("SELECT * FROM foo")
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("with RETURNING", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE test (k INTEGER PRIMARY KEY); INSERT INTO test (k, a) VALUES (1, 1)`)
+		require.NoError(t, err)
+
+		st, err := db.Query(`INSERT INTO test (k, a) VALUES (1, 10), (2, 20) ON CONFLICT DO UPDATE SET a = excluded.a + 1 RETURNING pk(), a`)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, st)
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+		require.JSONEq(t, `[{"pk()":1,"a":11},{"pk()":2,"a":20}]`, buf.String())
+
+		d, err := db.QueryDocument(`INSERT INTO test (k, a) VALUES (1, 100) ON CONFLICT DO NOTHING RETURNING *`)
+		require.Equal(t, err, database.ErrDocumentNotFound)
+		require.Nil(t, d)
+	})
+}
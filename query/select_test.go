@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"io/ioutil"
 	"strconv"
 	"testing"
 
@@ -77,6 +78,21 @@ func TestSelectStmt(t *testing.T) {
 		{"With offset then limit", "SELECT * FROM test WHERE size = 10 OFFSET 1 LIMIT 1", true, "", nil},
 		{"With positional params", "SELECT * FROM test WHERE color = ? OR height = ?", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":3,"height":100,"weight":200}]`, []interface{}{"red", 100}},
 		{"With named params", "SELECT * FROM test WHERE color = $a OR height = $d", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":3,"height":100,"weight":200}]`, []interface{}{sql.Named("a", "red"), sql.Named("d", 100)}},
+		{"With coalesce", "SELECT COALESCE(shape, color, 'none') AS s FROM test", false, `[{"s":"square"},{"s":"blue"},{"s":"none"}]`, nil},
+		{"With ifnull", "SELECT IFNULL(weight, 0) AS w FROM test", false, `[{"w":0},{"w":100},{"w":200}]`, nil},
+		{"With nullif", "SELECT k FROM test WHERE NULLIF(size, 10) IS NULL", false, `[{"k":1},{"k":2},{"k":3}]`, nil},
+		{"With typeof", "SELECT typeof(k) AS k, typeof(color) AS c FROM test WHERE typeof(weight) = 'double'", false, `[{"k":"integer","c":"text"},{"k":"integer","c":"null"}]`, nil},
+		{"With text functions", "SELECT UPPER(color) AS c, LENGTH(shape) AS l FROM test WHERE LOWER(color) = LOWER('RED')", false, `[{"c":"RED","l":6}]`, nil},
+		{"With nested text functions", "SELECT FORMAT('%s-%s', LPAD(SUBSTR(color, 1, 2), 3, '*'), INSTR(color, 'e')) AS f FROM test WHERE color IS NOT NULL", false, `[{"f":"*re-2"},{"f":"*bl-4"}]`, nil},
+		{"With math functions", "SELECT SQRT(weight) AS s, GREATEST(size, height) AS g, ROUND(size / 3, 1) AS r FROM test ORDER BY k", false, `[{"s":null,"g":10,"r":3.3},{"s":10,"g":10,"r":3.3},{"s":14.142135623730951,"g":100,"r":null}]`, nil},
+		{"No table, math functions", "SELECT ABS(-2) AS a, POW(2, 10) AS p, LEAST(3, 1.5) AS l", false, `[{"a":2,"p":1024,"l":1.5}]`, nil},
+		{"With math overflow", "SELECT * FROM test WHERE size > POW(2, 64)", true, ``, nil},
+		{"With between", "SELECT k FROM test WHERE weight BETWEEN 150 AND 250", false, `[{"k":3}]`, nil},
+		{"With regexp", "SELECT k FROM test WHERE color =~ '^(r|g)'", false, `[{"k":1}]`, nil},
+		{"With not regexp", "SELECT k FROM test WHERE color !~ '^(r|g)'", false, `[{"k":2}]`, nil},
+		{"With regexp param", "SELECT k FROM test WHERE color =~ ?", false, `[{"k":2}]`, []interface{}{"u"}},
+		{"With invalid constant regexp", "SELECT k FROM test WHERE 'x' =~ '('", true, ``, nil},
+		{"With regexp functions", "SELECT REGEXP_REPLACE(color, '[aeiou]', '_') AS r, REGEXP_EXTRACT(shape, '^(.)(.)', 2) AS e FROM test WHERE color IS NOT NULL", false, `[{"r":"r_d","e":"q"},{"r":"bl__","e":null}]`, nil},
 		{"With pk()", "SELECT pk(), color FROM test", false, `[{"pk()":1,"color":"red"},{"pk()":2,"color":"blue"},{"pk()":3,"color":null}]`, []interface{}{sql.Named("a", "red"), sql.Named("d", 100)}},
 		{"With pk in cond, gt", "SELECT * FROM test WHERE k > 0 AND weight = 100", false, `[{"k":2,"color":"blue","size":10,"weight":100,"k":2}]`, nil},
 		{"With pk in cond, =", "SELECT * FROM test WHERE k = 2.0 AND weight = 100", false, `[{"k":2,"color":"blue","size":10,"weight":100,"k":2}]`, nil},
@@ -378,3 +394,729 @@ func TestDistinct(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectJoin(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Inner join", "SELECT a.name, b.label FROM foo AS a JOIN bar AS b ON a.id = b.fooid ORDER BY b.k", false, `[{"a.name":"x","b.label":"first"},{"a.name":"x","b.label":"second"},{"a.name":"y","b.label":"third"}]`},
+		{"Inner join without aliases", "SELECT foo.name, bar.label FROM foo JOIN bar ON foo.id = bar.fooid AND bar.k > 1 ORDER BY bar.k", false, `[{"foo.name":"x","bar.label":"second"},{"foo.name":"y","bar.label":"third"}]`},
+		{"Inner join on pk", "SELECT a.name, b.label FROM bar b INNER JOIN foo a ON b.fooid = a.id ORDER BY b.k", false, `[{"a.name":"x","b.label":"first"},{"a.name":"x","b.label":"second"},{"a.name":"y","b.label":"third"}]`},
+		{"Left join", "SELECT a.name, b.label FROM foo a LEFT JOIN bar b ON a.id = b.fooid ORDER BY a.id", false, `[{"a.name":"x","b.label":"first"},{"a.name":"x","b.label":"second"},{"a.name":"y","b.label":"third"},{"a.name":"z","b.label":null}]`},
+		{"Left outer join wildcard", "SELECT * FROM foo a LEFT OUTER JOIN bar b ON a.id = b.fooid WHERE a.id = 3", false, `[{"a":{"id":3,"name":"z"},"b":null}]`},
+		{"Comma join", "SELECT a.id, b.k FROM foo a, bar b WHERE a.id = b.fooid AND a.name = 'y'", false, `[{"a.id":2,"b.k":3}]`},
+		{"Cross join count", "SELECT COUNT(*) FROM foo CROSS JOIN bar", false, `[{"COUNT(*)":9}]`},
+		{"Self join", "SELECT x.name, y.name FROM foo x JOIN foo y ON x.id + 1 = y.id ORDER BY x.id", false, `[{"x.name":"x","y.name":"y"},{"x.name":"y","y.name":"z"}]`},
+		{"Multiple joins", "SELECT a.name, b.label, c.name FROM foo a JOIN bar b ON a.id = b.fooid JOIN foo c ON c.id = b.k ORDER BY b.k", false, `[{"a.name":"x","b.label":"first","c.name":"x"},{"a.name":"x","b.label":"second","c.name":"y"},{"a.name":"y","b.label":"third","c.name":"z"}]`},
+		{"Join with params", "SELECT b.label FROM foo a JOIN bar b ON a.id = b.fooid AND b.k = ?", false, `[{"b.label":"second"}]`},
+		{"Duplicate alias", "SELECT * FROM foo JOIN foo ON foo.id = foo.id", true, ``},
+		{"Missing ON", "SELECT * FROM foo JOIN bar", true, ``},
+		{"Single table alias", "SELECT name FROM foo f WHERE id = 2", false, `[{"name":"y"}]`},
+		{"Single table alias, mixed paths", "SELECT f.id, name FROM foo AS f ORDER BY id DESC LIMIT 1", false, `[{"f.id":3,"name":"z"}]`},
+		{"Unqualified paths", "SELECT name, label FROM foo a JOIN bar b ON id = fooid ORDER BY k", false, `[{"name":"x","label":"first"},{"name":"x","label":"second"},{"name":"y","label":"third"}]`},
+		{"Unqualified path compared to indexed path", "SELECT a.name, b.label FROM foo a JOIN bar b ON b.fooid = id ORDER BY b.k", false, `[{"a.name":"x","b.label":"first"},{"a.name":"x","b.label":"second"},{"a.name":"y","b.label":"third"}]`},
+		{"Unqualified path of the joined table", "SELECT a.name, b.label FROM foo a JOIN bar b ON b.fooid = k ORDER BY a.id", false, `[{"a.name":"x","b.label":"first"},{"a.name":"y","b.label":"first"},{"a.name":"z","b.label":"first"}]`},
+		{"Ambiguous path", "SELECT id FROM foo a JOIN foo b ON a.id = b.id", true, ``},
+	}
+
+	for _, test := range tests {
+		testFn := func(withIndexes bool) func(t *testing.T) {
+			return func(t *testing.T) {
+				db, err := genji.Open(":memory:")
+				require.NoError(t, err)
+				defer db.Close()
+
+				err = db.Exec(`
+					CREATE TABLE foo (id INTEGER PRIMARY KEY);
+					CREATE TABLE bar (k INTEGER PRIMARY KEY);
+				`)
+				require.NoError(t, err)
+				if withIndexes {
+					err = db.Exec(`
+						CREATE INDEX idx_bar_fooid ON bar (fooid);
+						CREATE INDEX idx_foo_name ON foo (name);
+					`)
+					require.NoError(t, err)
+				}
+
+				err = db.Exec(`
+					INSERT INTO foo (id, name) VALUES (1, 'x'), (2, 'y'), (3, 'z');
+					INSERT INTO bar (k, fooid, label) VALUES (1, 1, 'first'), (2, 1, 'second'), (3, 2, 'third');
+				`)
+				require.NoError(t, err)
+
+				st, err := db.Query(test.query, 2)
+				if test.fails {
+					// some errors are only detected while reading the documents
+					if err == nil {
+						err = testutil.IteratorToJSONArray(ioutil.Discard, st)
+						st.Close()
+					}
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			}
+		}
+		t.Run("No Index/"+test.name, testFn(false))
+		t.Run("With Index/"+test.name, testFn(true))
+	}
+}
+
+func TestSelectUnnest(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Comma", "SELECT o.id, item.name FROM orders o, UNNEST(o.items) AS item ORDER BY o.id, item.name", false, `[{"o.id":1,"item.name":"apple"},{"o.id":1,"item.name":"apple"},{"o.id":1,"item.name":"pear"},{"o.id":2,"item.name":"apple"}]`},
+		{"Filter", "SELECT orders.id, i.qty FROM orders CROSS JOIN UNNEST(orders.items) i WHERE i.qty > 2", false, `[{"orders.id":1,"i.qty":3},{"orders.id":2,"i.qty":5}]`},
+		{"Scalars", "SELECT tag, COUNT(*) AS c FROM orders o, UNNEST(o.tags) tag GROUP BY tag ORDER BY tag", false, `[{"tag":"a","c":2},{"tag":"b","c":1}]`},
+		{"Aggregate", "SELECT item.name, SUM(item.qty) AS total FROM orders o, UNNEST(o.items) item GROUP BY item.name ORDER BY item.name", false, `[{"item.name":"apple","total":10},{"item.name":"pear","total":1}]`},
+		{"Condition", "SELECT o.id, i.qty FROM orders o JOIN UNNEST(o.items) i ON i.name = 'apple' ORDER BY o.id", false, `[{"o.id":1,"i.qty":2},{"o.id":1,"i.qty":3},{"o.id":2,"i.qty":5}]`},
+		{"Left", "SELECT o.id, t FROM orders o LEFT JOIN UNNEST(o.tags) t ON t = 'b' ORDER BY o.id", false, `[{"o.id":1,"t":"b"},{"o.id":2,"t":null},{"o.id":3,"t":null}]`},
+		{"With params", "SELECT v FROM orders o, UNNEST(?) v WHERE o.id = 1", false, `[{"v":1},{"v":2}]`},
+		{"Unqualified", "SELECT id, t FROM orders, UNNEST(tags) AS t ORDER BY id, t", false, `[{"id":1,"t":"a"},{"id":1,"t":"b"},{"id":2,"t":"a"}]`},
+		{"Unqualified with qualified array", "SELECT id, t FROM orders, UNNEST(orders.tags) t ORDER BY id, t", false, `[{"id":1,"t":"a"},{"id":1,"t":"b"},{"id":2,"t":"a"}]`},
+		{"Unqualified with aliases", "SELECT id, name FROM orders o, UNNEST(o.items) AS item WHERE qty > 2 ORDER BY id", false, `[{"id":1,"name":"apple"},{"id":2,"name":"apple"}]`},
+		{"Ambiguous", "SELECT id FROM orders, UNNEST([{id: 10}]) AS x", true, ``},
+		{"Duplicate alias", "SELECT * FROM orders o, UNNEST(o.tags) o", true, ``},
+		{"Missing alias", "SELECT * FROM orders o, UNNEST(o.tags)", true, ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE orders (id INTEGER PRIMARY KEY);
+				INSERT INTO orders (id, items, tags) VALUES
+					(1, [{name: 'apple', qty: 2}, {name: 'pear', qty: 1}, {name: 'apple', qty: 3}], ['a', 'b']),
+					(2, [{name: 'apple', qty: 5}], ['a']),
+					(3, [], NULL);
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query, []int{1, 2})
+			if test.fails {
+				// some errors are only detected while reading the documents
+				if err == nil {
+					err = testutil.IteratorToJSONArray(ioutil.Discard, st)
+					st.Close()
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectSubquery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"In", "SELECT id FROM foo WHERE id IN (SELECT fooid FROM bar)", false, `[{"id":1},{"id":2}]`},
+		{"Not in", "SELECT id FROM foo WHERE id NOT IN (SELECT fooid FROM bar)", false, `[{"id":3}]`},
+		{"In with filter", "SELECT id FROM foo WHERE id IN (SELECT fooid FROM bar WHERE label = 'third')", false, `[{"id":2}]`},
+		{"In with params", "SELECT id FROM foo WHERE id IN (SELECT fooid FROM bar WHERE k = ?)", false, `[{"id":1}]`},
+		{"In empty", "SELECT id FROM foo WHERE id IN (SELECT fooid FROM bar WHERE k > 10)", false, `[]`},
+		{"Exists", "SELECT id FROM foo WHERE EXISTS (SELECT * FROM bar WHERE fooid = foo.id)", false, `[{"id":1},{"id":2}]`},
+		{"Not exists", "SELECT id FROM foo WHERE NOT EXISTS (SELECT * FROM bar WHERE fooid = foo.id)", false, `[{"id":3}]`},
+		{"Uncorrelated exists", "SELECT id FROM foo WHERE EXISTS (SELECT * FROM bar WHERE k = 3)", false, `[{"id":1},{"id":2},{"id":3}]`},
+		{"Uncorrelated not exists", "SELECT id FROM foo WHERE NOT EXISTS (SELECT * FROM bar)", false, `[]`},
+		{"Correlated in", "SELECT id FROM foo WHERE 'second' IN (SELECT label FROM bar WHERE fooid = foo.id)", false, `[{"id":1}]`},
+		{"Correlated with aliases", "SELECT a.id FROM foo a WHERE EXISTS (SELECT * FROM bar b WHERE b.fooid = a.id AND b.label = 'third')", false, `[{"a.id":2}]`},
+		{"Nested", "SELECT id FROM foo WHERE EXISTS (SELECT * FROM bar WHERE fooid = foo.id AND k IN (SELECT id FROM foo WHERE name = 'y'))", false, `[{"id":1}]`},
+		{"Nested correlated", "SELECT id FROM foo WHERE EXISTS (SELECT * FROM bar WHERE EXISTS (SELECT * FROM foo f WHERE f.id = bar.k AND bar.fooid = foo.id AND f.name = 'z'))", false, `[{"id":2}]`},
+		{"Too many fields", "SELECT id FROM foo WHERE id IN (SELECT * FROM bar)", true, ``},
+		{"Qualified paths", "SELECT id FROM foo WHERE EXISTS (SELECT * FROM bar WHERE bar.fooid = foo.id)", false, `[{"id":1},{"id":2}]`},
+		{"Qualified and unqualified paths", "SELECT id FROM foo WHERE EXISTS (SELECT * FROM bar WHERE bar.fooid = foo.id AND label = 'third')", false, `[{"id":2}]`},
+		{"In with qualified paths", "SELECT id FROM foo WHERE id IN (SELECT fooid FROM bar WHERE bar.k > 1)", false, `[{"id":1},{"id":2}]`},
+		{"Scalar in projection", "SELECT name, (SELECT COUNT(*) FROM bar WHERE bar.fooid = foo.id) AS n FROM foo", false, `[{"name":"x","n":2},{"name":"y","n":1},{"name":"z","n":0}]`},
+		{"Scalar with unqualified aggregate", "SELECT name, (SELECT SUM(k) FROM bar WHERE bar.fooid = foo.id) AS n FROM foo WHERE id < 3", false, `[{"name":"x","n":3},{"name":"y","n":3}]`},
+		{"Scalar with unqualified outer path", "SELECT id, (SELECT label FROM bar WHERE k = id) AS l FROM foo", false, `[{"id":1,"l":"first"},{"id":2,"l":"second"},{"id":3,"l":"third"}]`},
+		{"Exists with unqualified outer path", "SELECT name FROM foo WHERE EXISTS (SELECT * FROM bar WHERE fooid = id)", false, `[{"name":"x"},{"name":"y"}]`},
+		{"In with unqualified outer path", "SELECT name FROM foo WHERE 'third' IN (SELECT label FROM bar WHERE fooid = id)", false, `[{"name":"y"}]`},
+		{"Scalar in where", "SELECT id FROM foo WHERE id = (SELECT fooid FROM bar WHERE k = 3)", false, `[{"id":2}]`},
+		{"Scalar in arithmetic", "SELECT id + (SELECT MAX(k) FROM bar) AS v FROM foo WHERE id = 1", false, `[{"v":4}]`},
+		{"Scalar without table", "SELECT (SELECT label FROM bar WHERE k = 2) AS l", false, `[{"l":"second"}]`},
+		{"Scalar without result", "SELECT (SELECT label FROM bar WHERE k = 10) AS l", false, `[{"l":null}]`},
+		{"Scalar with many documents", "SELECT id FROM foo WHERE id = (SELECT fooid FROM bar)", true, ``},
+		{"Correlated scalar with many documents", "SELECT (SELECT k FROM bar WHERE fooid = foo.id) FROM foo", true, ``},
+	}
+
+	for _, test := range tests {
+		testFn := func(withIndexes bool) func(t *testing.T) {
+			return func(t *testing.T) {
+				db, err := genji.Open(":memory:")
+				require.NoError(t, err)
+				defer db.Close()
+
+				err = db.Exec(`
+					CREATE TABLE foo (id INTEGER PRIMARY KEY);
+					CREATE TABLE bar (k INTEGER PRIMARY KEY);
+				`)
+				require.NoError(t, err)
+				if withIndexes {
+					err = db.Exec(`
+						CREATE INDEX idx_bar_fooid ON bar (fooid);
+						CREATE INDEX idx_foo_name ON foo (name);
+					`)
+					require.NoError(t, err)
+				}
+
+				err = db.Exec(`
+					INSERT INTO foo (id, name) VALUES (1, 'x'), (2, 'y'), (3, 'z');
+					INSERT INTO bar (k, fooid, label) VALUES (1, 1, 'first'), (2, 1, 'second'), (3, 2, 'third');
+				`)
+				require.NoError(t, err)
+
+				st, err := db.Query(test.query, 2)
+				if test.fails {
+					if err == nil {
+						defer st.Close()
+						err = testutil.IteratorToJSONArray(new(bytes.Buffer), st)
+					}
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			}
+		}
+		t.Run("No Index/"+test.name, testFn(false))
+		t.Run("With Index/"+test.name, testFn(true))
+	}
+
+	t.Run("Update and delete", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE foo (id INTEGER PRIMARY KEY);
+			CREATE TABLE bar (k INTEGER PRIMARY KEY);
+			INSERT INTO foo (id, name) VALUES (1, 'x'), (2, 'y'), (3, 'z');
+			INSERT INTO bar (k, fooid) VALUES (1, 1), (2, 2);
+			UPDATE foo SET name = 'updated' WHERE EXISTS (SELECT * FROM bar WHERE fooid = foo.id);
+			UPDATE foo SET total = (SELECT SUM(bar.k) FROM bar WHERE bar.fooid = foo.id);
+			UPDATE foo SET n = (SELECT SUM(k) FROM bar WHERE bar.fooid = foo.id);
+			DELETE FROM foo WHERE id NOT IN (SELECT fooid FROM bar WHERE k > 1);
+		`)
+		require.NoError(t, err)
+
+		st, err := db.Query("SELECT * FROM foo")
+		require.NoError(t, err)
+		defer st.Close()
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, st)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id":2,"name":"updated","total":2,"n":2}]`, buf.String())
+	})
+}
+
+func TestSelectSetOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Union", "SELECT a FROM foo UNION SELECT b FROM bar", false, `[{"a":1},{"a":2},{"a":3},{"a":4}]`},
+		{"Union all", "SELECT a FROM foo UNION ALL SELECT b FROM bar", false, `[{"a":1},{"a":2},{"a":3},{"a":2},{"a":2},{"a":3},{"a":4}]`},
+		{"Intersect", "SELECT a FROM foo INTERSECT SELECT b FROM bar", false, `[{"a":2},{"a":3}]`},
+		{"Except", "SELECT a FROM foo EXCEPT SELECT b FROM bar", false, `[{"a":1}]`},
+		{"Multiple fields", "SELECT a, c FROM foo UNION SELECT b, c FROM bar", false, `[{"a":1,"c":"x"},{"a":2,"c":"x"},{"a":3,"c":"y"},{"a":2,"c":"y"},{"a":4,"c":"z"}]`},
+		{"With filters", "SELECT a FROM foo WHERE a > 1 UNION ALL SELECT b FROM bar WHERE b < 3", false, `[{"a":2},{"a":3},{"a":2},{"a":2}]`},
+		{"Left to right", "SELECT a FROM foo UNION SELECT b FROM bar EXCEPT SELECT a FROM foo WHERE a < 3", false, `[{"a":3},{"a":4}]`},
+		{"Order by and limit", "SELECT a FROM foo UNION SELECT b FROM bar ORDER BY a DESC LIMIT 2", false, `[{"a":4},{"a":3}]`},
+		{"With params", "SELECT a FROM foo WHERE a = ? UNION SELECT b FROM bar WHERE b = ?", false, `[{"a":1},{"a":4}]`},
+		{"Without table", "SELECT 1 AS a UNION SELECT 1 UNION ALL SELECT 2", false, `[{"a":1},{"a":2}]`},
+		{"Empty left side", "SELECT a FROM foo WHERE a > 10 UNION SELECT b FROM bar WHERE b > 3", false, `[{"b":4}]`},
+		{"Aggregates", "SELECT COUNT(*) FROM foo UNION ALL SELECT COUNT(*) FROM bar", false, `[{"COUNT(*)":3},{"COUNT(*)":4}]`},
+		{"Subquery", "SELECT a FROM foo WHERE a IN (SELECT b FROM bar WHERE b < 3 UNION SELECT 3)", false, `[{"a":2},{"a":3}]`},
+		{"Different number of fields", "SELECT a FROM foo UNION SELECT b, c FROM bar", true, ``},
+		{"Different number of fields with empty left side", "SELECT a FROM foo WHERE a > 10 UNION SELECT b, c FROM bar", true, ``},
+		{"Different number of fields in intersect", "SELECT a, c FROM foo INTERSECT SELECT b FROM bar", true, ``},
+		{"Different number of fields in except", "SELECT a FROM foo EXCEPT SELECT b, c FROM bar", true, ``},
+		{"Missing select", "SELECT a FROM foo UNION", true, ``},
+		{"Order by before union", "SELECT a FROM foo ORDER BY a UNION SELECT b FROM bar", true, ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE foo;
+				CREATE TABLE bar;
+				INSERT INTO foo (a, c) VALUES (1, 'x'), (2, 'x'), (3, 'y');
+				INSERT INTO bar (b, c) VALUES (2, 'y'), (2, 'y'), (3, 'y'), (4, 'z');
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query, 1, 4)
+			if test.fails {
+				if err == nil {
+					defer st.Close()
+					err = testutil.IteratorToJSONArray(new(bytes.Buffer), st)
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Row number", "SELECT k, ROW_NUMBER() OVER (ORDER BY v DESC) AS n FROM foo", false, `[{"k":4,"n":1},{"k":2,"n":2},{"k":3,"n":3},{"k":1,"n":4},{"k":5,"n":5},{"k":6,"n":6}]`},
+		{"Partition", "SELECT k, ROW_NUMBER() OVER (PARTITION BY g ORDER BY v) AS n FROM foo", false, `[{"k":1,"n":1},{"k":2,"n":2},{"k":3,"n":3},{"k":4,"n":4},{"k":6,"n":1},{"k":5,"n":2}]`},
+		{"Rank", "SELECT k, RANK() OVER (PARTITION BY g ORDER BY v) AS r, DENSE_RANK() OVER (PARTITION BY g ORDER BY v) AS d FROM foo", false, `[{"k":1,"r":1,"d":1},{"k":2,"r":2,"d":2},{"k":3,"r":2,"d":2},{"k":4,"r":4,"d":3},{"k":6,"r":1,"d":1},{"k":5,"r":2,"d":2}]`},
+		{"Lag and lead", "SELECT k, LAG(v) OVER (PARTITION BY g ORDER BY k) AS l, LEAD(v, 2, 0) OVER (PARTITION BY g ORDER BY k) AS n FROM foo", false, `[{"k":1,"l":null,"n":20},{"k":2,"l":10,"n":40},{"k":3,"l":20,"n":0},{"k":4,"l":20,"n":0},{"k":5,"l":null,"n":0},{"k":6,"l":7,"n":0}]`},
+		{"First value", "SELECT k, FIRST_VALUE(k) OVER (PARTITION BY g ORDER BY v DESC) AS f FROM foo", false, `[{"k":4,"f":4},{"k":2,"f":4},{"k":3,"f":4},{"k":1,"f":4},{"k":5,"f":5},{"k":6,"f":5}]`},
+		{"Running sum", "SELECT k, SUM(v) OVER (PARTITION BY g ORDER BY v) AS s FROM foo", false, `[{"k":1,"s":10},{"k":2,"s":50},{"k":3,"s":50},{"k":4,"s":90},{"k":6,"s":5},{"k":5,"s":12}]`},
+		{"Rows frame", "SELECT k, SUM(v) OVER (ORDER BY k ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) AS s FROM foo", false, `[{"k":1,"s":30},{"k":2,"s":50},{"k":3,"s":80},{"k":4,"s":67},{"k":5,"s":52},{"k":6,"s":12}]`},
+		{"Large frame offsets", "SELECT k, SUM(v) OVER (ORDER BY k ROWS BETWEEN 9223372036854775807 PRECEDING AND 9223372036854775807 FOLLOWING) AS s FROM foo WHERE g = 1", false, `[{"k":1,"s":90},{"k":2,"s":90},{"k":3,"s":90},{"k":4,"s":90}]`},
+		{"Large lag offset", "SELECT k, LAG(v, 9223372036854775807, 0) OVER (ORDER BY k) AS l, LEAD(v, 9223372036854775807, 0) OVER (ORDER BY k) AS n FROM foo WHERE k < 3", false, `[{"k":1,"l":0,"n":0},{"k":2,"l":0,"n":0}]`},
+		{"Double lag offset", "SELECT LAG(v, 1.5) OVER (ORDER BY k) FROM foo", true, ``},
+		{"Whole partition", "SELECT k, COUNT(*) OVER (PARTITION BY g) AS c, AVG(v) OVER () AS a FROM foo WHERE k < 6", false, `[{"k":1,"c":4,"a":19.4},{"k":2,"c":4,"a":19.4},{"k":3,"c":4,"a":19.4},{"k":4,"c":4,"a":19.4},{"k":5,"c":1,"a":19.4}]`},
+		{"Empty frame", "SELECT k, MAX(v) OVER (ORDER BY k ROWS BETWEEN 2 FOLLOWING AND UNBOUNDED FOLLOWING) AS m FROM foo WHERE k > 3", false, `[{"k":4,"m":5},{"k":5,"m":null},{"k":6,"m":null}]`},
+		{"Expression", "SELECT k, v - FIRST_VALUE(v) OVER (ORDER BY k) AS d FROM foo WHERE g = 1", false, `[{"k":1,"d":0},{"k":2,"d":10},{"k":3,"d":10},{"k":4,"d":30}]`},
+		{"Empty table", "SELECT ROW_NUMBER() OVER () FROM foo WHERE k > 10", false, `[]`},
+		{"Missing over", "SELECT ROW_NUMBER() FROM foo", true, ``},
+		{"Not a window function", "SELECT pk() OVER () FROM foo", true, ``},
+		{"Invalid frame", "SELECT SUM(v) OVER (ORDER BY k ROWS BETWEEN CURRENT ROW AND 1 PRECEDING) FROM foo", true, ``},
+		{"Range offset", "SELECT SUM(v) OVER (ORDER BY k RANGE 1 PRECEDING) FROM foo", true, ``},
+		{"In where clause", "SELECT k FROM foo WHERE ROW_NUMBER() OVER () = 1", true, ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE foo;
+				INSERT INTO foo (k, g, v) VALUES (1, 1, 10), (2, 1, 20), (3, 1, 20), (4, 1, 40), (5, 2, 7), (6, 2, 5);
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query)
+			if test.fails {
+				if err == nil {
+					defer st.Close()
+					err = testutil.IteratorToJSONArray(new(bytes.Buffer), st)
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectHaving(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Projected aggregate", "SELECT g, COUNT(*) FROM foo GROUP BY g HAVING COUNT(*) > 1", false, `[{"g":1,"COUNT(*)":3}]`},
+		{"Aggregate not projected", "SELECT g FROM foo GROUP BY g HAVING SUM(v) < 60", false, `[{"g":2},{"g":3}]`},
+		{"Group key", "SELECT g, MAX(v) FROM foo GROUP BY g HAVING g >= 2", false, `[{"g":2,"MAX(v)":7},{"g":3,"MAX(v)":50}]`},
+		{"Multiple conditions", "SELECT g, AVG(v) AS a FROM foo GROUP BY g HAVING COUNT(*) = 1 AND MIN(v) > 10", false, `[{"g":3,"a":50.0}]`},
+		{"With where", "SELECT g FROM foo WHERE v > 5 GROUP BY g HAVING COUNT(v) = 1", false, `[{"g":2},{"g":3}]`},
+		{"Without group by", "SELECT COUNT(*) FROM foo HAVING SUM(v) > 100", false, `[{"COUNT(*)":5}]`},
+		{"Without group by, no match", "SELECT COUNT(*) FROM foo HAVING SUM(v) > 1000", false, `[]`},
+		{"With index", "SELECT g FROM foo GROUP BY g HAVING g = 2", false, `[{"g":2}]`},
+		{"No match", "SELECT g FROM foo GROUP BY g HAVING COUNT(*) > 10", false, `[]`},
+		{"Ungrouped field", "SELECT g FROM foo GROUP BY g HAVING v > 1", true, ``},
+		{"Ungrouped projection", "SELECT v FROM foo HAVING COUNT(*) > 1", true, ``},
+		{"Missing expression", "SELECT g FROM foo GROUP BY g HAVING", true, ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE foo;
+				CREATE INDEX foo_g ON foo(g);
+				INSERT INTO foo (g, v) VALUES (1, 10), (1, 20), (2, 7), (1, 40), (3, 50);
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query)
+			if test.fails {
+				if err == nil {
+					defer st.Close()
+					err = testutil.IteratorToJSONArray(new(bytes.Buffer), st)
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectGroupBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Multiple fields", "SELECT country, city, COUNT(*) FROM foo GROUP BY country, city", false, `[{"country":"fr","city":"lyon","COUNT(*)":2},{"country":"fr","city":"paris","COUNT(*)":1},{"country":"us","city":"paris","COUNT(*)":1}]`},
+		{"Nested field", "SELECT country, info.zip, SUM(n) FROM foo GROUP BY country, info.zip", false, `[{"country":"fr","info.zip":69,"SUM(n)":3},{"country":"fr","info.zip":75,"SUM(n)":3},{"country":"us","info.zip":75,"SUM(n)":4}]`},
+		{"Subset projected", "SELECT city FROM foo GROUP BY country, city", false, `[{"city":"lyon"},{"city":"paris"},{"city":"paris"}]`},
+		{"With having", "SELECT country, city FROM foo GROUP BY country, city HAVING city = 'paris' AND COUNT(*) = 1", false, `[{"country":"fr","city":"paris"},{"country":"us","city":"paris"}]`},
+		{"With order by", "SELECT country, city, MAX(n) FROM foo GROUP BY country, city ORDER BY MAX(n) DESC", false, `[{"country":"us","city":"paris","MAX(n)":4},{"country":"fr","city":"paris","MAX(n)":3},{"country":"fr","city":"lyon","MAX(n)":2}]`},
+		{"Ungrouped field", "SELECT country, n FROM foo GROUP BY country, city", true, ``},
+		{"Missing expression", "SELECT country FROM foo GROUP BY country,", true, ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE foo;
+				INSERT INTO foo (country, city, info, n) VALUES
+					('fr', 'lyon', {zip: 69}, 1),
+					('fr', 'lyon', {zip: 69}, 2),
+					('fr', 'paris', {zip: 75}, 3),
+					('us', 'paris', {zip: 75}, 4);
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query)
+			if test.fails {
+				if err == nil {
+					defer st.Close()
+					err = testutil.IteratorToJSONArray(new(bytes.Buffer), st)
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Searched", "SELECT k, CASE WHEN a >= 10 THEN 'big' WHEN a >= 5 THEN 'medium' ELSE 'small' END AS size FROM foo", false, `[{"k":1,"size":"small"},{"k":2,"size":"medium"},{"k":3,"size":"big"},{"k":4,"size":"big"}]`},
+		{"Simple", "SELECT k, CASE b WHEN 'x' THEN 1 WHEN 'y' THEN 2 END AS n FROM foo", false, `[{"k":1,"n":1},{"k":2,"n":2},{"k":3,"n":1},{"k":4,"n":null}]`},
+		{"Where", "SELECT k FROM foo WHERE CASE b WHEN 'x' THEN a > 1 ELSE true END", false, `[{"k":2},{"k":3},{"k":4}]`},
+		{"Where constant", "SELECT k FROM foo WHERE k = CASE WHEN 1 > 2 THEN 1 ELSE 3 END", false, `[{"k":3}]`},
+		{"Order by", "SELECT k FROM foo ORDER BY CASE WHEN b = 'y' THEN 0 ELSE 1 END, k DESC", false, `[{"k":2},{"k":4},{"k":3},{"k":1}]`},
+		{"Group by", "SELECT CASE WHEN a >= 5 THEN 'big' ELSE 'small' END AS size, COUNT(*) FROM foo GROUP BY CASE WHEN a >= 5 THEN 'big' ELSE 'small' END", false, `[{"size":"small","COUNT(*)":1},{"size":"big","COUNT(*)":3}]`},
+		{"Group by with having", "SELECT CASE b WHEN 'x' THEN 'x' ELSE 'other' END AS c, SUM(a) FROM foo GROUP BY CASE b WHEN 'x' THEN 'x' ELSE 'other' END HAVING CASE b WHEN 'x' THEN 'x' ELSE 'other' END = 'other'", false, `[{"c":"other","SUM(a)":25}]`},
+		{"Missing END", "SELECT CASE WHEN a > 1 THEN 1 FROM foo", true, ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE foo (k INTEGER PRIMARY KEY, a INTEGER);
+				INSERT INTO foo (k, a, b) VALUES (1, 1, 'x'), (2, 5, 'y'), (3, 10, 'x');
+				INSERT INTO foo (k, a) VALUES (4, 20);
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query)
+			if test.fails {
+				if err == nil {
+					defer st.Close()
+					err = testutil.IteratorToJSONArray(new(bytes.Buffer), st)
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectOrderBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Multiple keys", "SELECT k FROM foo ORDER BY a DESC, b, k DESC", false, `[{"k":4},{"k":3},{"k":5},{"k":2},{"k":1}]`},
+		{"Expression", "SELECT k FROM foo ORDER BY a * -1, k", false, `[{"k":3},{"k":4},{"k":5},{"k":1},{"k":2}]`},
+		{"Function", "SELECT k, b FROM foo ORDER BY pk() DESC LIMIT 2", false, `[{"k":5,"b":"y"},{"k":4,"b":"x"}]`},
+		{"Alias", "SELECT k, a + k AS s FROM foo ORDER BY s DESC, k", false, `[{"k":5,"s":7},{"k":4,"s":6},{"k":3,"s":5},{"k":2,"s":3},{"k":1,"s":2}]`},
+		{"Field not projected", "SELECT k FROM foo ORDER BY b DESC, a + 1 DESC, k", false, `[{"k":5},{"k":1},{"k":3},{"k":4},{"k":2}]`},
+		{"Limit and offset", "SELECT k FROM foo ORDER BY a, k DESC LIMIT 2 OFFSET 1", false, `[{"k":1},{"k":5}]`},
+		{"Aggregate", "SELECT b FROM foo GROUP BY b ORDER BY COUNT(*) DESC, b", false, `[{"b":"x"},{"b":"y"}]`},
+		{"Projected aggregate", "SELECT b, SUM(a) AS s FROM foo GROUP BY b ORDER BY SUM(a), b DESC", false, `[{"b":"y","s":3},{"b":"x","s":5}]`},
+		{"Union", "SELECT a FROM foo UNION SELECT a + 1 FROM foo ORDER BY a DESC, a", false, `[{"a":3},{"a":2},{"a":1}]`},
+		{"Missing expression", "SELECT k FROM foo ORDER BY a,", true, ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE foo (k INTEGER PRIMARY KEY);
+				INSERT INTO foo (k, a, b) VALUES (1, 1, 'y'), (2, 1, 'x'), (3, 2, 'x'), (4, 2, 'x'), (5, 2, 'y');
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query)
+			if test.fails {
+				if err == nil {
+					defer st.Close()
+					err = testutil.IteratorToJSONArray(new(bytes.Buffer), st)
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectTimestamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Order by", "SELECT k FROM foo ORDER BY t DESC", false, `[{"k":2},{"k":3},{"k":1}]`},
+		{"Where", "SELECT k FROM foo WHERE t > CAST('2021-03-04 12:00:00' AS TIMESTAMP)", false, `[{"k":2},{"k":3}]`},
+		{"Where text", "SELECT k FROM foo WHERE t >= '2021-03-04T12:00:00Z'", false, `[{"k":2},{"k":3}]`},
+		{"Where interval", "SELECT k FROM foo WHERE t + INTERVAL '12 hours' < CAST('2021-03-05' AS TIMESTAMP)", false, `[{"k":1}]`},
+		{"Cast", "SELECT CAST(t AS TEXT) AS t, CAST(t AS INTEGER) AS i FROM foo WHERE k = 1", false, `[{"t":"2021-03-04T05:06:07Z","i":1614834367}]`},
+		{"Extract", "SELECT EXTRACT(DAY FROM t) AS d, EXTRACT(HOUR FROM t) AS h FROM foo ORDER BY k", false, `[{"d":4,"h":5},{"d":5,"h":0},{"d":4,"h":22}]`},
+		{"Date trunc", "SELECT DATE_TRUNC('day', t) AS d, COUNT(*) AS c FROM foo GROUP BY DATE_TRUNC('day', t)", false, `[{"d":"2021-03-04T00:00:00Z","c":2},{"d":"2021-03-05T00:00:00Z","c":1}]`},
+		{"Difference", "SELECT (t - CAST('2021-03-04' AS TIMESTAMP)) / 1000000000 AS s FROM foo WHERE k = 1", false, `[{"s":18367}]`},
+		{"Now", "SELECT k FROM foo WHERE t < NOW() ORDER BY k", false, `[{"k":1},{"k":2},{"k":3}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE foo (k INTEGER PRIMARY KEY, t TIMESTAMP);
+				CREATE INDEX idx_foo_t ON foo(t);
+				INSERT INTO foo (k, t) VALUES (1, '2021-03-04T05:06:07Z'), (2, '2021-03-05'), (3, '2021-03-04T22:00:00Z');
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query)
+			if test.fails {
+				if err == nil {
+					defer st.Close()
+					err = testutil.IteratorToJSONArray(new(bytes.Buffer), st)
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("Invalid timestamp", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE foo (t TIMESTAMP); INSERT INTO foo (t) VALUES ('foo')")
+		require.Error(t, err)
+	})
+}
+
+func TestSelectArray(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		params   []interface{}
+		expected string
+	}{
+		{"Functions", "SELECT ARRAY_LENGTH(tags) AS l, ARRAY_SORT(ARRAY_APPEND(tags, 'z')) AS s FROM foo WHERE k = 1", nil, `[{"l":2,"s":["a","b","z"]}]`},
+		{"Contains", "SELECT k FROM foo WHERE ARRAY_CONTAINS(tags, 'c')", nil, `[{"k":2},{"k":3}]`},
+		{"Distinct", "SELECT ARRAY_DISTINCT(ARRAY_CONCAT(tags, ['a', 'c'])) AS t FROM foo WHERE k = 2", nil, `[{"t":["c","d","a"]}]`},
+		{"Any", "SELECT k FROM foo WHERE a = ANY([1, 3])", nil, `[{"k":1},{"k":3}]`},
+		{"Any with param", "SELECT k FROM foo WHERE a = ANY(?)", []interface{}{[]int{2, 3}}, `[{"k":2},{"k":3}]`},
+		{"Any with path", "SELECT k FROM foo WHERE 'd' = ANY(tags)", nil, `[{"k":2}]`},
+		{"All", "SELECT k FROM foo WHERE a > ALL(?)", []interface{}{[]int{1, 2}}, `[{"k":3}]`},
+		{"Any with subquery", "SELECT k FROM foo WHERE a > ANY(SELECT a FROM foo WHERE k > 1)", nil, `[{"k":3}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE foo (k INTEGER PRIMARY KEY, a INTEGER);
+				CREATE INDEX idx_foo_a ON foo(a);
+				INSERT INTO foo (k, a, tags) VALUES (1, 1, ['b', 'a']), (2, 2, ['c', 'd']), (3, 3, ['c']);
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query, test.params...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSelectWildcard(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		params   []interface{}
+		expected string
+	}{
+		{"Project", "SELECT k, items[*].sku AS skus FROM orders", nil, `[{"k":1,"skus":["x","y"]},{"k":2,"skus":["z"]},{"k":3,"skus":[]}]`},
+		{"Equal", "SELECT k FROM orders WHERE items[*].sku = 'x'", nil, `[{"k":1}]`},
+		{"Greater", "SELECT k FROM orders WHERE items[*].qty > 2", nil, `[{"k":2}]`},
+		{"Param", "SELECT k FROM orders WHERE ? = items[*].sku", []interface{}{"z"}, `[{"k":2}]`},
+		{"Not found", "SELECT k FROM orders WHERE items[*].sku = 'w'", nil, `[]`},
+		{"In", "SELECT k FROM orders WHERE items[*].sku IN ['y', 'z']", nil, `[{"k":1},{"k":2}]`},
+		{"Not in", "SELECT k FROM orders WHERE items[*].sku NOT IN ['x']", nil, `[{"k":1},{"k":2}]`},
+		{"Like", "SELECT k FROM orders WHERE items[*].sku LIKE 'y%'", nil, `[{"k":1}]`},
+		{"Regexp", "SELECT k FROM orders WHERE items[*].sku =~ '^[xz]$'", nil, `[{"k":1},{"k":2}]`},
+		{"Between", "SELECT k FROM orders WHERE items[*].qty BETWEEN 2 AND 5", nil, `[{"k":1},{"k":2}]`},
+		{"Update", "UPDATE orders SET done = true WHERE items[*].sku = 'y'; SELECT k FROM orders WHERE done", nil, `[{"k":1}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE orders (k INTEGER PRIMARY KEY);
+				INSERT INTO orders (k, items) VALUES
+					(1, [{sku: 'x', qty: 1}, {sku: 'y', qty: 2}]),
+					(2, [{sku: 'z', qty: 3}]),
+					(3, []);
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query, test.params...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+}
@@ -32,6 +32,7 @@ func TestUpdateStmt(t *testing.T) {
 		{"SET / With cond / with missing field", "UPDATE test SET f = 'boo' WHERE d = 'bar3'", false, `[{"a":"foo1","b":"bar1","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3","f":"boo"}]`, nil},
 		{"SET / Field not found", "UPDATE test SET a = 1, b = 2 WHERE a = f", false, `[{"a":"foo1","b":"bar1","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, nil},
 		{"SET / Positional params", "UPDATE test SET a = ?, b = ? WHERE a = ?", false, `[{"a":"a","b":"b","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, []interface{}{"a", "b", "foo1"}},
+		{"SET / With case", "UPDATE test SET b = CASE a WHEN 'foo1' THEN 1 WHEN 'foo2' THEN 2 ELSE b END", false, `[{"a":"foo1","b":1,"c":"baz1"},{"a":"foo2","b":2},{"a":"foo3","b":null,"d":"bar3","e":"baz3"}]`, nil},
 		{"SET / Named params", "UPDATE test SET a = $a, b = $b WHERE a = $c", false, `[{"a":"a","b":"b","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, []interface{}{sql.Named("b", "b"), sql.Named("a", "a"), sql.Named("c", "foo1")}},
 
 		// UNSET tests.
@@ -40,6 +41,14 @@ func TestUpdateStmt(t *testing.T) {
 		{"UNSET / No cond / with missing field", "UPDATE test UNSET f", false, `[{"a":"foo1","b":"bar1","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, nil},
 		{"UNSET / No cond / with string", `UPDATE test UNSET 'a'`, true, "", nil},
 		{"UNSET / With cond", `UPDATE test UNSET b WHERE a = 'foo2'`, false, `[{"a":"foo1","b":"bar1","c":"baz1"},{"a":"foo2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, nil},
+
+		// PATCH tests.
+		{"PATCH / No cond", `UPDATE test PATCH {b: NULL, f: 'boo'}`, false, `[{"a":"foo1","c":"baz1","f":"boo"},{"a":"foo2","f":"boo"},{"a":"foo3","d":"bar3","e":"baz3","f":"boo"}]`, nil},
+		{"PATCH / With cond", `UPDATE test PATCH {c: {x: 1, y: NULL}} WHERE a = 'foo1'`, false, `[{"a":"foo1","b":"bar1","c":{"x":1}},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"baz3"}]`, nil},
+		{"PATCH / With path", `UPDATE test PATCH {e: a} WHERE d = 'bar3'`, false, `[{"a":"foo1","b":"bar1","c":"baz1"},{"a":"foo2","b":"bar2"},{"a":"foo3","d":"bar3","e":"foo3"}]`, nil},
+		{"PATCH / Params", `UPDATE test PATCH ? WHERE a = ?`, false, `[{"a":"foo1","b":"bar1","c":"baz1"},{"a":"foo2","b":2},{"a":"foo3","d":"bar3","e":"baz3"}]`, []interface{}{map[string]interface{}{"b": 2}, "foo2"}},
+		{"PATCH / Not null constraint", `UPDATE test PATCH {a: NULL}`, true, "", nil},
+		{"PATCH / Not a document", `UPDATE test PATCH 1`, true, "", nil},
 	}
 
 	for _, test := range tests {
@@ -136,4 +145,53 @@ func TestUpdateStmt(t *testing.T) {
 			})
 		}
 	})
+	t.Run("with RETURNING", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE foo; INSERT INTO foo (a) VALUES (1), (2), (3)`)
+		require.NoError(t, err)
+
+		st, err := db.Query(`UPDATE foo SET a = a * 10 WHERE a > 1 RETURNING *, pk()`)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, st)
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+		require.JSONEq(t, `[{"a": 20, "pk()": 2}, {"a": 30, "pk()": 3}]`, buf.String())
+
+		d, err := db.QueryDocument(`SELECT SUM(a) AS s FROM foo`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"s": 51}`)
+	})
+
+	t.Run("with RETURNING *", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE foo; INSERT INTO foo (a) VALUES (1), (2)`)
+		require.NoError(t, err)
+
+		st, err := db.Query(`UPDATE foo SET b = 'x' WHERE a = 2 RETURNING *`)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, st)
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+		require.JSONEq(t, `[{"a": 2, "b": "x"}]`, buf.String())
+
+		// without RETURNING, nothing is returned
+		st, err = db.Query(`UPDATE foo SET b = 'y'`)
+		require.NoError(t, err)
+
+		buf.Reset()
+		err = testutil.IteratorToJSONArray(&buf, st)
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+		require.JSONEq(t, `[]`, buf.String())
+	})
 }
@@ -0,0 +1,97 @@
+package query_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tie/genji-release-test"
+	"github.com/tie/genji-release-test/sql/parser"
+	"github.com/tie/genji-release-test/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStmt(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		fails    bool
+		expected string
+	}{
+		{"Simple", "WITH t AS (SELECT id FROM cat WHERE id > 3) SELECT * FROM t", false, `[{"id":4},{"id":5}]`},
+		{"Projection", "WITH t AS (SELECT id, name FROM cat) SELECT name FROM t WHERE id < 3", false, `[{"name":"root"},{"name":"a"}]`},
+		{"Multiple", "WITH a AS (SELECT id FROM cat WHERE id < 3), b AS (SELECT id + 1 AS id FROM a) SELECT * FROM b", false, `[{"id":2},{"id":3}]`},
+		{"Shadowing a table", "WITH cat AS (SELECT 1 AS id) SELECT * FROM cat", false, `[{"id":1}]`},
+		{"Join", "WITH p AS (SELECT id, name FROM cat WHERE id <= 2) SELECT c.name, p.name FROM cat c JOIN p ON c.parent_id = p.id", false, `[{"c.name":"a","p.name":"root"},{"c.name":"b","p.name":"root"},{"c.name":"c","p.name":"a"}]`},
+		{"Subquery", "WITH p AS (SELECT parent_id FROM cat) SELECT id FROM cat WHERE id NOT IN (SELECT parent_id FROM p WHERE parent_id IS NOT NULL)", false, `[{"id":3},{"id":5}]`},
+		{"Aggregate", "WITH t AS (SELECT id FROM cat) SELECT COUNT(*) FROM t", false, `[{"COUNT(*)":5}]`},
+		{"Recursive counter", "WITH RECURSIVE n AS (SELECT 1 AS x UNION ALL SELECT x + 1 FROM n WHERE x < 5) SELECT * FROM n", false, `[{"x":1},{"x":2},{"x":3},{"x":4},{"x":5}]`},
+		{"Recursive tree", "WITH RECURSIVE tree AS (SELECT id, name FROM cat WHERE id = 2 UNION ALL SELECT c.id, c.name FROM cat c JOIN tree t ON c.parent_id = t.id) SELECT name FROM tree", false, `[{"name":"a"},{"name":"c"},{"name":"d"}]`},
+		{"Recursive ancestors", "WITH RECURSIVE up AS (SELECT id, parent_id FROM cat WHERE id = 5 UNION SELECT c.id, c.parent_id FROM cat c, up u WHERE c.id = u.parent_id) SELECT id FROM up ORDER BY id", false, `[{"id":1},{"id":2},{"id":4},{"id":5}]`},
+		{"Recursive with limit", "WITH RECURSIVE n AS (SELECT 1 AS x UNION ALL SELECT x + 1 FROM n) SELECT * FROM n LIMIT 3", false, `[{"x":1},{"x":2},{"x":3}]`},
+		{"Recursive union stops on cycles", "WITH RECURSIVE n AS (SELECT 1 AS x UNION SELECT x FROM n) SELECT * FROM n", false, `[{"x":1}]`},
+		{"Recursive union all with a cycle", "WITH RECURSIVE n AS (SELECT 1 AS x UNION ALL SELECT x FROM n) SELECT * FROM n", true, ``},
+		{"Recursive without union", "WITH RECURSIVE n AS (SELECT x FROM n) SELECT * FROM n", true, ``},
+		{"Recursive with order by", "WITH RECURSIVE n AS (SELECT 1 AS x UNION ALL SELECT x + 1 FROM n WHERE x < 5 ORDER BY x) SELECT * FROM n", true, ``},
+		{"Not recursive", "WITH n AS (SELECT 1 AS x UNION ALL SELECT x + 1 FROM n) SELECT * FROM n", true, ``},
+		{"Missing select", "WITH t AS (SELECT 1)", true, ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE cat (id INTEGER PRIMARY KEY);
+				INSERT INTO cat (id, parent_id, name) VALUES (1, NULL, 'root'), (2, 1, 'a'), (3, 1, 'b'), (4, 2, 'c'), (5, 4, 'd');
+			`)
+			require.NoError(t, err)
+
+			st, err := db.Query(test.query)
+			if test.fails {
+				if err == nil {
+					defer st.Close()
+					err = testutil.IteratorToJSONArray(new(bytes.Buffer), st)
+				}
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("Limits", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		run := func(q string, opts *parser.Options) error {
+			pq, err := parser.NewParserWithOptions(strings.NewReader(q), opts).ParseQuery()
+			require.NoError(t, err)
+
+			return db.Update(func(tx *genji.Tx) error {
+				res, err := pq.Exec(tx.Transaction, nil)
+				if err != nil {
+					return err
+				}
+				defer res.Close()
+
+				return testutil.IteratorToJSONArray(new(bytes.Buffer), res)
+			})
+		}
+
+		q := "WITH RECURSIVE n AS (SELECT 1 AS x UNION ALL SELECT x + 1 FROM n WHERE x < 100) SELECT * FROM n"
+		require.NoError(t, run(q, nil))
+		require.Error(t, run(q, &parser.Options{MaxRecursionDepth: 10}))
+		require.Error(t, run(q, &parser.Options{MaxRecursionRows: 50}))
+		require.NoError(t, run(q, &parser.Options{MaxRecursionDepth: 100, MaxRecursionRows: 100}))
+	})
+}
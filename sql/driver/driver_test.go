@@ -281,4 +281,12 @@ func TestDriverWithTimeValues(t *testing.T) {
 	err = tx.QueryRow(`SELECT a FROM test`).Scan(Scanner(&tt))
 	require.NoError(t, err)
 	require.Equal(t, now, tt)
+
+	// timestamps are returned as time.Time
+	var typ string
+	tt = time.Time{}
+	err = tx.QueryRow(`SELECT a, typeof(a) FROM test`).Scan(&tt, &typ)
+	require.NoError(t, err)
+	require.Equal(t, now, tt)
+	require.Equal(t, "timestamp", typ)
 }
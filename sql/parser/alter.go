@@ -33,7 +33,7 @@ func (p *Parser) parseAlterTableAddFieldStatement(tableName string) (_ query.Alt
 	}
 
 	// Parse new field definition.
-	err = p.parseFieldDefinition(&stmt.Constraint)
+	err = p.parseFieldDefinition(&stmt.Constraint, nil)
 	if err != nil {
 		return stmt, err
 	}
@@ -42,6 +42,10 @@ func (p *Parser) parseAlterTableAddFieldStatement(tableName string) (_ query.Alt
 		return stmt, &ParseError{Message: "cannot add a PRIMARY KEY constraint"}
 	}
 
+	if stmt.Constraint.IsGenerated() {
+		return stmt, &ParseError{Message: "cannot add a generated field"}
+	}
+
 	return stmt, nil
 }
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/tie/genji-release-test/database"
 	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/query"
 	"github.com/tie/genji-release-test/sql/parser"
 	"github.com/tie/genji-release-test/testutil"
@@ -66,7 +67,7 @@ func TestParserAlterTableAddField(t *testing.T) {
 				Path:         document.Path(testutil.ParsePath(t, "bar")),
 				Type:         document.IntegerValue,
 				IsNotNull:    true,
-				DefaultValue: document.NewIntegerValue(0),
+				DefaultValue: expr.Constraint(parser.MustParseExpr("0")),
 			},
 		}, false},
 		{"With error / missing FIELD keyword", "ALTER TABLE foo ADD bar", nil, true},
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"github.com/tie/genji-release-test/database"
+	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/query"
 	"github.com/tie/genji-release-test/sql/scanner"
@@ -55,8 +56,10 @@ func (p *Parser) parseCreateTableStatement() (query.CreateTableStmt, error) {
 	return stmt, nil
 }
 
-func (p *Parser) parseFieldDefinition(fc *database.FieldConstraint) (err error) {
-	fc.Path, err = p.parsePath()
+// parseFieldDefinition parses a field path, its optional type and its constraints.
+// CHECK and FOREIGN KEY constraints are added to tcs. If tcs is nil, they are not allowed.
+func (p *Parser) parseFieldDefinition(fc *database.FieldConstraint, tcs *database.TableConstraints) (err error) {
+	fc.Path, err = p.parseFieldPath()
 	if err != nil {
 		return err
 	}
@@ -66,12 +69,19 @@ func (p *Parser) parseFieldDefinition(fc *database.FieldConstraint) (err error)
 		p.Unscan()
 	}
 
-	err = p.parseFieldConstraint(fc)
+	var n int
+	if tcs != nil {
+		n = len(*tcs)
+	}
+
+	err = p.parseFieldConstraint(fc, tcs)
 	if err != nil {
 		return err
 	}
 
-	if fc.Type.IsAny() && fc.DefaultValue.Type.IsAny() && !fc.IsNotNull && !fc.IsPrimaryKey && !fc.IsUnique {
+	hasTableConstraint := tcs != nil && len(*tcs) > n
+
+	if fc.Type.IsAny() && !fc.HasDefaultValue() && !fc.IsGenerated() && !fc.IsNotNull && !fc.IsPrimaryKey && !fc.IsUnique && !hasTableConstraint {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
 		return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", "TYPE"}, pos)
 	}
@@ -114,7 +124,7 @@ func (p *Parser) parseConstraints(stmt *query.CreateTableStmt) error {
 		if !parsingTableConstraints {
 			var fc database.FieldConstraint
 
-			err = p.parseFieldDefinition(&fc)
+			err = p.parseFieldDefinition(&fc, &stmt.Info.TableConstraints)
 			if err != nil {
 				return err
 			}
@@ -145,13 +155,71 @@ func (p *Parser) parseConstraints(stmt *query.CreateTableStmt) error {
 		}
 	}
 
+	return checkGeneratedFields(stmt.Info.FieldConstraints)
+}
+
+// checkGeneratedFields ensures generated fields can be computed in the order
+// they are declared: their expression can only refer to regular fields
+// and to the generated fields declared before them.
+func checkGeneratedFields(fcs database.FieldConstraints) error {
+	for i, fc := range fcs {
+		if !fc.IsGenerated() {
+			continue
+		}
+
+		var err error
+		expr.Walk(fc.GeneratedExpr.(*expr.ConstraintExpr).Expr, func(e expr.Expr) bool {
+			p, ok := e.(expr.Path)
+			if !ok {
+				return true
+			}
+
+			for _, other := range fcs[i:] {
+				if other.IsGenerated() && pathsOverlap(document.Path(p), other.Path) {
+					if other == fc {
+						err = stringutil.Errorf("generated field %q cannot refer to itself", fc.Path)
+					} else {
+						err = stringutil.Errorf("generated field %q cannot refer to generated field %q declared after it", fc.Path, other.Path)
+					}
+					return false
+				}
+			}
+
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
+// pathsOverlap returns true if one of the paths is a prefix of the other.
+func pathsOverlap(a, b document.Path) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	return b[:len(a)].IsEqual(a)
+}
+
+func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint, tcs *database.TableConstraints) error {
 	for {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
 		switch tok {
+		case scanner.CONSTRAINT, scanner.CHECK, scanner.REFERENCES:
+			p.Unscan()
+			if tcs == nil {
+				return nil
+			}
+
+			tc, err := p.parseNamedConstraint(fc.Path)
+			if err != nil {
+				return err
+			}
+
+			*tcs = append(*tcs, tc)
 		case scanner.PRIMARY:
 			// Parse "KEY"
 			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.KEY {
@@ -178,22 +246,53 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 			fc.IsNotNull = true
 		case scanner.DEFAULT:
 			// Parse default value expression.
+			// It is evaluated every time a document is inserted,
+			// so it can't depend on other documents or on parameters.
 			e, err := p.parseUnaryExpr()
 			if err != nil {
 				return err
 			}
 
-			d, err := e.Eval(&expr.Environment{})
+			if hasSubquery(e) {
+				return &ParseError{Message: "cannot use subquery in DEFAULT expression", Pos: pos}
+			}
+			if hasParam(e) {
+				return &ParseError{Message: "cannot use parameter in DEFAULT expression", Pos: pos}
+			}
+
+			// if it has already a default value or is generated we return an error
+			if fc.HasDefaultValue() || fc.IsGenerated() {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			fc.DefaultValue = expr.Constraint(e)
+		case scanner.AS:
+			// if it has already a default value or is generated we return an error
+			if fc.HasDefaultValue() || fc.IsGenerated() {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			// Parse "(" expr ") STORED"
+			err := p.parseTokens(scanner.LPAREN)
+			if err != nil {
+				return err
+			}
+
+			e, _, err := p.ParseExpr()
 			if err != nil {
 				return err
 			}
 
-			// if it has already a default value we return an error
-			if fc.HasDefaultValue() {
-				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			err = p.parseTokens(scanner.RPAREN, scanner.STORED)
+			if err != nil {
+				return err
 			}
 
-			fc.DefaultValue = d
+			if hasParam(e) {
+				return &ParseError{Message: "cannot use parameter in generated field expression", Pos: pos}
+			}
+
+			fc.GeneratedExpr = expr.Constraint(e)
 		case scanner.UNIQUE:
 			// if it's already unique we return an error
 			if fc.IsUnique {
@@ -213,6 +312,15 @@ func (p *Parser) parseTableConstraint(stmt *query.CreateTableStmt) (bool, error)
 
 	tok, _, _ := p.ScanIgnoreWhitespace()
 	switch tok {
+	case scanner.CONSTRAINT, scanner.CHECK, scanner.FOREIGN:
+		p.Unscan()
+		tc, err := p.parseNamedConstraint(nil)
+		if err != nil {
+			return false, err
+		}
+
+		stmt.Info.TableConstraints = append(stmt.Info.TableConstraints, tc)
+		return true, nil
 	case scanner.PRIMARY:
 		// Parse "KEY ("
 		err = p.parseTokens(scanner.KEY, scanner.LPAREN)
@@ -220,7 +328,7 @@ func (p *Parser) parseTableConstraint(stmt *query.CreateTableStmt) (bool, error)
 			return false, err
 		}
 
-		primaryKeyPath, err := p.parsePath()
+		primaryKeyPath, err := p.parseFieldPath()
 		if err != nil {
 			return false, err
 		}
@@ -255,7 +363,7 @@ func (p *Parser) parseTableConstraint(stmt *query.CreateTableStmt) (bool, error)
 			return false, err
 		}
 
-		uniquePath, err := p.parsePath()
+		uniquePath, err := p.parseFieldPath()
 		if err != nil {
 			return false, err
 		}
@@ -286,6 +394,154 @@ func (p *Parser) parseTableConstraint(stmt *query.CreateTableStmt) (bool, error)
 	}
 }
 
+// parseNamedConstraint parses a CHECK or a FOREIGN KEY constraint,
+// optionally named using the CONSTRAINT keyword.
+// If path is not nil, the constraint is declared on that field:
+//   [CONSTRAINT name] CHECK (expr)
+//   [CONSTRAINT name] REFERENCES table [(path)] [ON DELETE action]
+// Otherwise, it is a table constraint:
+//   [CONSTRAINT name] CHECK (expr)
+//   [CONSTRAINT name] FOREIGN KEY (path) REFERENCES table [(path)] [ON DELETE action]
+func (p *Parser) parseNamedConstraint(path document.Path) (*database.TableConstraint, error) {
+	tc := database.TableConstraint{
+		Path: path,
+	}
+	var err error
+
+	// Parse optional "CONSTRAINT name"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.CONSTRAINT {
+		tc.Name, err = p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.CHECK:
+		// Parse "("
+		err = p.parseTokens(scanner.LPAREN)
+		if err != nil {
+			return nil, err
+		}
+
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse ")"
+		err = p.parseTokens(scanner.RPAREN)
+		if err != nil {
+			return nil, err
+		}
+
+		// the check is evaluated every time a document is inserted,
+		// when no parameters are available.
+		if hasParam(e) {
+			return nil, &ParseError{Message: "cannot use parameter in CHECK expression", Pos: pos}
+		}
+
+		tc.Check = expr.Constraint(e)
+	case tok == scanner.REFERENCES && path != nil:
+		p.Unscan()
+		tc.ForeignKey, err = p.parseReferences()
+		if err != nil {
+			return nil, err
+		}
+	case tok == scanner.FOREIGN && path == nil:
+		// Parse "KEY ("
+		err = p.parseTokens(scanner.KEY, scanner.LPAREN)
+		if err != nil {
+			return nil, err
+		}
+
+		tc.Path, err = p.parseFieldPath()
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse ")"
+		err = p.parseTokens(scanner.RPAREN)
+		if err != nil {
+			return nil, err
+		}
+
+		tc.ForeignKey, err = p.parseReferences()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		expected := []string{"CHECK", "FOREIGN KEY"}
+		if path != nil {
+			expected = []string{"CHECK", "REFERENCES"}
+		}
+		return nil, newParseError(scanner.Tokstr(tok, lit), expected, pos)
+	}
+
+	return &tc, nil
+}
+
+// parseReferences parses the referenced table and field of a foreign key:
+//   REFERENCES table [(path)] [ON DELETE RESTRICT | CASCADE | SET NULL]
+func (p *Parser) parseReferences() (*database.ForeignKey, error) {
+	var fk database.ForeignKey
+	var err error
+
+	// Parse "REFERENCES"
+	err = p.parseTokens(scanner.REFERENCES)
+	if err != nil {
+		return nil, err
+	}
+
+	fk.Table, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse optional "(path)"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.LPAREN {
+		fk.Path, err = p.parseFieldPath()
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.parseTokens(scanner.RPAREN)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse optional "ON DELETE action"
+	ok, err := p.parseOptional(scanner.ON, scanner.DELETE)
+	if err != nil || !ok {
+		return &fk, err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.RESTRICT:
+		fk.OnDelete = database.ForeignKeyRestrict
+	case scanner.CASCADE:
+		fk.OnDelete = database.ForeignKeyCascade
+	case scanner.SET:
+		err = p.parseTokens(scanner.NULL)
+		if err != nil {
+			return nil, err
+		}
+
+		fk.OnDelete = database.ForeignKeySetNull
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"RESTRICT", "CASCADE", "SET NULL"}, pos)
+	}
+
+	return &fk, nil
+}
+
 // parseCreateIndexStatement parses a create index string and returns a Statement AST object.
 // This function assumes the CREATE INDEX or CREATE UNIQUE INDEX tokens have already been consumed.
 func (p *Parser) parseCreateIndexStatement(unique bool) (query.CreateIndexStmt, error) {
@@ -335,3 +591,27 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (query.CreateIndexStmt,
 
 	return stmt, nil
 }
+
+// hasSubquery returns true if e contains a subquery.
+func hasSubquery(e expr.Expr) bool {
+	return !expr.Walk(e, func(e expr.Expr) bool {
+		switch e.(type) {
+		case expr.ScalarSubquery, expr.Exists, *expr.Subquery:
+			return false
+		}
+
+		return true
+	})
+}
+
+// hasParam returns true if e contains a positional or named parameter.
+func hasParam(e expr.Expr) bool {
+	return !expr.Walk(e, func(e expr.Expr) bool {
+		switch e.(type) {
+		case expr.PositionalParam, expr.NamedParam:
+			return false
+		}
+
+		return true
+	})
+}
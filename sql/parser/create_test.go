@@ -5,6 +5,7 @@ import (
 
 	"github.com/tie/genji-release-test/database"
 	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/query"
 	"github.com/tie/genji-release-test/sql/parser"
 	"github.com/tie/genji-release-test/testutil"
@@ -21,6 +22,7 @@ func TestParserCreateTable(t *testing.T) {
 		{"Basic", "CREATE TABLE test", query.CreateTableStmt{TableName: "test"}, false},
 		{"If not exists", "CREATE TABLE IF NOT EXISTS test", query.CreateTableStmt{TableName: "test", IfNotExists: true}, false},
 		{"Path only", "CREATE TABLE test(a)", query.CreateTableStmt{}, true},
+		{"Wildcard path", "CREATE TABLE test(a[*] INTEGER)", query.CreateTableStmt{}, true},
 		{"With primary key", "CREATE TABLE test(foo INTEGER PRIMARY KEY)",
 			query.CreateTableStmt{
 				TableName: "test",
@@ -55,7 +57,17 @@ func TestParserCreateTable(t *testing.T) {
 				TableName: "test",
 				Info: database.TableInfo{
 					FieldConstraints: []*database.FieldConstraint{
-						{Path: document.Path(testutil.ParsePath(t, "foo")), DefaultValue: document.NewTextValue("10")},
+						{Path: document.Path(testutil.ParsePath(t, "foo")), DefaultValue: expr.Constraint(parser.MustParseExpr(`"10"`))},
+					},
+				},
+			}, false},
+		{"With default expression", "CREATE TABLE test(foo DEFAULT NOW(), bar DEFAULT (foo + 1))",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "foo")), DefaultValue: expr.Constraint(parser.MustParseExpr("NOW()"))},
+						{Path: document.Path(testutil.ParsePath(t, "bar")), DefaultValue: expr.Constraint(parser.MustParseExpr("(foo + 1)"))},
 					},
 				},
 			}, false},
@@ -164,6 +176,106 @@ func TestParserCreateTable(t *testing.T) {
 					},
 				},
 			}, false},
+		{"With check", "CREATE TABLE test(foo INTEGER CHECK (foo > 0))",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "foo")), Type: document.IntegerValue},
+					},
+					TableConstraints: []*database.TableConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "foo")), Check: expr.Constraint(parser.MustParseExpr("foo > 0"))},
+					},
+				},
+			}, false},
+		{"With named check and no type", "CREATE TABLE test(foo CONSTRAINT positive CHECK (foo > 0))",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "foo"))},
+					},
+					TableConstraints: []*database.TableConstraint{
+						{Name: "positive", Path: document.Path(testutil.ParsePath(t, "foo")), Check: expr.Constraint(parser.MustParseExpr("foo > 0"))},
+					},
+				},
+			}, false},
+		{"With generated field", "CREATE TABLE test(price DOUBLE, qty INTEGER, total DOUBLE AS (price * qty) STORED)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "price")), Type: document.DoubleValue},
+						{Path: document.Path(testutil.ParsePath(t, "qty")), Type: document.IntegerValue},
+						{Path: document.Path(testutil.ParsePath(t, "total")), Type: document.DoubleValue, GeneratedExpr: expr.Constraint(parser.MustParseExpr("price * qty"))},
+					},
+				},
+			}, false},
+		{"With generated field referring to a previous one", "CREATE TABLE test(a INTEGER, c INTEGER AS (a * 2) STORED, b INTEGER AS (c + 1) STORED)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "a")), Type: document.IntegerValue},
+						{Path: document.Path(testutil.ParsePath(t, "c")), Type: document.IntegerValue, GeneratedExpr: expr.Constraint(parser.MustParseExpr("a * 2"))},
+						{Path: document.Path(testutil.ParsePath(t, "b")), Type: document.IntegerValue, GeneratedExpr: expr.Constraint(parser.MustParseExpr("c + 1"))},
+					},
+				},
+			}, false},
+		{"With table constraints / CHECK", "CREATE TABLE test(foo INTEGER, bar INTEGER, CHECK (foo < bar), CONSTRAINT c CHECK (bar < 10))",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "foo")), Type: document.IntegerValue},
+						{Path: document.Path(testutil.ParsePath(t, "bar")), Type: document.IntegerValue},
+					},
+					TableConstraints: []*database.TableConstraint{
+						{Check: expr.Constraint(parser.MustParseExpr("foo < bar"))},
+						{Name: "c", Check: expr.Constraint(parser.MustParseExpr("bar < 10"))},
+					},
+				},
+			}, false},
+		{"With references", "CREATE TABLE test(foo INTEGER REFERENCES bar, baz REFERENCES bar(baz) ON DELETE CASCADE)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "foo")), Type: document.IntegerValue},
+						{Path: document.Path(testutil.ParsePath(t, "baz"))},
+					},
+					TableConstraints: []*database.TableConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "foo")), ForeignKey: &database.ForeignKey{Table: "bar"}},
+						{Path: document.Path(testutil.ParsePath(t, "baz")), ForeignKey: &database.ForeignKey{Table: "bar", Path: document.Path(testutil.ParsePath(t, "baz")), OnDelete: database.ForeignKeyCascade}},
+					},
+				},
+			}, false},
+		{"With table constraints / FOREIGN KEY", "CREATE TABLE test(foo INTEGER, CONSTRAINT fk FOREIGN KEY (foo) REFERENCES bar(a.b) ON DELETE SET NULL)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "foo")), Type: document.IntegerValue},
+					},
+					TableConstraints: []*database.TableConstraint{
+						{Name: "fk", Path: document.Path(testutil.ParsePath(t, "foo")), ForeignKey: &database.ForeignKey{Table: "bar", Path: document.Path(testutil.ParsePath(t, "a.b")), OnDelete: database.ForeignKeySetNull}},
+					},
+				},
+			}, false},
+		{"With references and invalid action", "CREATE TABLE test(foo REFERENCES bar ON DELETE NOTHING)", nil, true},
+		{"With foreign key on a field", "CREATE TABLE test(foo FOREIGN KEY (foo) REFERENCES bar)", nil, true},
+		{"With references at the table level", "CREATE TABLE test(foo INTEGER, REFERENCES bar)", nil, true},
+		{"With generated field without STORED", "CREATE TABLE test(total DOUBLE AS (price * qty))", nil, true},
+		{"With generated field and default", "CREATE TABLE test(total DOUBLE DEFAULT 0 AS (price * qty) STORED)", nil, true},
+		{"With self-referencing generated field", "CREATE TABLE test(a INTEGER AS (a + 1) STORED)", nil, true},
+		{"With generated field referring to a later one", "CREATE TABLE test(a INTEGER, b INTEGER AS (c + 1) STORED, c INTEGER AS (a * 2) STORED)", nil, true},
+		{"With generated fields referring to each other", "CREATE TABLE test(a INTEGER AS (b) STORED, b INTEGER AS (a) STORED)", nil, true},
+		{"With generated field referring to a nested generated field", "CREATE TABLE test(a INTEGER AS (b.c) STORED, b.c INTEGER AS (1) STORED)", nil, true},
+		{"With parameter in generated field", "CREATE TABLE test(a INTEGER AS (? + 1) STORED)", nil, true},
+		{"With check without parentheses", "CREATE TABLE test(foo INTEGER CHECK foo > 0)", nil, true},
+		{"With positional parameter in check", "CREATE TABLE test(foo INTEGER CHECK (foo > ?))", nil, true},
+		{"With named parameter in table check", "CREATE TABLE test(foo INTEGER, CHECK (foo > $min))", nil, true},
+		{"With constraint name without check", "CREATE TABLE test(foo INTEGER, CONSTRAINT c UNIQUE (foo))", nil, true},
 		{"With table constraints / duplicate pk on same path", "CREATE TABLE test(foo INTEGER PRIMARY KEY, PRIMARY KEY (foo))", nil, true},
 		{"With multiple primary keys", "CREATE TABLE test(foo PRIMARY KEY, bar PRIMARY KEY)",
 			query.CreateTableStmt{}, true},
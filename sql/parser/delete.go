@@ -33,8 +33,8 @@ func (p *Parser) parseDeleteStatement() (*planner.Statement, error) {
 		return nil, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	cfg.OrderBy, cfg.OrderByDirection, err = p.parseOrderBy()
+	// Parse order by: "ORDER BY expr [ASC|DESC]?, ..."
+	cfg.OrderBy, err = p.parseOrderBy()
 	if err != nil {
 		return nil, err
 	}
@@ -51,17 +51,24 @@ func (p *Parser) parseDeleteStatement() (*planner.Statement, error) {
 		return nil, err
 	}
 
+	cfg.Returning, err = p.parseReturning()
+	if err != nil {
+		return nil, err
+	}
+
+	p.bindSubqueries(subqueryScope{names: []string{cfg.TableName}, name: cfg.TableName}, cfg.WhereExpr)
+
 	return cfg.ToStream()
 }
 
 // DeleteConfig holds DELETE configuration.
 type deleteConfig struct {
-	TableName        string
-	WhereExpr        expr.Expr
-	OffsetExpr       expr.Expr
-	OrderBy          expr.Path
-	LimitExpr        expr.Expr
-	OrderByDirection scanner.Token
+	TableName  string
+	WhereExpr  expr.Expr
+	OffsetExpr expr.Expr
+	OrderBy    []expr.SortKey
+	LimitExpr  expr.Expr
+	Returning  []expr.Expr
 }
 
 func (cfg deleteConfig) ToStream() (*planner.Statement, error) {
@@ -71,12 +78,8 @@ func (cfg deleteConfig) ToStream() (*planner.Statement, error) {
 		s = s.Pipe(stream.Filter(cfg.WhereExpr))
 	}
 
-	if cfg.OrderBy != nil {
-		if cfg.OrderByDirection == scanner.DESC {
-			s = s.Pipe(stream.SortReverse(cfg.OrderBy))
-		} else {
-			s = s.Pipe(stream.Sort(cfg.OrderBy))
-		}
+	if len(cfg.OrderBy) > 0 {
+		s = s.Pipe(stream.SortBy(cfg.OrderBy...))
 	}
 
 	if cfg.OffsetExpr != nil {
@@ -117,6 +120,10 @@ func (cfg deleteConfig) ToStream() (*planner.Statement, error) {
 
 	s = s.Pipe(stream.TableDelete(cfg.TableName))
 
+	if len(cfg.Returning) > 0 {
+		s = s.Pipe(stream.Project(cfg.Returning...))
+	}
+
 	return &planner.Statement{
 		Stream:   s,
 		ReadOnly: false,
@@ -6,6 +6,7 @@ import (
 	"github.com/tie/genji-release-test/planner"
 	"github.com/tie/genji-release-test/sql/parser"
 	"github.com/tie/genji-release-test/stream"
+	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,6 +28,12 @@ func TestParserDelete(t *testing.T) {
 				Pipe(stream.Skip(20)).
 				Pipe(stream.TableDelete("test")),
 		},
+		{"WithReturning", "DELETE FROM test WHERE age = 10 RETURNING a",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Filter(parser.MustParseExpr("age = 10"))).
+				Pipe(stream.TableDelete("test")).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+		},
 		{"WithLimit", "DELETE FROM test LIMIT 10",
 			stream.New(stream.SeqScan("test")).
 				Pipe(stream.Take(10)).
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/expr"
@@ -43,6 +44,10 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int) (e expr.Expr, lit st
 
 	// Parse a non-binary expression type to start.
 	// This variable will always be the root of the expression tree.
+	// subqueries may parse expressions while the buffer is being filled,
+	// the literal representation starts at the current position.
+	start := p.buf.Len()
+
 	e, err = p.parseUnaryExpr()
 	if err != nil {
 		return nil, "", err
@@ -57,7 +62,7 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int) (e expr.Expr, lit st
 			return nil, "", err
 		}
 		if tok == 0 {
-			return root.RightHand(), strings.TrimSpace(p.buf.String()), nil
+			return root.RightHand(), strings.TrimSpace(p.buf.String()[start:]), nil
 		}
 
 		var rhs expr.Expr
@@ -82,6 +87,50 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int) (e expr.Expr, lit st
 	}
 }
 
+// inOperator wraps the constructor of the IN and NOT IN operators
+// so that a subquery on the right side evaluates to all of its values
+// instead of a single one.
+func inOperator(fn func(lhs, rhs expr.Expr) expr.Expr) func(lhs, rhs expr.Expr) expr.Expr {
+	return func(lhs, rhs expr.Expr) expr.Expr {
+		if sq, ok := rhs.(expr.ScalarSubquery); ok {
+			rhs = sq.Subquery
+		}
+
+		return fn(lhs, rhs)
+	}
+}
+
+// parseQuantifier parses the ANY or ALL keyword following a comparison operator
+// and returns the constructor of the corresponding operator.
+// The right operand must be enclosed in parentheses. It can be a subquery,
+// in which case the operator compares with all of its values.
+// If the next token is neither ANY nor ALL, it returns nil.
+func (p *Parser) parseQuantifier(op scanner.Token) (func(lhs, rhs expr.Expr) expr.Expr, error) {
+	var fn func(lhs, rhs expr.Expr) expr.Expr
+	switch tok, _, _ := p.ScanIgnoreWhitespace(); tok {
+	case scanner.ANY:
+		fn = expr.Any(op)
+	case scanner.ALL:
+		fn = expr.All(op)
+	default:
+		p.Unscan()
+		return nil, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+	p.Unscan()
+
+	return func(lhs, rhs expr.Expr) expr.Expr {
+		if par, ok := rhs.(expr.Parentheses); ok {
+			rhs = par.E
+		}
+
+		return inOperator(fn)(lhs, rhs)
+	}, nil
+}
+
 func (p *Parser) parseOperator(minPrecedence int) (func(lhs, rhs expr.Expr) expr.Expr, scanner.Token, error) {
 	op, _, _ := p.ScanIgnoreWhitespace()
 	if !op.IsOperator() && op != scanner.NOT {
@@ -89,10 +138,16 @@ func (p *Parser) parseOperator(minPrecedence int) (func(lhs, rhs expr.Expr) expr
 		return nil, 0, nil
 	}
 
-	// Ignore currently unused operators.
-	if op == scanner.EQREGEX || op == scanner.NEQREGEX {
-		p.Unscan()
-		return nil, 0, nil
+	switch op {
+	case scanner.EQ, scanner.NEQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+		if op.Precedence() < minPrecedence {
+			break
+		}
+
+		fn, err := p.parseQuantifier(op)
+		if err != nil || fn != nil {
+			return fn, op, err
+		}
 	}
 
 	switch {
@@ -129,7 +184,7 @@ func (p *Parser) parseOperator(minPrecedence int) (func(lhs, rhs expr.Expr) expr
 	case op == scanner.BITWISEXOR && op.Precedence() >= minPrecedence:
 		return expr.BitwiseXor, op, nil
 	case op == scanner.IN && op.Precedence() >= minPrecedence:
-		return expr.In, op, nil
+		return inOperator(expr.In), op, nil
 	case op == scanner.IS && op.Precedence() >= minPrecedence:
 		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.NOT {
 			return expr.IsNot, op, nil
@@ -140,7 +195,7 @@ func (p *Parser) parseOperator(minPrecedence int) (func(lhs, rhs expr.Expr) expr
 		tok, pos, lit := p.ScanIgnoreWhitespace()
 		switch {
 		case tok == scanner.IN && tok.Precedence() >= minPrecedence:
-			return expr.NotIn, op, nil
+			return inOperator(expr.NotIn), op, nil
 		case tok == scanner.LIKE && tok.Precedence() >= minPrecedence:
 			return expr.NotLike, op, nil
 		}
@@ -148,6 +203,10 @@ func (p *Parser) parseOperator(minPrecedence int) (func(lhs, rhs expr.Expr) expr
 		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"IN, LIKE"}, pos)
 	case op == scanner.LIKE && op.Precedence() >= minPrecedence:
 		return expr.Like, op, nil
+	case op == scanner.EQREGEX && op.Precedence() >= minPrecedence:
+		return expr.Regexp, op, nil
+	case op == scanner.NEQREGEX && op.Precedence() >= minPrecedence:
+		return expr.NotRegexp, op, nil
 	case op == scanner.CONCAT && op.Precedence() >= minPrecedence:
 		return expr.Concat, op, nil
 	case op == scanner.BETWEEN && op.Precedence() >= minPrecedence:
@@ -175,12 +234,25 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 	case scanner.CAST:
 		p.Unscan()
 		return p.parseCastExpression()
+	case scanner.CASE:
+		p.Unscan()
+		return p.parseCaseExpression()
+	case scanner.EXTRACT:
+		p.Unscan()
+		return p.parseExtractExpression()
+	case scanner.INTERVAL:
+		p.Unscan()
+		return p.parseInterval()
 	case scanner.IDENT:
 		// if the next token is a left parenthesis, this is a function
 		if tok1, _, _ := p.Scan(); tok1 == scanner.LPAREN {
 			p.Unscan()
 			p.Unscan()
-			return p.parseFunction()
+			fn, err := p.parseFunction()
+			if err != nil {
+				return nil, err
+			}
+			return p.parseOver(fn)
 		}
 		p.Unscan()
 		p.Unscan()
@@ -235,6 +307,17 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 		p.Unscan()
 		return p.parseExprList(scanner.LSBRACKET, scanner.RSBRACKET)
 	case scanner.LPAREN:
+		// if the next token is SELECT, this is a subquery
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.SELECT {
+			p.Unscan()
+			sq, err := p.parseSubquery()
+			if err != nil {
+				return nil, err
+			}
+			return expr.ScalarSubquery{Subquery: sq}, nil
+		}
+		p.Unscan()
+
 		e, _, err := p.ParseExpr()
 		if err != nil {
 			return nil, err
@@ -262,6 +345,16 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 			return nil, err
 		}
 		return expr.Not(e), nil
+	case scanner.EXISTS:
+		if err := p.parseTokens(scanner.LPAREN); err != nil {
+			return nil, err
+		}
+
+		sq, err := p.parseSubquery()
+		if err != nil {
+			return nil, err
+		}
+		return expr.Exists{Subquery: sq}, nil
 	default:
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"identifier", "string", "number", "bool"}, pos)
 	}
@@ -352,6 +445,8 @@ func (p *Parser) parseType() (document.ValueType, error) {
 		return document.IntegerValue, nil
 	case scanner.TYPETEXT:
 		return document.TextValue, nil
+	case scanner.TYPETIMESTAMP, scanner.TYPEDATE:
+		return document.TimestampValue, nil
 	case scanner.TYPEVARCHAR, scanner.TYPECHARACTER:
 		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
 			return 0, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
@@ -471,18 +566,24 @@ LOOP:
 				FieldName: lit,
 			})
 		case scanner.LSBRACKET:
-			// scan the next token for an integer
+			// scan the next token for an integer or a wildcard
 			tok, pos, lit := p.Scan()
-			if tok != scanner.INTEGER || lit[0] == '-' {
+			switch {
+			case tok == scanner.MUL:
+				path = append(path, document.PathFragment{
+					Wildcard: true,
+				})
+			case tok != scanner.INTEGER || lit[0] == '-':
 				return nil, newParseError(lit, []string{"array index"}, pos)
+			default:
+				idx, err := strconv.Atoi(lit)
+				if err != nil {
+					return nil, newParseError(lit, []string{"integer"}, pos)
+				}
+				path = append(path, document.PathFragment{
+					ArrayIndex: idx,
+				})
 			}
-			idx, err := strconv.Atoi(lit)
-			if err != nil {
-				return nil, newParseError(lit, []string{"integer"}, pos)
-			}
-			path = append(path, document.PathFragment{
-				ArrayIndex: idx,
-			})
 			// scan the next token for a closing left bracket
 			tok, pos, lit = p.Scan()
 			if tok != scanner.RSBRACKET {
@@ -497,6 +598,24 @@ LOOP:
 	return path, nil
 }
 
+// parseFieldPath parses a path designating a single value of a document,
+// which cannot contain the [*] wildcard.
+func (p *Parser) parseFieldPath() (document.Path, error) {
+	_, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if path.HasWildcard() {
+		return nil, &ParseError{Message: "wildcards are only allowed in expressions", Pos: pos}
+	}
+
+	return path, nil
+}
+
 func (p *Parser) parseExprListUntil(rightToken scanner.Token) (expr.LiteralExprList, error) {
 	var exprList expr.LiteralExprList
 	var expr expr.Expr
@@ -626,3 +745,163 @@ func (p *Parser) parseCastExpression() (expr.Expr, error) {
 
 	return expr.CastFunc{Expr: e, CastAs: tp}, nil
 }
+
+// parseExtractExpression parses a string of the form EXTRACT(field FROM expr).
+func (p *Parser) parseExtractExpression() (expr.Expr, error) {
+	// Parse required EXTRACT and ( tokens.
+	if err := p.parseTokens(scanner.EXTRACT, scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	// Parse required field name.
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"field"}, pos)
+	}
+
+	// Parse required FROM token.
+	if err := p.parseTokens(scanner.FROM); err != nil {
+		return nil, err
+	}
+
+	e, _, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse required ) token.
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	f, err := expr.NewExtractFunc(lit, e)
+	if err != nil {
+		return nil, &ParseError{Message: err.Error(), Pos: pos}
+	}
+
+	return f, nil
+}
+
+// intervalUnits associates the units of an interval with their duration.
+var intervalUnits = map[string]time.Duration{
+	"nanosecond":  time.Nanosecond,
+	"microsecond": time.Microsecond,
+	"millisecond": time.Millisecond,
+	"second":      time.Second,
+	"minute":      time.Minute,
+	"hour":        time.Hour,
+	"day":         24 * time.Hour,
+	"week":        7 * 24 * time.Hour,
+}
+
+// parseInterval parses a string of the form INTERVAL 'quantity unit [quantity unit ...]'
+// and returns the interval as an integer number of nanoseconds.
+// Units can be plural, and the string can also be a Go duration, such as '1h30m'.
+func (p *Parser) parseInterval() (expr.Expr, error) {
+	// Parse required INTERVAL token.
+	if err := p.parseTokens(scanner.INTERVAL); err != nil {
+		return nil, err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"string"}, pos)
+	}
+
+	if d, err := time.ParseDuration(lit); err == nil {
+		return expr.LiteralValue(document.NewIntegerValue(int64(d))), nil
+	}
+
+	fields := strings.Fields(strings.ToLower(lit))
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return nil, &ParseError{Message: stringutil.Sprintf("invalid interval %q", lit), Pos: pos}
+	}
+
+	var d time.Duration
+	for i := 0; i < len(fields); i += 2 {
+		n, err := strconv.ParseInt(fields[i], 10, 64)
+		if err != nil {
+			return nil, &ParseError{Message: stringutil.Sprintf("invalid interval %q", lit), Pos: pos}
+		}
+
+		unit, ok := intervalUnits[strings.TrimSuffix(fields[i+1], "s")]
+		if !ok {
+			return nil, &ParseError{Message: stringutil.Sprintf("invalid interval unit %q", fields[i+1]), Pos: pos}
+		}
+
+		d += time.Duration(n) * unit
+	}
+
+	return expr.LiteralValue(document.NewIntegerValue(int64(d))), nil
+}
+
+// parseCaseExpression parses a string of the form
+//   CASE [expr] WHEN expr THEN expr [WHEN expr THEN expr ...] [ELSE expr] END
+func (p *Parser) parseCaseExpression() (expr.Expr, error) {
+	// Parse required CASE token.
+	if err := p.parseTokens(scanner.CASE); err != nil {
+		return nil, err
+	}
+
+	var c expr.CaseExpr
+
+	// Parse optional operand of the simple form.
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	if tok != scanner.WHEN {
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.Operand = e
+	}
+
+	// Parse WHEN clauses, at least one is required.
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.WHEN {
+			if len(c.Whens) == 0 {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"WHEN"}, pos)
+			}
+			p.Unscan()
+			break
+		}
+
+		var w expr.WhenClause
+		var err error
+		w.When, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.parseTokens(scanner.THEN); err != nil {
+			return nil, err
+		}
+
+		w.Then, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		c.Whens = append(c.Whens, w)
+	}
+
+	// Parse optional ELSE clause.
+	ok, err := p.parseOptional(scanner.ELSE)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		c.Else, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse required END token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.END {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"WHEN", "ELSE", "END"}, pos)
+	}
+
+	return &c, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/sql/parser"
+	"github.com/tie/genji-release-test/sql/scanner"
 	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -123,7 +124,19 @@ func TestParserExpr(t *testing.T) {
 		{"IS NOT", "age IS NOT NULL", expr.IsNot(testutil.ParsePath(t, "age"), testutil.NullValue()), false},
 		{"LIKE", "name LIKE 'foo'", expr.Like(testutil.ParsePath(t, "name"), testutil.TextValue("foo")), false},
 		{"NOT LIKE", "name NOT LIKE 'foo'", expr.NotLike(testutil.ParsePath(t, "name"), testutil.TextValue("foo")), false},
+		{"=~", "name =~ '^foo'", expr.Regexp(testutil.ParsePath(t, "name"), testutil.TextValue("^foo")), false},
+		{"!~", "name !~ '^foo'", expr.NotRegexp(testutil.ParsePath(t, "name"), testutil.TextValue("^foo")), false},
 		{"NOT =", "name NOT = 'foo'", nil, true},
+		{"= ANY", "age = ANY(ages)", expr.Any(scanner.EQ)(testutil.ParsePath(t, "age"), testutil.ParsePath(t, "ages")), false},
+		{"> ANY with list", "age > ANY(1, 2)", expr.Any(scanner.GT)(testutil.ParsePath(t, "age"), expr.LiteralExprList{testutil.IntegerValue(1), testutil.IntegerValue(2)}), false},
+		{"<= ALL", "age <= ALL([1, 2])", expr.All(scanner.LTE)(testutil.ParsePath(t, "age"), expr.LiteralExprList{testutil.IntegerValue(1), testutil.IntegerValue(2)}), false},
+		{"ANY precedence", "age + 1 = ANY(ages) AND a",
+			expr.And(
+				expr.Any(scanner.EQ)(expr.Add(testutil.ParsePath(t, "age"), testutil.IntegerValue(1)), testutil.ParsePath(t, "ages")),
+				testutil.ParsePath(t, "a"),
+			), false},
+		{"ANY without parentheses", "age = ANY ages", nil, true},
+		{"ALL without comparison", "age + ALL(ages)", nil, true},
 		{"precedence", "4 > 1 + 2", expr.Gt(
 			testutil.IntegerValue(4),
 			expr.Add(
@@ -153,6 +166,31 @@ func TestParserExpr(t *testing.T) {
 
 		// unary operators
 		{"CAST", "CAST(a.b[1][0] AS TEXT)", expr.CastFunc{Expr: testutil.ParsePath(t, "a.b[1][0]"), CastAs: document.TextValue}, false},
+		{"CAST as TIMESTAMP", "CAST(a AS TIMESTAMP)", expr.CastFunc{Expr: testutil.ParsePath(t, "a"), CastAs: document.TimestampValue}, false},
+		{"CAST as DATE", "CAST(a AS DATE)", expr.CastFunc{Expr: testutil.ParsePath(t, "a"), CastAs: document.TimestampValue}, false},
+		{"EXTRACT", "EXTRACT(year FROM a)", &expr.ExtractFunc{Field: "YEAR", Expr: testutil.ParsePath(t, "a")}, false},
+		{"EXTRACT with unknown field", "EXTRACT(foo FROM a)", nil, true},
+		{"EXTRACT without FROM", "EXTRACT(YEAR a)", nil, true},
+		{"INTERVAL", "INTERVAL '1 day 2 hours'", testutil.IntegerValue(26 * 3600 * 1e9), false},
+		{"INTERVAL with duration", "INTERVAL '1m30s'", testutil.IntegerValue(90 * 1e9), false},
+		{"INTERVAL with unknown unit", "INTERVAL '1 month'", nil, true},
+		{"INTERVAL without quantity", "INTERVAL 'day'", nil, true},
+		{"CASE", "CASE WHEN a > 1 THEN 'big' ELSE 'small' END",
+			&expr.CaseExpr{
+				Whens: []expr.WhenClause{{When: expr.Gt(testutil.ParsePath(t, "a"), testutil.IntegerValue(1)), Then: testutil.TextValue("big")}},
+				Else:  testutil.TextValue("small"),
+			}, false},
+		{"CASE with operand", "CASE a + 1 WHEN 1 THEN 'one' WHEN 2 THEN 'two' END",
+			&expr.CaseExpr{
+				Operand: expr.Add(testutil.ParsePath(t, "a"), testutil.IntegerValue(1)),
+				Whens: []expr.WhenClause{
+					{When: testutil.IntegerValue(1), Then: testutil.TextValue("one")},
+					{When: testutil.IntegerValue(2), Then: testutil.TextValue("two")},
+				},
+			}, false},
+		{"CASE without WHEN", "CASE a ELSE 1 END", nil, true},
+		{"CASE without END", "CASE WHEN a THEN 1", nil, true},
+		{"CASE without THEN", "CASE WHEN a 1 END", nil, true},
 		{"NOT", "NOT 10", expr.Not(testutil.IntegerValue(10)), false},
 		{"NOT", "NOT NOT", nil, true},
 		{"NOT", "NOT NOT 10", expr.Not(expr.Not(testutil.IntegerValue(10))), false},
@@ -161,6 +199,20 @@ func TestParserExpr(t *testing.T) {
 		{"pk() function", "pk()", &expr.PKFunc{}, false},
 		{"count(expr) function", "count(a)", &expr.CountFunc{Expr: testutil.ParsePath(t, "a")}, false},
 		{"count(*) function", "count(*)", &expr.CountFunc{Wildcard: true}, false},
+		{"coalesce function", "coalesce(a, 1)", &expr.CoalesceFunc{Exprs: []expr.Expr{testutil.ParsePath(t, "a"), testutil.IntegerValue(1)}}, false},
+		{"coalesce without arguments", "coalesce()", nil, true},
+		{"nullif function", "NULLIF(a, 1)", &expr.NullIfFunc{Expr: testutil.ParsePath(t, "a"), Other: testutil.IntegerValue(1)}, false},
+		{"nullif with one argument", "NULLIF(a)", nil, true},
+		{"ifnull function", "IFNULL(a, 1)", &expr.IfNullFunc{Expr: testutil.ParsePath(t, "a"), Default: testutil.IntegerValue(1)}, false},
+		{"scalar function", "LOWER(a)", expr.NewScalarFunc("LOWER", nil, testutil.ParsePath(t, "a")), false},
+		{"scalar function with too many arguments", "LOWER(a, b)", nil, true},
+		{"variadic scalar function without arguments", "FORMAT()", nil, true},
+		{"math function", "ROUND(a, 2)", expr.NewScalarFunc("ROUND", nil, testutil.ParsePath(t, "a"), testutil.IntegerValue(2)), false},
+		{"variadic math function", "GREATEST(a, 1, 2)", expr.NewScalarFunc("GREATEST", nil, testutil.ParsePath(t, "a"), testutil.IntegerValue(1), testutil.IntegerValue(2)), false},
+		{"math function with too few arguments", "POW(a)", nil, true},
+		{"now function", "NOW()", &expr.NowFunc{}, false},
+		{"date_trunc function", "DATE_TRUNC('day', a)", expr.NewScalarFunc("DATE_TRUNC", nil, testutil.TextValue("day"), testutil.ParsePath(t, "a")), false},
+		{"typeof function", "typeof(a)", &expr.TypeOfFunc{Expr: testutil.ParsePath(t, "a")}, false},
 	}
 
 	for _, test := range tests {
@@ -206,6 +258,12 @@ func TestParsePath(t *testing.T) {
 			document.PathFragment{ArrayIndex: 5},
 			document.PathFragment{FieldName: "  \"quotes"},
 		}, false},
+		{"wildcard", `a.b[*].c`, document.Path{
+			document.PathFragment{FieldName: "a"},
+			document.PathFragment{FieldName: "b"},
+			document.PathFragment{Wildcard: true},
+			document.PathFragment{FieldName: "c"},
+		}, false},
 		{"negative index", `a.b[-100].c`, nil, true},
 		{"with spaces", `a.  b[100].  c`, nil, true},
 		{"starting with array", `[10].a`, nil, true},
@@ -53,6 +53,11 @@ func (p *Parser) parseInsertStatement() (*planner.Statement, error) {
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VALUES", "SELECT"}, pos)
 	}
 
+	cfg.OnConflict, err = p.parseOnConflictClause()
+	if err != nil {
+		return nil, err
+	}
+
 	cfg.Returning, err = p.parseReturning()
 	if err != nil {
 		return nil, err
@@ -194,6 +199,67 @@ func (p *Parser) parseParamOrDocument() (expr.Expr, error) {
 	return p.ParseDocument()
 }
 
+// parseOnConflictClause parses the ON CONFLICT clause of the query, if it exists:
+//   ON CONFLICT [(path, ...)] DO NOTHING
+//   ON CONFLICT [(path, ...)] DO UPDATE SET path = expr [, path = expr ...]
+func (p *Parser) parseOnConflictClause() (*stream.OnConflict, error) {
+	// Parse ON CONFLICT tokens.
+	if ok, err := p.parseOptional(scanner.ON, scanner.CONFLICT); !ok || err != nil {
+		return nil, err
+	}
+
+	var oc stream.OnConflict
+
+	// Parse optional conflict target.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.LPAREN {
+		for {
+			path, err := p.parseFieldPath()
+			if err != nil {
+				return nil, err
+			}
+			oc.Paths = append(oc.Paths, path)
+
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+				p.Unscan()
+				break
+			}
+		}
+
+		if err := p.parseTokens(scanner.RPAREN); err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	if err := p.parseTokens(scanner.DO); err != nil {
+		return nil, err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.NOTHING:
+		oc.DoNothing = true
+	case scanner.UPDATE:
+		if err := p.parseTokens(scanner.SET); err != nil {
+			return nil, err
+		}
+
+		pairs, err := p.parseSetClause()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pair := range pairs {
+			oc.Updates = append(oc.Updates, stream.ConflictUpdate{Path: pair.path, E: pair.e})
+		}
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"NOTHING", "UPDATE"}, pos)
+	}
+
+	return &oc, nil
+}
+
 func (p *Parser) parseReturning() ([]expr.Expr, error) {
 	// Parse RETURNING clause: RETURNING expr [AS alias]
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.RETURNING {
@@ -210,6 +276,7 @@ type insertConfig struct {
 	Values     []expr.Expr
 	Fields     []string
 	SelectStmt *planner.Statement
+	OnConflict *stream.OnConflict
 	Returning  []expr.Expr
 }
 
@@ -217,8 +284,6 @@ func (cfg *insertConfig) ToStream() (*planner.Statement, error) {
 	var s *stream.Stream
 	if cfg.Values != nil {
 		s = stream.New(stream.Expressions(cfg.Values...))
-
-		s = s.Pipe(stream.TableInsert(cfg.TableName))
 	} else {
 		s = cfg.SelectStmt.Stream
 
@@ -230,7 +295,11 @@ func (cfg *insertConfig) ToStream() (*planner.Statement, error) {
 		if len(cfg.Fields) > 0 {
 			s = s.Pipe(stream.IterRename(cfg.Fields...))
 		}
+	}
 
+	if cfg.OnConflict != nil {
+		s = s.Pipe(stream.TableUpsert(cfg.TableName, cfg.OnConflict))
+	} else {
 		s = s.Pipe(stream.TableInsert(cfg.TableName))
 	}
 
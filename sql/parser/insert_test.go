@@ -3,6 +3,7 @@ package parser_test
 import (
 	"testing"
 
+	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/planner"
 	"github.com/tie/genji-release-test/sql/parser"
@@ -83,6 +84,31 @@ func TestParserInsert(t *testing.T) {
 			)).Pipe(stream.TableInsert("test")).
 				Pipe(stream.Project(expr.Wildcard{}, testutil.ParseNamedExpr(t, "a"), testutil.ParseNamedExpr(t, "b", "B"), testutil.ParseNamedExpr(t, "c"))),
 			false},
+		{"Values / On conflict do nothing", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT DO NOTHING",
+			stream.New(stream.Expressions(
+				&expr.KVPairs{Pairs: []expr.KVPair{
+					{K: "a", V: testutil.TextValue("c")},
+					{K: "b", V: testutil.TextValue("d")},
+				}},
+			)).Pipe(stream.TableUpsert("test", &stream.OnConflict{DoNothing: true})),
+			false},
+		{"Values / On conflict do update / Returning", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT (a) DO UPDATE SET b = excluded.b, c = 1 RETURNING b",
+			stream.New(stream.Expressions(
+				&expr.KVPairs{Pairs: []expr.KVPair{
+					{K: "a", V: testutil.TextValue("c")},
+					{K: "b", V: testutil.TextValue("d")},
+				}},
+			)).Pipe(stream.TableUpsert("test", &stream.OnConflict{
+				Paths: []document.Path{document.Path(testutil.ParsePath(t, "a"))},
+				Updates: []stream.ConflictUpdate{
+					{Path: document.Path(testutil.ParsePath(t, "b")), E: testutil.ParsePath(t, "excluded.b")},
+					{Path: document.Path(testutil.ParsePath(t, "c")), E: testutil.IntegerValue(1)},
+				},
+			})).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "b"))),
+			false},
+		{"Values / On conflict without action", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT", nil, true},
+		{"Values / On conflict with unknown action", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT DO DELETE", nil, true},
 		{"Values / With fields / Wrong values", "INSERT INTO test (a, b) VALUES {a: 1}, ('e', 'f')",
 			nil, true},
 		{"Values / Without fields / Wrong values", "INSERT INTO test VALUES {a: 1}, ('e', 'f')",
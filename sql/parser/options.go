@@ -2,14 +2,31 @@ package parser
 
 import "github.com/tie/genji-release-test/expr"
 
+// Default limits of recursive common table expressions.
+// Queries run through genji.DB, genji.Tx or the database/sql driver
+// always use these limits, they can only be changed by creating a
+// parser with NewParserWithOptions.
+const (
+	DefaultMaxRecursionDepth = 1000
+	DefaultMaxRecursionRows  = 1000000
+)
+
 // Options of the SQL parser.
 type Options struct {
 	// A map of builtin SQL functions.
 	Functions expr.Functions
+	// Maximum number of iterations of a recursive common table expression.
+	// If zero, DefaultMaxRecursionDepth is used. If negative, it is not limited.
+	MaxRecursionDepth int
+	// Maximum number of documents returned by a recursive common table expression.
+	// If zero, DefaultMaxRecursionRows is used. If negative, it is not limited.
+	MaxRecursionRows int
 }
 
 func defaultOptions() *Options {
 	return &Options{
-		Functions: expr.NewFunctions(),
+		Functions:         expr.NewFunctions(),
+		MaxRecursionDepth: DefaultMaxRecursionDepth,
+		MaxRecursionRows:  DefaultMaxRecursionRows,
 	}
 }
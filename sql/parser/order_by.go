@@ -5,26 +5,43 @@ import (
 	"github.com/tie/genji-release-test/sql/scanner"
 )
 
-func (p *Parser) parseOrderBy() (expr.Path, scanner.Token, error) {
+func (p *Parser) parseOrderBy() ([]expr.SortKey, error) {
 	// parse ORDER token
 	ok, err := p.parseOptional(scanner.ORDER, scanner.BY)
 	if err != nil || !ok {
-		return nil, 0, err
+		return nil, err
 	}
 
-	// parse path
-	path, err := p.parsePath()
-	if err != nil {
-		return nil, 0, err
-	}
+	return p.parseSortKeys()
+}
 
-	// parse optional ASC or DESC
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
-		return expr.Path(path), tok, nil
-	}
-	p.Unscan()
+// parseSortKeys parses a list of expressions, each followed by an optional ASC or DESC token.
+func (p *Parser) parseSortKeys() ([]expr.SortKey, error) {
+	var keys []expr.SortKey
+
+	for {
+		// parse expr
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
 
-	return expr.Path(path), 0, nil
+		k := expr.SortKey{Expr: e}
+
+		// parse optional ASC or DESC
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.DESC {
+			k.Desc = true
+		} else if tok != scanner.ASC {
+			p.Unscan()
+		}
+
+		keys = append(keys, k)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			return keys, nil
+		}
+	}
 }
 
 func (p *Parser) parseLimit() (expr.Expr, error) {
@@ -5,6 +5,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/tie/genji-release-test/database"
 	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/query"
@@ -12,6 +13,26 @@ import (
 	"github.com/tie/genji-release-test/stringutil"
 )
 
+func init() {
+	// expressions of table constraints are stored as text in the catalog
+	// and must be parsed when the catalog is loaded.
+	database.ParseTableExpression = func(s string) (database.TableExpression, error) {
+		p := NewParser(strings.NewReader(s))
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		// the whole text must be consumed, otherwise the stored
+		// expression would silently be truncated.
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EOF {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"EOF"}, pos)
+		}
+
+		return expr.Constraint(e), nil
+	}
+}
+
 // Parser represents an Genji SQL Parser.
 type Parser struct {
 	s             *scanner.BufScanner
@@ -19,6 +40,10 @@ type Parser struct {
 	namedParams   int
 	buf           *bytes.Buffer
 	functions     expr.Functions
+	subqueries    map[*expr.Subquery]*subqueryInfo
+	ctes          map[string]*cteConfig
+	maxDepth      int
+	maxRows       int
 }
 
 // NewParser returns a new instance of Parser.
@@ -32,7 +57,21 @@ func NewParserWithOptions(r io.Reader, opts *Options) *Parser {
 		opts = defaultOptions()
 	}
 
-	return &Parser{s: scanner.NewBufScanner(r), functions: opts.Functions}
+	p := Parser{
+		s:         scanner.NewBufScanner(r),
+		functions: opts.Functions,
+		maxDepth:  opts.MaxRecursionDepth,
+		maxRows:   opts.MaxRecursionRows,
+	}
+
+	if p.maxDepth == 0 {
+		p.maxDepth = DefaultMaxRecursionDepth
+	}
+	if p.maxRows == 0 {
+		p.maxRows = DefaultMaxRecursionRows
+	}
+
+	return &p
 }
 
 // ParseQuery parses a query string and returns its AST representation.
@@ -98,6 +137,8 @@ func (p *Parser) ParseStatement() (query.Statement, error) {
 		return p.parseCommitStatement()
 	case scanner.SELECT:
 		return p.parseSelectStatement()
+	case scanner.WITH:
+		return p.parseWithStatement()
 	case scanner.DELETE:
 		return p.parseDeleteStatement()
 	case scanner.UPDATE:
@@ -117,7 +158,7 @@ func (p *Parser) ParseStatement() (query.Statement, error) {
 	}
 
 	return nil, newParseError(scanner.Tokstr(tok, lit), []string{
-		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK",
+		"ALTER", "BEGIN", "COMMIT", "SELECT", "WITH", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK",
 	}, pos)
 }
 
@@ -150,7 +191,7 @@ func (p *Parser) parsePathList() ([]document.Path, error) {
 	var err error
 	var path document.Path
 	// Parse first (required) path.
-	if path, err = p.parsePath(); err != nil {
+	if path, err = p.parseFieldPath(); err != nil {
 		return nil, err
 	}
 
@@ -163,7 +204,7 @@ func (p *Parser) parsePathList() ([]document.Path, error) {
 			break
 		}
 
-		vp, err := p.parsePath()
+		vp, err := p.parseFieldPath()
 		if err != nil {
 			return nil, err
 		}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/tie/genji-release-test/database"
 	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/planner"
 	"github.com/tie/genji-release-test/query"
@@ -45,3 +46,27 @@ func TestParserDivideByZero(t *testing.T) {
 		_, _ = parser.ParseQuery("SELECT * FROM t LIMIT 0 % .5")
 	})
 }
+
+func TestParseTableExpression(t *testing.T) {
+	tests := []struct {
+		s     string
+		fails bool
+	}{
+		{"a > 0", false},
+		{"`we ird` > 0", false},
+		{"we ird > 0", true},
+		{"a > 0)", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			e, err := database.ParseTableExpression(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.s, e.String())
+		})
+	}
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 
+	"github.com/tie/genji-release-test/document"
 	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/planner"
 	"github.com/tie/genji-release-test/sql/scanner"
@@ -13,6 +14,60 @@ import (
 // parseSelectStatement parses a select string and returns a Statement AST object.
 // This function assumes the SELECT token has already been consumed.
 func (p *Parser) parseSelectStatement() (*planner.Statement, error) {
+	cfg, err := p.parseSelectConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.ToStream()
+}
+
+// parseSelectConfig parses the clauses of a SELECT statement, including set operations.
+// This function assumes the SELECT token has already been consumed.
+func (p *Parser) parseSelectConfig() (*selectConfig, error) {
+	cfg, err := p.parseSelectCore()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse set operations: "UNION [ALL] SELECT ...", "INTERSECT SELECT ..." or "EXCEPT SELECT ..."
+	cfg.Compound, err = p.parseCompound()
+	if err != nil {
+		return nil, err
+	}
+
+	// the number of fields returned by statements using
+	// a wildcard is only known when they are run.
+	for _, cc := range cfg.Compound {
+		if n, m := cfg.arity(), cc.Select.arity(); n >= 0 && m >= 0 && n != m {
+			return nil, errors.New("each side of a set operation must have the same number of fields")
+		}
+	}
+
+	// Parse order by: "ORDER BY expr [ASC|DESC]?, ..."
+	cfg.OrderBy, err = p.parseOrderBy()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse limit: "LIMIT expr"
+	cfg.LimitExpr, err = p.parseLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse offset: "OFFSET expr"
+	cfg.OffsetExpr, err = p.parseOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// parseSelectCore parses the clauses of a SELECT statement that precede
+// set operations and ORDER BY.
+func (p *Parser) parseSelectCore() (*selectConfig, error) {
 	var cfg selectConfig
 	var err error
 
@@ -34,40 +89,79 @@ func (p *Parser) parseSelectStatement() (*planner.Statement, error) {
 		return nil, err
 	}
 	if !found {
-		return cfg.ToStream()
+		p.bindSubqueries(cfg.scope(), cfg.exprs()...)
+		return &cfg, nil
 	}
+	cfg.Source = p.source(cfg.TableName)
 
-	// Parse condition: "WHERE expr".
-	cfg.WhereExpr, err = p.parseCondition()
+	// Parse optional table alias: "[AS] alias"
+	cfg.TableAlias, err = p.parseTableAlias()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse group by: "GROUP BY expr"
-	cfg.GroupByExpr, err = p.parseGroupBy()
+	// Parse joined tables: "JOIN table ON expr", "LEFT JOIN table ON expr" or ", table"
+	cfg.Joins, err = p.parseJoins()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	cfg.OrderBy, cfg.OrderByDirection, err = p.parseOrderBy()
+	// Parse condition: "WHERE expr".
+	cfg.WhereExpr, err = p.parseCondition()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse limit: "LIMIT expr"
-	cfg.LimitExpr, err = p.parseLimit()
+	// Parse group by: "GROUP BY expr, ..."
+	cfg.GroupByExprs, err = p.parseGroupBy()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse offset: "OFFSET expr"
-	cfg.OffsetExpr, err = p.parseOffset()
+	// Parse having: "HAVING expr"
+	cfg.HavingExpr, err = p.parseHaving()
 	if err != nil {
 		return nil, err
 	}
 
-	return cfg.ToStream()
+	p.bindSubqueries(cfg.scope(), cfg.exprs()...)
+	return &cfg, nil
+}
+
+// parseCompound parses the list of SELECT statements combined with the first one.
+func (p *Parser) parseCompound() ([]compoundConfig, error) {
+	var compound []compoundConfig
+
+	for {
+		var cc compoundConfig
+
+		tok, _, _ := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.UNION:
+			ok, err := p.parseOptional(scanner.ALL)
+			if err != nil {
+				return nil, err
+			}
+			cc.All = ok
+		case scanner.INTERSECT, scanner.EXCEPT:
+		default:
+			p.Unscan()
+			return compound, nil
+		}
+		cc.Operator = tok
+
+		if err := p.parseTokens(scanner.SELECT); err != nil {
+			return nil, err
+		}
+
+		var err error
+		cc.Select, err = p.parseSelectCore()
+		if err != nil {
+			return nil, err
+		}
+
+		compound = append(compound, cc)
+	}
 }
 
 // parseProjectedExprs parses the list of projected fields.
@@ -110,7 +204,7 @@ func (p *Parser) parseProjectedExpr() (expr.Expr, string, error) {
 	// Paths may be quoted, we make sure we name the result field
 	// with the unquoted name instead.
 	if fs, ok := e.(expr.Path); ok {
-		lit = fs.String()
+		lit = document.Path(fs).String()
 	}
 
 	rf := &expr.NamedExpr{Expr: e, ExprName: lit}
@@ -155,45 +249,353 @@ func (p *Parser) parseFrom() (string, bool, error) {
 	return ident, true, nil
 }
 
-func (p *Parser) parseGroupBy() (expr.Expr, error) {
+// parseTableAlias parses an optional table alias, with or without the AS keyword.
+func (p *Parser) parseTableAlias() (string, error) {
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.AS:
+		return p.parseIdent()
+	case scanner.IDENT:
+		p.Unscan()
+		return p.parseIdent()
+	}
+
+	p.Unscan()
+	return "", nil
+}
+
+// parseJoins parses the list of tables joined to the first table of the FROM clause.
+func (p *Parser) parseJoins() ([]joinConfig, error) {
+	var joins []joinConfig
+
+	for {
+		var jc joinConfig
+		var needsCondition bool
+
+		tok, _, _ := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.COMMA:
+		case scanner.CROSS:
+			if err := p.parseTokens(scanner.JOIN); err != nil {
+				return nil, err
+			}
+		case scanner.JOIN:
+			needsCondition = true
+		case scanner.INNER:
+			if err := p.parseTokens(scanner.JOIN); err != nil {
+				return nil, err
+			}
+			needsCondition = true
+		case scanner.LEFT:
+			if _, err := p.parseOptional(scanner.OUTER); err != nil {
+				return nil, err
+			}
+			if err := p.parseTokens(scanner.JOIN); err != nil {
+				return nil, err
+			}
+			jc.Left = true
+			needsCondition = true
+		default:
+			p.Unscan()
+			return joins, nil
+		}
+
+		var err error
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.UNNEST {
+			// Parse unnested array: "UNNEST(expr) [AS] alias"
+			jc.Unnest, err = p.parseUnnest()
+		} else {
+			p.Unscan()
+			jc.TableName, err = p.parseIdent()
+			if err != nil {
+				pErr := err.(*ParseError)
+				pErr.Expected = []string{"table_name"}
+				return nil, pErr
+			}
+
+			jc.Source = p.source(jc.TableName)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		jc.Alias, err = p.parseTableAlias()
+		if err != nil {
+			return nil, err
+		}
+		// unnested values are only addressable through their alias
+		if jc.Unnest != nil && jc.Alias == "" {
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"alias"}, pos)
+		}
+
+		if needsCondition {
+			if err := p.parseTokens(scanner.ON); err != nil {
+				return nil, err
+			}
+
+			jc.On, _, err = p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		joins = append(joins, jc)
+	}
+}
+
+// parseUnnest parses the array expression of UNNEST, enclosed in parentheses.
+// This function assumes the UNNEST token has already been consumed.
+func (p *Parser) parseUnnest() (expr.Expr, error) {
+	if err := p.parseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	e, _, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (p *Parser) parseGroupBy() ([]expr.Expr, error) {
 	ok, err := p.parseOptional(scanner.GROUP, scanner.BY)
 	if err != nil || !ok {
 		return nil, err
 	}
 
-	// parse expr
+	var exprs []expr.Expr
+	for {
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			return exprs, nil
+		}
+	}
+}
+
+func (p *Parser) parseHaving() (expr.Expr, error) {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.HAVING {
+		p.Unscan()
+		return nil, nil
+	}
+
 	e, _, err := p.ParseExpr()
 	return e, err
 }
 
+// joinConfig holds the configuration of a table joined in a SELECT statement.
+type joinConfig struct {
+	TableName string
+	Alias     string
+	On        expr.Expr
+	Left      bool
+
+	// Source is the stream reading the documents of the table.
+	Source *stream.Stream
+	// Unnest is the array whose values are joined, instead of a table.
+	Unnest expr.Expr
+}
+
+// compoundConfig holds the configuration of a SELECT statement
+// combined with the previous ones using a set operation.
+type compoundConfig struct {
+	// Operator is either UNION, INTERSECT or EXCEPT.
+	Operator scanner.Token
+	// All is set for UNION ALL.
+	All    bool
+	Select *selectConfig
+}
+
 // SelectConfig holds SELECT configuration.
 type selectConfig struct {
-	TableName        string
-	Distinct         bool
-	WhereExpr        expr.Expr
-	GroupByExpr      expr.Expr
-	OrderBy          expr.Path
-	OrderByDirection scanner.Token
-	OffsetExpr       expr.Expr
-	LimitExpr        expr.Expr
-	ProjectionExprs  []expr.Expr
+	TableName       string
+	TableAlias      string
+	Joins           []joinConfig
+	Compound        []compoundConfig
+	Distinct        bool
+	WhereExpr       expr.Expr
+	GroupByExprs    []expr.Expr
+	HavingExpr      expr.Expr
+	OrderBy         []expr.SortKey
+	OffsetExpr      expr.Expr
+	LimitExpr       expr.Expr
+	ProjectionExprs []expr.Expr
+
+	// Source is the stream reading the documents of the table,
+	// which is either a real table or a common table expression.
+	Source *stream.Stream
+}
+
+// scope returns the names under which the documents read by the statement
+// are made available to its subqueries.
+func (cfg selectConfig) scope() subqueryScope {
+	if cfg.TableName == "" {
+		return subqueryScope{}
+	}
+
+	if cfg.TableAlias == "" && len(cfg.Joins) == 0 {
+		return subqueryScope{names: []string{cfg.TableName}, name: cfg.TableName}
+	}
+
+	var sc subqueryScope
+	if cfg.TableAlias != "" {
+		sc.names = append(sc.names, cfg.TableAlias)
+	} else {
+		sc.names = append(sc.names, cfg.TableName)
+	}
+	for _, j := range cfg.Joins {
+		if j.Alias != "" {
+			sc.names = append(sc.names, j.Alias)
+		} else {
+			sc.names = append(sc.names, j.TableName)
+		}
+	}
+
+	return sc
+}
+
+// exprs returns all the expressions used by the statement.
+func (cfg selectConfig) exprs() []expr.Expr {
+	exprs := append([]expr.Expr{}, cfg.ProjectionExprs...)
+	for _, j := range cfg.Joins {
+		exprs = append(exprs, j.Unnest, j.On)
+	}
+
+	exprs = append(exprs, cfg.GroupByExprs...)
+	exprs = append(exprs, cfg.WhereExpr, cfg.HavingExpr, cfg.OffsetExpr, cfg.LimitExpr)
+	for _, k := range cfg.OrderBy {
+		exprs = append(exprs, k.Expr)
+	}
+
+	return exprs
+}
+
+// arity returns the number of fields of the documents returned by the statement,
+// or -1 if it projects a wildcard.
+func (cfg selectConfig) arity() int {
+	for _, e := range cfg.ProjectionExprs {
+		if _, ok := e.(expr.Wildcard); ok {
+			return -1
+		}
+	}
+
+	return len(cfg.ProjectionExprs)
+}
+
+// compoundSelects returns the statements combined with the first one using set operations.
+func (cfg selectConfig) compoundSelects() []*selectConfig {
+	selects := make([]*selectConfig, 0, len(cfg.Compound))
+	for _, cc := range cfg.Compound {
+		selects = append(selects, cc.Select)
+	}
+
+	return selects
 }
 
 func (cfg selectConfig) ToStream() (*planner.Statement, error) {
+	s, err := cfg.coreStream()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cc := range cfg.Compound {
+		right, err := cc.Select.coreStream()
+		if err != nil {
+			return nil, err
+		}
+
+		// set operations are evaluated from left to right
+		switch {
+		case cc.Operator == scanner.UNION && cc.All:
+			s = stream.New(stream.UnionAll(s, right))
+		case cc.Operator == scanner.UNION:
+			s = stream.New(stream.Union(s, right))
+		case cc.Operator == scanner.INTERSECT:
+			s = stream.New(stream.Intersect(s, right))
+		case cc.Operator == scanner.EXCEPT:
+			s = stream.New(stream.Except(s, right))
+		}
+	}
+
+	return cfg.withOrderAndLimit(s)
+}
+
+// coreStream returns the stream of the statement, without the clauses that apply
+// to the result of set operations.
+func (cfg selectConfig) coreStream() (*stream.Stream, error) {
 	var s *stream.Stream
 
 	if cfg.TableName != "" {
-		s = stream.New(stream.SeqScan(cfg.TableName))
+		s = cfg.Source
+		if s == nil {
+			s = stream.New(stream.SeqScan(cfg.TableName))
+		}
+
+		// when tables are joined or aliased, documents are
+		// stored under the alias of their table. Paths that
+		// don't start with an alias are looked up in every document.
+		if cfg.TableAlias != "" || len(cfg.Joins) > 0 {
+			alias := cfg.TableAlias
+			if alias == "" {
+				alias = cfg.TableName
+			}
+			s = s.Pipe(stream.Alias(alias))
+
+			aliases := map[string]struct{}{alias: {}}
+			for _, j := range cfg.Joins {
+				alias := j.Alias
+				if alias == "" {
+					alias = j.TableName
+				}
+
+				if _, ok := aliases[alias]; ok {
+					return nil, stringutil.Errorf("table name %q specified more than once", alias)
+				}
+				aliases[alias] = struct{}{}
+
+				if j.Unnest != nil {
+					if j.Left {
+						s = s.Pipe(stream.LeftUnnest(j.Unnest, alias, j.On))
+					} else {
+						s = s.Pipe(stream.Unnest(j.Unnest, alias, j.On))
+					}
+					continue
+				}
+
+				right := j.Source
+				if right == nil {
+					right = stream.New(stream.SeqScan(j.TableName))
+				}
+				if j.Left {
+					s = s.Pipe(stream.LeftJoin(right, alias, j.On))
+				} else {
+					s = s.Pipe(stream.Join(right, alias, j.On))
+				}
+			}
+		}
 	}
 
 	if cfg.WhereExpr != nil {
 		s = s.Pipe(stream.Filter(cfg.WhereExpr))
 	}
 
-	// when using GROUP BY, only aggregation functions or GroupByExpr can be selected
-	if cfg.GroupByExpr != nil {
+	// when using GROUP BY or HAVING, only aggregation functions or GroupByExprs can be selected
+	if len(cfg.GroupByExprs) > 0 || cfg.HavingExpr != nil {
 		// add Group node
-		s = s.Pipe(stream.GroupBy(cfg.GroupByExpr))
+		if len(cfg.GroupByExprs) > 0 {
+			s = s.Pipe(stream.GroupBy(cfg.GroupByExprs...))
+		}
 
 		var invalidProjectedField expr.Expr
 		var aggregators []expr.AggregatorBuilder
@@ -212,8 +614,8 @@ func (cfg selectConfig) ToStream() (*planner.Statement, error) {
 				continue
 			}
 
-			// check if this is the same expression as the one used in the GROUP BY clause
-			if expr.Equal(e, cfg.GroupByExpr) {
+			// check if this is one of the expressions used in the GROUP BY clause
+			if isGroupByExpr(e, cfg.GroupByExprs) {
 				continue
 			}
 
@@ -222,12 +624,62 @@ func (cfg selectConfig) ToStream() (*planner.Statement, error) {
 			break
 		}
 
+		if invalidProjectedField == nil {
+			invalidProjectedField = ungroupedPath(cfg.HavingExpr, cfg.GroupByExprs)
+		}
+
 		if invalidProjectedField != nil {
 			return nil, stringutil.Errorf("field %q must appear in the GROUP BY clause or be used in an aggregate function", invalidProjectedField)
 		}
 
+		// aggregation functions only used by the HAVING or ORDER BY clauses must be computed as well.
+		// ORDER BY applies to the result of set operations, if any, in which case
+		// it can only refer to the projected fields.
+		others := []expr.Expr{cfg.HavingExpr}
+		if len(cfg.Compound) == 0 {
+			for _, k := range cfg.OrderBy {
+				others = append(others, k.Expr)
+			}
+		}
+		for _, e := range others {
+			expr.Walk(e, func(e expr.Expr) bool {
+				agg, ok := e.(expr.AggregatorBuilder)
+				if !ok {
+					return true
+				}
+
+				for _, a := range aggregators {
+					if expr.Equal(a.(expr.Expr), e) {
+						return true
+					}
+				}
+
+				aggregators = append(aggregators, agg)
+				return true
+			})
+		}
+
 		// add Aggregation node
 		s = s.Pipe(stream.HashAggregate(aggregators...))
+
+		// expressions of the GROUP BY clause used after the aggregation
+		// must read the value computed for each group
+		if len(cfg.GroupByExprs) > 0 {
+			for i, pe := range cfg.ProjectionExprs {
+				cfg.ProjectionExprs[i] = bindGroupedExprs(pe, cfg.GroupByExprs)
+			}
+			cfg.HavingExpr = bindGroupedExprs(cfg.HavingExpr, cfg.GroupByExprs)
+			if len(cfg.Compound) == 0 {
+				for i, k := range cfg.OrderBy {
+					cfg.OrderBy[i].Expr = bindGroupedExprs(k.Expr, cfg.GroupByExprs)
+				}
+			}
+		}
+
+		// add Filter node for the HAVING clause
+		if cfg.HavingExpr != nil {
+			s = s.Pipe(stream.Filter(cfg.HavingExpr))
+		}
 	} else {
 		// if there is no GROUP BY clause, check if there are any aggregation function
 		// and if so add an aggregation node
@@ -252,6 +704,21 @@ func (cfg selectConfig) ToStream() (*planner.Statement, error) {
 		}
 	}
 
+	// if there are any window functions, add a window node
+	// that computes their value for each document
+	var windows []*expr.Over
+	for _, pe := range cfg.ProjectionExprs {
+		expr.Walk(pe, func(e expr.Expr) bool {
+			if o, ok := e.(*expr.Over); ok {
+				windows = append(windows, o)
+			}
+			return true
+		})
+	}
+	if len(windows) > 0 {
+		s = s.Pipe(stream.Window(windows...))
+	}
+
 	// If there is no FROM clause ensure there is no wildcard or path
 	if cfg.TableName == "" {
 		var err error
@@ -278,14 +745,91 @@ func (cfg selectConfig) ToStream() (*planner.Statement, error) {
 		s = s.Pipe(stream.Distinct())
 	}
 
-	if cfg.OrderBy != nil {
-		if cfg.OrderByDirection == scanner.DESC {
-			s = s.Pipe(stream.SortReverse(cfg.OrderBy))
-		} else {
-			s = s.Pipe(stream.Sort(cfg.OrderBy))
+	return s, nil
+}
+
+// isGroupByExpr returns true if e is one of the expressions of the GROUP BY clause.
+func isGroupByExpr(e expr.Expr, groupBy []expr.Expr) bool {
+	for _, g := range groupBy {
+		if expr.Equal(e, g) {
+			return true
 		}
 	}
 
+	return false
+}
+
+// bindGroupedExprs replaces the expressions of e that are part of the GROUP BY clause
+// by expressions reading their value from the result of the aggregation.
+// Paths are left untouched, their value is stored at the same path in that document.
+func bindGroupedExprs(e expr.Expr, groupBy []expr.Expr) expr.Expr {
+	if e == nil {
+		return nil
+	}
+
+	if _, ok := e.(expr.Path); !ok && isGroupByExpr(e, groupBy) {
+		return expr.GroupedExpr{Expr: e}
+	}
+
+	switch t := e.(type) {
+	case *expr.NamedExpr:
+		t.Expr = bindGroupedExprs(t.Expr, groupBy)
+	case expr.Operator:
+		t.SetLeftHandExpr(bindGroupedExprs(t.LeftHand(), groupBy))
+		t.SetRightHandExpr(bindGroupedExprs(t.RightHand(), groupBy))
+	case expr.Parentheses:
+		return expr.Parentheses{E: bindGroupedExprs(t.E, groupBy)}
+	}
+
+	return e
+}
+
+// ungroupedPath returns the first path of e that is neither part of the GROUP BY expressions
+// nor used by an aggregation function, if any.
+func ungroupedPath(e expr.Expr, groupBy []expr.Expr) expr.Expr {
+	if e == nil {
+		return nil
+	}
+
+	if isGroupByExpr(e, groupBy) {
+		return nil
+	}
+
+	switch t := e.(type) {
+	case expr.AggregatorBuilder:
+		return nil
+	case expr.Path:
+		return t
+	case expr.Operator:
+		if p := ungroupedPath(t.LeftHand(), groupBy); p != nil {
+			return p
+		}
+		return ungroupedPath(t.RightHand(), groupBy)
+	case expr.Parentheses:
+		return ungroupedPath(t.E, groupBy)
+	case expr.LiteralExprList:
+		for _, e := range t {
+			if p := ungroupedPath(e, groupBy); p != nil {
+				return p
+			}
+		}
+	case expr.Function:
+		for _, e := range t.Params() {
+			if p := ungroupedPath(e, groupBy); p != nil {
+				return p
+			}
+		}
+	}
+
+	return nil
+}
+
+// withOrderAndLimit adds the ORDER BY, OFFSET and LIMIT clauses to the stream.
+func (cfg selectConfig) withOrderAndLimit(s *stream.Stream) (*planner.Statement, error) {
+	if len(cfg.OrderBy) > 0 {
+		s = s.Pipe(stream.SortBy(cfg.OrderBy...))
+	}
+
 	if cfg.OffsetExpr != nil {
 		v, err := cfg.OffsetExpr.Eval(&expr.Environment{})
 		if err != nil {
@@ -7,6 +7,7 @@ import (
 	"github.com/tie/genji-release-test/planner"
 	"github.com/tie/genji-release-test/sql/parser"
 	"github.com/tie/genji-release-test/stream"
+	"github.com/tie/genji-release-test/stringutil"
 	"github.com/tie/genji-release-test/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -84,8 +85,25 @@ func TestParserSelect(t *testing.T) {
 				Pipe(stream.Project(testutil.ParseNamedExpr(t, "a.b.c"))),
 			false,
 		},
+		{"WithMultipleGroupBy", "SELECT a, b.c, COUNT(*) FROM test GROUP BY a, b.c",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.GroupBy(parser.MustParseExpr("a"), parser.MustParseExpr("b.c"))).
+				Pipe(stream.HashAggregate(&expr.CountFunc{Wildcard: true})).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"), testutil.ParseNamedExpr(t, "b.c"), testutil.ParseNamedExpr(t, "COUNT(*)"))),
+			false,
+		},
 		{"With Invalid GroupBy: Wildcard", "SELECT * FROM test WHERE age = 10 GROUP BY a.b.c", nil, true},
 		{"With Invalid GroupBy: a.b", "SELECT a.b FROM test WHERE age = 10 GROUP BY a.b.c", nil, true},
+		{"WithHaving", "SELECT a, COUNT(*) FROM test GROUP BY a HAVING SUM(b) > 10",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.GroupBy(parser.MustParseExpr("a"))).
+				Pipe(stream.HashAggregate(&expr.CountFunc{Wildcard: true}, &expr.SumFunc{Expr: testutil.ParsePath(t, "b")})).
+				Pipe(stream.Filter(parser.MustParseExpr("SUM(b) > 10"))).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"), testutil.ParseNamedExpr(t, "COUNT(*)"))),
+			false,
+		},
+		{"With Invalid GroupBy: ungrouped field", "SELECT a, c FROM test GROUP BY a, b", nil, true},
+		{"With Invalid Having", "SELECT a FROM test GROUP BY a HAVING b > 10", nil, true},
 		{"WithOrderBy", "SELECT * FROM test WHERE age = 10 ORDER BY a.b.c",
 			stream.New(stream.SeqScan("test")).
 				Pipe(stream.Filter(parser.MustParseExpr("age = 10"))).
@@ -107,6 +125,16 @@ func TestParserSelect(t *testing.T) {
 				Pipe(stream.SortReverse(testutil.ParsePath(t, "a.b.c"))),
 			false,
 		},
+		{"WithMultipleOrderBy", "SELECT * FROM test ORDER BY a DESC, b * 2, c ASC",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Project(expr.Wildcard{})).
+				Pipe(stream.SortBy(
+					expr.SortKey{Expr: parser.MustParseExpr("a"), Desc: true},
+					expr.SortKey{Expr: parser.MustParseExpr("b * 2")},
+					expr.SortKey{Expr: parser.MustParseExpr("c")},
+				)),
+			false,
+		},
 		{"WithLimit", "SELECT * FROM test WHERE age = 10 LIMIT 20",
 			stream.New(stream.SeqScan("test")).
 				Pipe(stream.Filter(parser.MustParseExpr("age = 10"))).
@@ -140,6 +168,82 @@ func TestParserSelect(t *testing.T) {
 		{"Invalid use of MAX() aggregator", "SELECT * FROM test LIMIT max(0)", nil, true},
 		{"Invalid use of SUM() aggregator", "SELECT * FROM test LIMIT sum(0)", nil, true},
 		{"Invalid use of AVG() aggregator", "SELECT * FROM test LIMIT avg(0)", nil, true},
+		{"WithTableAlias", "SELECT t.a FROM test AS t WHERE t.b = 1",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Alias("t")).
+				Pipe(stream.Filter(parser.MustParseExpr("t.b = 1"))).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "t.a"))),
+			false,
+		},
+		{"WithJoin", "SELECT * FROM foo JOIN bar b ON foo.a = b.a",
+			stream.New(stream.SeqScan("foo")).
+				Pipe(stream.Alias("foo")).
+				Pipe(stream.Join(stream.New(stream.SeqScan("bar")), "b", parser.MustParseExpr("foo.a = b.a"))).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithInnerAndLeftJoins", "SELECT * FROM foo f INNER JOIN bar ON f.a = bar.a LEFT OUTER JOIN baz AS z ON z.b = bar.b",
+			stream.New(stream.SeqScan("foo")).
+				Pipe(stream.Alias("f")).
+				Pipe(stream.Join(stream.New(stream.SeqScan("bar")), "bar", parser.MustParseExpr("f.a = bar.a"))).
+				Pipe(stream.LeftJoin(stream.New(stream.SeqScan("baz")), "z", parser.MustParseExpr("z.b = bar.b"))).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithCrossJoins", "SELECT * FROM foo, bar CROSS JOIN baz",
+			stream.New(stream.SeqScan("foo")).
+				Pipe(stream.Alias("foo")).
+				Pipe(stream.Join(stream.New(stream.SeqScan("bar")), "bar", nil)).
+				Pipe(stream.Join(stream.New(stream.SeqScan("baz")), "baz", nil)).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithUnnest", "SELECT item.a FROM foo, UNNEST(foo.items) AS item",
+			stream.New(stream.SeqScan("foo")).
+				Pipe(stream.Alias("foo")).
+				Pipe(stream.Unnest(parser.MustParseExpr("foo.items"), "item", nil)).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "item.a"))),
+			false,
+		},
+		{"WithUnnestJoins", "SELECT * FROM foo f JOIN UNNEST(f.items) i ON i.a > 1 LEFT JOIN UNNEST(f.tags) AS t ON true",
+			stream.New(stream.SeqScan("foo")).
+				Pipe(stream.Alias("f")).
+				Pipe(stream.Unnest(parser.MustParseExpr("f.items"), "i", parser.MustParseExpr("i.a > 1"))).
+				Pipe(stream.LeftUnnest(parser.MustParseExpr("f.tags"), "t", parser.MustParseExpr("true"))).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithUnion", "SELECT a FROM foo UNION SELECT b FROM bar ORDER BY a LIMIT 1",
+			stream.New(stream.Union(
+				stream.New(stream.SeqScan("foo")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+				stream.New(stream.SeqScan("bar")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "b"))),
+			)).
+				Pipe(stream.Sort(parser.MustParseExpr("a").(expr.Path))).
+				Pipe(stream.Take(1)),
+			false,
+		},
+		{"WithSetOperations", "SELECT a FROM foo UNION ALL SELECT b FROM bar INTERSECT SELECT c FROM baz EXCEPT SELECT d FROM qux WHERE d > 1",
+			stream.New(stream.Except(
+				stream.New(stream.Intersect(
+					stream.New(stream.UnionAll(
+						stream.New(stream.SeqScan("foo")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+						stream.New(stream.SeqScan("bar")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "b"))),
+					)),
+					stream.New(stream.SeqScan("baz")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "c"))),
+				)),
+				stream.New(stream.SeqScan("qux")).Pipe(stream.Filter(parser.MustParseExpr("d > 1"))).Pipe(stream.Project(testutil.ParseNamedExpr(t, "d"))),
+			)),
+			false,
+		},
+		{"WithUnionAfterOrderBy", "SELECT a FROM foo ORDER BY a UNION SELECT b FROM bar", nil, true},
+		{"WithUnionDifferentArity", "SELECT a FROM foo UNION SELECT b, c FROM bar", nil, true},
+		{"WithIntersectDifferentArity", "SELECT a, b FROM foo INTERSECT SELECT c FROM bar", nil, true},
+		{"WithExceptDifferentArity", "SELECT a FROM foo EXCEPT SELECT a FROM foo UNION SELECT b, c FROM bar", nil, true},
+		{"WithJoinWithoutCondition", "SELECT * FROM foo JOIN bar", nil, true},
+		{"WithDuplicateAlias", "SELECT * FROM foo JOIN bar foo ON foo.a = 1", nil, true},
+		{"WithUnnestWithoutParentheses", "SELECT * FROM foo, UNNEST foo.items", nil, true},
+		{"WithUnnestWithoutAlias", "SELECT * FROM foo, UNNEST(foo.items) WHERE a = 1", nil, true},
+		{"WithUnnestFirst", "SELECT * FROM UNNEST([1, 2])", nil, true},
 	}
 
 	for _, test := range tests {
@@ -155,3 +259,101 @@ func TestParserSelect(t *testing.T) {
 		})
 	}
 }
+
+func TestParserSubquery(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		where      string
+		scope      string
+		correlated bool
+		fails      bool
+	}{
+		{"In", "SELECT * FROM foo WHERE a IN (SELECT b FROM bar)", "a IN (seqScan(bar) | project(b))", "foo", false, false},
+		{"Not in", "SELECT * FROM foo WHERE a NOT IN (SELECT b FROM bar WHERE c > 1)", "a NOT IN (seqScan(bar) | filter(c > 1) | project(b))", "foo", false, false},
+		{"Exists", "SELECT * FROM foo WHERE EXISTS (SELECT * FROM bar WHERE b = foo.a)", "EXISTS (seqScan(bar) | filter(b = foo.a) | project(*))", "foo", true, false},
+		{"Not exists", "SELECT * FROM foo f WHERE NOT EXISTS (SELECT 1 FROM bar WHERE b = f.a)", "NOT EXISTS (seqScan(bar) | filter(b = f.a) | project(1))", "", true, false},
+		{"Nested", "SELECT * FROM foo WHERE EXISTS (SELECT * FROM bar WHERE b IN (SELECT c FROM baz WHERE c = foo.a))", "EXISTS (seqScan(bar) | filter(b IN (seqScan(baz) | filter(c = foo.a) | project(c))) | project(*))", "foo", true, false},
+		{"Scalar", "SELECT * FROM foo WHERE a > (SELECT MAX(b) FROM bar)", "a > (seqScan(bar) | hashAggregate(MAX(b)) | project(MAX(b)))", "foo", false, false},
+		{"Correlated scalar", "SELECT * FROM foo WHERE a = (SELECT bar.b FROM bar WHERE bar.c = foo.c)", "a = (seqScan(bar) | alias(bar) | filter(bar.c = foo.c) | project(bar.b))", "foo", true, false},
+		{"Missing parenthesis", "SELECT * FROM foo WHERE a IN (SELECT b FROM bar", "", "", false, true},
+		{"Exists without subquery", "SELECT * FROM foo WHERE EXISTS (1)", "", "", false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+
+			var f *stream.FilterOperator
+			for op := q.Statements[0].(*planner.Statement).Stream.First(); op != nil; op = op.GetNext() {
+				if fop, ok := op.(*stream.FilterOperator); ok {
+					f = fop
+				}
+			}
+			require.NotNil(t, f)
+			require.Equal(t, test.where, f.E.(stringutil.Stringer).String())
+
+			var sq *expr.Subquery
+			expr.Walk(f.E, func(e expr.Expr) bool {
+				switch t := e.(type) {
+				case *expr.Subquery:
+					sq = t
+				case expr.Exists:
+					sq = t.Subquery
+				}
+				return sq == nil
+			})
+			require.NotNil(t, sq)
+			require.Equal(t, test.scope, sq.Scope)
+			require.Equal(t, test.correlated, sq.Correlated)
+		})
+	}
+}
+
+func TestParserWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected string
+		fails    bool
+	}{
+		{"Empty window", "SELECT ROW_NUMBER() OVER () FROM foo",
+			"seqScan(foo) | window(ROW_NUMBER() OVER ()) | project(ROW_NUMBER() OVER ())", false},
+		{"Partition and order", "SELECT a, RANK() OVER (PARTITION BY b, c ORDER BY d DESC, e ASC) AS r FROM foo",
+			"seqScan(foo) | window(RANK() OVER (PARTITION BY b, c ORDER BY d DESC, e)) | project(a, RANK() OVER (PARTITION BY b, c ORDER BY d DESC, e))", false},
+		{"Aggregate", "SELECT SUM(a) OVER (ORDER BY b) AS s FROM foo WHERE a > 1",
+			"seqScan(foo) | filter(a > 1) | window(SUM(a) OVER (ORDER BY b)) | project(SUM(a) OVER (ORDER BY b))", false},
+		{"Rows frame", "SELECT SUM(a) OVER (ORDER BY b ROWS BETWEEN 2 PRECEDING AND UNBOUNDED FOLLOWING) AS s FROM foo",
+			"seqScan(foo) | window(SUM(a) OVER (ORDER BY b ROWS BETWEEN 2 PRECEDING AND UNBOUNDED FOLLOWING)) | project(SUM(a) OVER (ORDER BY b ROWS BETWEEN 2 PRECEDING AND UNBOUNDED FOLLOWING))", false},
+		{"Short frame", "SELECT COUNT(*) OVER (RANGE UNBOUNDED PRECEDING) AS c FROM foo",
+			"seqScan(foo) | window(COUNT(*) OVER (RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW)) | project(COUNT(*) OVER (RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW))", false},
+		{"Lag", "SELECT LAG(a, 2, 0) OVER (ORDER BY b), LEAD(a) OVER (ORDER BY b) AS n FROM foo",
+			"seqScan(foo) | window(LAG(a, 2, 0) OVER (ORDER BY b), LEAD(a) OVER (ORDER BY b)) | project(LAG(a, 2, 0) OVER (ORDER BY b), LEAD(a) OVER (ORDER BY b))", false},
+		{"Missing over", "SELECT RANK() FROM foo", "", true},
+		{"Not a window function", "SELECT pk() OVER () FROM foo", "", true},
+		{"Missing parenthesis", "SELECT RANK() OVER (ORDER BY a FROM foo", "", true},
+		{"Invalid start", "SELECT RANK() OVER (ROWS UNBOUNDED FOLLOWING) FROM foo", "", true},
+		{"Invalid end", "SELECT RANK() OVER (ROWS BETWEEN CURRENT ROW AND UNBOUNDED PRECEDING) FROM foo", "", true},
+		{"Range offset", "SELECT RANK() OVER (RANGE BETWEEN 1 PRECEDING AND CURRENT ROW) FROM foo", "", true},
+		{"With group by", "SELECT a, RANK() OVER (ORDER BY a) FROM foo GROUP BY a", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.Equal(t, test.expected, q.Statements[0].(*planner.Statement).Stream.String())
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package parser
+
+import (
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/sql/scanner"
+)
+
+// subqueryInfo holds information about a subquery that is only needed
+// while parsing the enclosing statement.
+type subqueryInfo struct {
+	// names referred to by the first fragment of the paths
+	// used by the subquery or by any of its own subqueries.
+	refs map[string]struct{}
+	// subqueries directly used by the subquery.
+	children []*expr.Subquery
+}
+
+// subqueryScope describes how the documents of a statement are
+// made available to its subqueries.
+type subqueryScope struct {
+	// names under which the documents are accessible.
+	names []string
+	// name passed to the subquery, see expr.Subquery.Scope.
+	name string
+}
+
+// parseSubquery parses a SELECT statement used as an expression, followed by a closing parenthesis.
+// This function assumes the opening parenthesis has already been consumed.
+func (p *Parser) parseSubquery() (*expr.Subquery, error) {
+	if err := p.parseTokens(scanner.SELECT); err != nil {
+		return nil, err
+	}
+
+	cfg, err := p.parseSelectConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	info := subqueryInfo{
+		refs: make(map[string]struct{}),
+	}
+	for _, core := range append([]*selectConfig{cfg}, cfg.compoundSelects()...) {
+		p.aliasSelfReference(core)
+
+		for _, e := range core.exprs() {
+			p.collectSubqueryRefs(&info, e)
+		}
+	}
+
+	stmt, err := cfg.ToStream()
+	if err != nil {
+		return nil, err
+	}
+
+	sq := expr.Subquery{Stream: stmt}
+
+	if p.subqueries == nil {
+		p.subqueries = make(map[*expr.Subquery]*subqueryInfo)
+	}
+	p.subqueries[&sq] = &info
+
+	return &sq, nil
+}
+
+// aliasSelfReference aliases the documents of the table read by a subquery
+// if the subquery refers to that table by name to distinguish its paths from
+// those of the enclosing query
+// (i.e. SELECT * FROM foo WHERE EXISTS (SELECT * FROM bar WHERE bar.a = foo.a)).
+// Paths that don't use the table name still resolve against its documents
+// (i.e. SELECT SUM(b) FROM bar WHERE bar.a = foo.a).
+func (p *Parser) aliasSelfReference(cfg *selectConfig) {
+	if cfg.TableName == "" || cfg.TableAlias != "" || len(cfg.Joins) > 0 {
+		return
+	}
+
+	info := subqueryInfo{
+		refs: make(map[string]struct{}),
+	}
+	for _, e := range cfg.exprs() {
+		p.collectSubqueryRefs(&info, e)
+	}
+
+	if _, ok := info.refs[cfg.TableName]; ok {
+		cfg.TableAlias = cfg.TableName
+		p.bindSubqueries(cfg.scope(), cfg.exprs()...)
+	}
+}
+
+// collectSubqueryRefs records the names referred to by the paths of e,
+// as well as the subqueries it contains.
+func (p *Parser) collectSubqueryRefs(info *subqueryInfo, e expr.Expr) {
+	expr.Walk(e, func(e expr.Expr) bool {
+		switch t := e.(type) {
+		case expr.Path:
+			if len(t) > 0 && t[0].FieldName != "" {
+				info.refs[t[0].FieldName] = struct{}{}
+			}
+		case *expr.Subquery:
+			if child, ok := p.subqueries[t]; ok {
+				for ref := range child.refs {
+					info.refs[ref] = struct{}{}
+				}
+				info.children = append(info.children, t)
+			}
+		}
+
+		return true
+	})
+}
+
+// bindSubqueries configures the subqueries used by the given expressions
+// so that they can access the documents of the enclosing statement.
+// Subqueries referring to any of the names of the scope are marked as correlated.
+func (p *Parser) bindSubqueries(scope subqueryScope, exprs ...expr.Expr) {
+	if len(p.subqueries) == 0 {
+		return
+	}
+
+	info := subqueryInfo{
+		refs: make(map[string]struct{}),
+	}
+	for _, e := range exprs {
+		p.collectSubqueryRefs(&info, e)
+	}
+
+	for _, sq := range info.children {
+		sq.Scope = scope.name
+		p.markCorrelated(sq, scope.names)
+	}
+}
+
+// markCorrelated marks sq and its own subqueries as correlated
+// if they refer to any of the given names.
+func (p *Parser) markCorrelated(sq *expr.Subquery, names []string) {
+	info := p.subqueries[sq]
+
+	for _, name := range names {
+		if _, ok := info.refs[name]; ok {
+			sq.Correlated = true
+			break
+		}
+	}
+
+	for _, child := range info.children {
+		p.markCorrelated(child, names)
+	}
+}
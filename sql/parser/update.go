@@ -22,15 +22,17 @@ func (p *Parser) parseUpdateStatement() (*planner.Statement, error) {
 		return nil, pErr
 	}
 
-	// Parse clause: SET or UNSET.
+	// Parse clause: SET, UNSET or PATCH.
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
 	case scanner.SET:
 		cfg.SetPairs, err = p.parseSetClause()
 	case scanner.UNSET:
 		cfg.UnsetFields, err = p.parseUnsetClause()
+	case scanner.PATCH:
+		cfg.Patch, _, err = p.ParseExpr()
 	default:
-		err = newParseError(scanner.Tokstr(tok, lit), []string{"SET", "UNSET"}, pos)
+		err = newParseError(scanner.Tokstr(tok, lit), []string{"SET", "UNSET", "PATCH"}, pos)
 	}
 	if err != nil {
 		return nil, err
@@ -42,6 +44,17 @@ func (p *Parser) parseUpdateStatement() (*planner.Statement, error) {
 		return nil, err
 	}
 
+	cfg.Returning, err = p.parseReturning()
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := []expr.Expr{cfg.WhereExpr, cfg.Patch}
+	for _, pair := range cfg.SetPairs {
+		exprs = append(exprs, pair.e)
+	}
+	p.bindSubqueries(subqueryScope{names: []string{cfg.TableName}, name: cfg.TableName}, exprs...)
+
 	return cfg.ToStream(), nil
 }
 
@@ -61,7 +74,7 @@ func (p *Parser) parseSetClause() ([]updateSetPair, error) {
 		}
 
 		// Scan the identifier for the path name.
-		path, err := p.parsePath()
+		path, err := p.parseFieldPath()
 		if err != nil {
 			pErr := err.(*ParseError)
 			pErr.Expected = []string{"path"}
@@ -125,7 +138,14 @@ type updateConfig struct {
 	// each path that should be unset from the document.
 	UnsetFields []string
 
+	// Patch is used along with the Patch clause. It holds
+	// the JSON merge patch applied to the document.
+	Patch expr.Expr
+
 	WhereExpr expr.Expr
+
+	// Returning holds the expressions projected on the updated documents.
+	Returning []expr.Expr
 }
 
 type updateSetPair struct {
@@ -149,10 +169,16 @@ func (cfg updateConfig) ToStream() *planner.Statement {
 		for _, name := range cfg.UnsetFields {
 			s = s.Pipe(stream.Unset(name))
 		}
+	} else if cfg.Patch != nil {
+		s = s.Pipe(stream.Patch(cfg.Patch))
 	}
 
 	s = s.Pipe(stream.TableReplace(cfg.TableName))
 
+	if len(cfg.Returning) > 0 {
+		s = s.Pipe(stream.Project(cfg.Returning...))
+	}
+
 	return &planner.Statement{
 		Stream:   s,
 		ReadOnly: false,
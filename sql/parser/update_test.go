@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
 	"github.com/tie/genji-release-test/planner"
 	"github.com/tie/genji-release-test/sql/parser"
 	"github.com/tie/genji-release-test/stream"
@@ -44,6 +45,14 @@ func TestParserUpdate(t *testing.T) {
 				Pipe(stream.TableReplace("test")),
 			false,
 		},
+		{"SET/Returning", "UPDATE test SET a = 1 WHERE age = 10 RETURNING *, pk()",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Filter(parser.MustParseExpr("age = 10"))).
+				Pipe(stream.Set(document.Path(testutil.ParsePath(t, "a")), testutil.IntegerValue(1))).
+				Pipe(stream.TableReplace("test")).
+				Pipe(stream.Project(expr.Wildcard{}, testutil.ParseNamedExpr(t, "pk()"))),
+			false,
+		},
 		{"UNSET/No cond", "UPDATE test UNSET a",
 			stream.New(stream.SeqScan("test")).
 				Pipe(stream.Unset("a")).
@@ -58,11 +67,26 @@ func TestParserUpdate(t *testing.T) {
 				Pipe(stream.TableReplace("test")),
 			false,
 		},
+		{"PATCH/With cond", "UPDATE test PATCH {a: 1, b: NULL} WHERE age = 10",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Filter(parser.MustParseExpr("age = 10"))).
+				Pipe(stream.Patch(parser.MustParseExpr("{a: 1, b: NULL}"))).
+				Pipe(stream.TableReplace("test")),
+			false,
+		},
+		{"PATCH/Param", "UPDATE test PATCH ?",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Patch(parser.MustParseExpr("?"))).
+				Pipe(stream.TableReplace("test")),
+			false,
+		},
+		{"No patch", "UPDATE test PATCH WHERE age = 10", nil, true},
 		{"Trailing comma", "UPDATE test SET a = 1, WHERE age = 10", nil, true},
 		{"No SET", "UPDATE test WHERE age = 10", nil, true},
 		{"No pair", "UPDATE test SET WHERE age = 10", nil, true},
 		{"query.Field only", "UPDATE test SET a WHERE age = 10", nil, true},
 		{"No value", "UPDATE test SET a = WHERE age = 10", nil, true},
+		{"Wildcard", "UPDATE test SET a[*] = 1", nil, true},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"strconv"
+
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/sql/scanner"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// parseOver parses the optional OVER clause following a function call
+// and returns the function evaluated over the window, if any.
+func (p *Parser) parseOver(fn expr.Expr) (expr.Expr, error) {
+	_, isWindowFunc := fn.(expr.WindowFunction)
+
+	ok, err := p.parseOptional(scanner.OVER)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if isWindowFunc {
+			return nil, stringutil.Errorf("window function %s requires an OVER clause", fn)
+		}
+		return fn, nil
+	}
+
+	if _, ok := fn.(expr.AggregatorBuilder); !ok && !isWindowFunc {
+		return nil, stringutil.Errorf("%s is not a window function", fn)
+	}
+
+	w, err := p.parseWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	return &expr.Over{Func: fn, Window: w}, nil
+}
+
+// parseWindow parses a window definition:
+//   ([PARTITION BY expr, ...] [ORDER BY expr [ASC|DESC], ...] [frame])
+func (p *Parser) parseWindow() (*expr.Window, error) {
+	if err := p.parseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	var w expr.Window
+
+	// Parse "PARTITION BY expr, ..."
+	ok, err := p.parseOptional(scanner.PARTITION, scanner.BY)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		for {
+			e, _, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+			w.PartitionBy = append(w.PartitionBy, e)
+
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+				p.Unscan()
+				break
+			}
+		}
+	}
+
+	// Parse "ORDER BY expr [ASC|DESC], ..."
+	ok, err = p.parseOptional(scanner.ORDER, scanner.BY)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		w.OrderBy, err = p.parseSortKeys()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Frame, err = p.parseWindowFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// parseWindowFrame parses an optional window frame:
+//   {ROWS|RANGE} BETWEEN start AND end
+//   {ROWS|RANGE} start
+func (p *Parser) parseWindowFrame() (*expr.WindowFrame, error) {
+	var f expr.WindowFrame
+
+	switch tok, _, _ := p.ScanIgnoreWhitespace(); tok {
+	case scanner.ROWS:
+		f.Rows = true
+	case scanner.RANGE:
+	default:
+		p.Unscan()
+		return nil, nil
+	}
+
+	between, err := p.parseOptional(scanner.BETWEEN)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Start, err = p.parseFrameBound(f.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	f.End = expr.FrameBound{Type: expr.CurrentRow}
+	if between {
+		if err := p.parseTokens(scanner.AND); err != nil {
+			return nil, err
+		}
+
+		f.End, err = p.parseFrameBound(f.Rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if f.Start.Type == expr.UnboundedFollowing {
+		return nil, &ParseError{Message: "frame start cannot be UNBOUNDED FOLLOWING"}
+	}
+	if f.End.Type == expr.UnboundedPreceding {
+		return nil, &ParseError{Message: "frame end cannot be UNBOUNDED PRECEDING"}
+	}
+	if f.End.Type < f.Start.Type {
+		return nil, &ParseError{Message: stringutil.Sprintf("frame starting from %s cannot end with %s", f.Start, f.End)}
+	}
+
+	return &f, nil
+}
+
+// parseFrameBound parses the start or the end of a window frame.
+// Offsets are only supported by ROWS frames.
+func (p *Parser) parseFrameBound(rows bool) (expr.FrameBound, error) {
+	var b expr.FrameBound
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.UNBOUNDED:
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.PRECEDING:
+			b.Type = expr.UnboundedPreceding
+		case scanner.FOLLOWING:
+			b.Type = expr.UnboundedFollowing
+		default:
+			return b, newParseError(scanner.Tokstr(tok, lit), []string{"PRECEDING", "FOLLOWING"}, pos)
+		}
+	case scanner.CURRENT:
+		if err := p.parseTokens(scanner.ROW); err != nil {
+			return b, err
+		}
+		b.Type = expr.CurrentRow
+	case scanner.INTEGER:
+		if !rows {
+			return b, &ParseError{Message: "RANGE frames only support UNBOUNDED and CURRENT ROW bounds", Pos: pos}
+		}
+
+		v, err := strconv.ParseInt(lit, 10, 64)
+		if err != nil {
+			return b, &ParseError{Message: "unable to parse integer", Pos: pos}
+		}
+		b.Offset = v
+
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.PRECEDING:
+			b.Type = expr.OffsetPreceding
+		case scanner.FOLLOWING:
+			b.Type = expr.OffsetFollowing
+		default:
+			return b, newParseError(scanner.Tokstr(tok, lit), []string{"PRECEDING", "FOLLOWING"}, pos)
+		}
+	default:
+		return b, newParseError(scanner.Tokstr(tok, lit), []string{"UNBOUNDED", "CURRENT ROW", "integer"}, pos)
+	}
+
+	return b, nil
+}
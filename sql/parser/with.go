@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"github.com/tie/genji-release-test/planner"
+	"github.com/tie/genji-release-test/sql/scanner"
+	"github.com/tie/genji-release-test/stream"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// parseWithStatement parses a SELECT statement preceded by a list of common table expressions
+// and returns a Statement AST object.
+// This function assumes the WITH token has already been consumed.
+func (p *Parser) parseWithStatement() (*planner.Statement, error) {
+	recursive, err := p.parseOptional(scanner.RECURSIVE)
+	if err != nil {
+		return nil, err
+	}
+
+	// common table expressions are only visible to the current statement
+	prev := p.ctes
+	defer func() {
+		p.ctes = prev
+	}()
+	p.ctes = make(map[string]*cteConfig)
+	for name, cte := range prev {
+		p.ctes[name] = cte
+	}
+
+	for {
+		cte, err := p.parseCTE(recursive)
+		if err != nil {
+			return nil, err
+		}
+		p.ctes[cte.Name] = cte
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	if err := p.parseTokens(scanner.SELECT); err != nil {
+		return nil, err
+	}
+
+	return p.parseSelectStatement()
+}
+
+// parseCTE parses a common table expression: "name AS (SELECT ...)".
+// If recursive is true, the statement can refer to the common table expression itself,
+// in which case it must be of the form "anchor UNION [ALL] recursive".
+func (p *Parser) parseCTE(recursive bool) (*cteConfig, error) {
+	name, err := p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return nil, pErr
+	}
+
+	if err := p.parseTokens(scanner.AS, scanner.LPAREN, scanner.SELECT); err != nil {
+		return nil, err
+	}
+
+	cte := cteConfig{
+		Name:     name,
+		MaxDepth: p.maxDepth,
+		MaxRows:  p.maxRows,
+	}
+
+	// while parsing the statement of a recursive common table expression,
+	// references to its name are made to its working table.
+	if recursive {
+		cte.working = true
+		prev, ok := p.ctes[name]
+		p.ctes[name] = &cte
+		defer func() {
+			cte.working = false
+			if ok {
+				p.ctes[name] = prev
+			} else {
+				delete(p.ctes, name)
+			}
+		}()
+	}
+
+	cfg, err := p.parseSelectConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	if !cte.referenced {
+		stmt, err := cfg.ToStream()
+		if err != nil {
+			return nil, err
+		}
+
+		cte.Stream = stmt.Stream
+		return &cte, nil
+	}
+
+	if len(cfg.Compound) == 0 || cfg.Compound[len(cfg.Compound)-1].Operator != scanner.UNION {
+		return nil, stringutil.Errorf("recursive common table expression %q must be of the form: anchor UNION [ALL] recursive", name)
+	}
+	if cfg.OrderBy != nil || cfg.LimitExpr != nil || cfg.OffsetExpr != nil {
+		return nil, stringutil.Errorf("ORDER BY, LIMIT and OFFSET are not supported by recursive common table expression %q", name)
+	}
+
+	last := cfg.Compound[len(cfg.Compound)-1]
+	anchor := *cfg
+	anchor.Compound = cfg.Compound[:len(cfg.Compound)-1]
+
+	stmt, err := anchor.ToStream()
+	if err != nil {
+		return nil, err
+	}
+	cte.Anchor = stmt.Stream
+
+	cte.Recursive, err = last.Select.coreStream()
+	if err != nil {
+		return nil, err
+	}
+	cte.All = last.All
+
+	return &cte, nil
+}
+
+// cteConfig holds the configuration of a common table expression.
+type cteConfig struct {
+	Name string
+	// Stream of the statement, if the common table expression is not recursive.
+	Stream *stream.Stream
+	// Anchor and Recursive streams, if the common table expression is recursive.
+	Anchor    *stream.Stream
+	Recursive *stream.Stream
+	All       bool
+	MaxDepth  int
+	MaxRows   int
+
+	// working is set while parsing the statement of a recursive common table expression.
+	working bool
+	// referenced is set if the statement refers to the common table expression itself.
+	referenced bool
+}
+
+// source returns a stream that reads the documents of the table with the given name,
+// or those of the common table expression with that name if any.
+func (p *Parser) source(tableName string) *stream.Stream {
+	cte, ok := p.ctes[tableName]
+	if !ok {
+		return stream.New(stream.SeqScan(tableName))
+	}
+
+	if cte.working {
+		cte.referenced = true
+		return stream.New(stream.WorkingTable(cte.Name))
+	}
+
+	if cte.Recursive == nil {
+		return stream.New(stream.CTE(cte.Name, cte.Stream))
+	}
+
+	var op *stream.RecursiveCTEOperator
+	if cte.All {
+		op = stream.RecursiveCTEAll(cte.Name, cte.Anchor, cte.Recursive)
+	} else {
+		op = stream.RecursiveCTE(cte.Name, cte.Anchor, cte.Recursive)
+	}
+
+	// negative limits disable them
+	if cte.MaxDepth > 0 {
+		op.MaxDepth = cte.MaxDepth
+	}
+	if cte.MaxRows > 0 {
+		op.MaxRows = cte.MaxRows
+	}
+
+	return stream.New(op)
+}
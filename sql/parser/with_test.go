@@ -0,0 +1,70 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/planner"
+	"github.com/tie/genji-release-test/sql/parser"
+	"github.com/tie/genji-release-test/stream"
+	"github.com/tie/genji-release-test/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserWith(t *testing.T) {
+	cteStream := func(s string) *stream.Stream {
+		return stream.New(stream.SeqScan("foo")).Pipe(stream.Filter(parser.MustParseExpr("a > 1"))).Pipe(stream.Project(testutil.ParseNamedExpr(t, s)))
+	}
+
+	recursive := stream.RecursiveCTEAll("n",
+		stream.New(stream.Project(testutil.ParseNamedExpr(t, "1", "x"))),
+		stream.New(stream.WorkingTable("n")).Pipe(stream.Filter(parser.MustParseExpr("x < 10"))).Pipe(stream.Project(testutil.ParseNamedExpr(t, "x + 1"))),
+	)
+	recursive.MaxDepth = parser.DefaultMaxRecursionDepth
+	recursive.MaxRows = parser.DefaultMaxRecursionRows
+
+	tests := []struct {
+		name     string
+		s        string
+		expected *stream.Stream
+		mustFail bool
+	}{
+		{"Simple", "WITH t AS (SELECT a FROM foo WHERE a > 1) SELECT * FROM t",
+			stream.New(stream.CTE("t", cteStream("a"))).Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"Multiple", "WITH t AS (SELECT a FROM foo WHERE a > 1), u AS (SELECT b FROM foo WHERE a > 1) SELECT * FROM t, u",
+			stream.New(stream.CTE("t", cteStream("a"))).
+				Pipe(stream.Alias("t")).
+				Pipe(stream.Join(stream.New(stream.CTE("u", cteStream("b"))), "u", nil)).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"Recursive", "WITH RECURSIVE n AS (SELECT 1 AS x UNION ALL SELECT x + 1 FROM n WHERE x < 10) SELECT * FROM n",
+			stream.New(recursive).Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"Not recursive", "WITH RECURSIVE t AS (SELECT a FROM foo WHERE a > 1) SELECT * FROM t",
+			stream.New(stream.CTE("t", cteStream("a"))).Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"Missing statement", "WITH t AS (SELECT a FROM foo)", nil, true},
+		{"Missing parenthesis", "WITH t AS SELECT a FROM foo SELECT * FROM t", nil, true},
+		{"Not a select", "WITH t AS (SELECT a FROM foo) DELETE FROM t", nil, true},
+		{"Recursive without union", "WITH RECURSIVE n AS (SELECT x FROM n) SELECT * FROM n", nil, true},
+		{"Recursive with intersect", "WITH RECURSIVE n AS (SELECT 1 AS x INTERSECT SELECT x FROM n) SELECT * FROM n", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.mustFail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, &planner.Statement{Stream: test.expected, ReadOnly: true}, q.Statements[0])
+		})
+	}
+}
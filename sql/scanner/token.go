@@ -77,52 +77,93 @@ const (
 	keywordBeg
 	// ALL and the following are Genji SQL Keywords
 	ADD_KEYWORD
+	ALL
 	ALTER
+	ANY
 	AS
 	ASC
 	BEGIN
 	BY
+	CASCADE
+	CASE
 	CAST
+	CHECK
 	COMMIT
+	CONFLICT
+	CONSTRAINT
 	CREATE
+	CROSS
+	CURRENT
 	DEFAULT
 	DELETE
 	DESC
 	DISTINCT
+	DO
 	DROP
+	ELSE
+	END
+	EXCEPT
 	EXISTS
 	EXPLAIN
+	EXTRACT
+	FOLLOWING
 	FIELD
+	FOREIGN
 	FROM
 	GROUP
+	HAVING
 	IF
 	INDEX
+	INNER
 	INSERT
+	INTERSECT
+	INTERVAL
 	INTO
+	JOIN
 	KEY
+	LEFT
 	LIMIT
 	NOT
+	NOTHING
 	OFFSET
 	ON
 	ONLY
 	ORDER
+	OUTER
+	OVER
+	PARTITION
+	PATCH
+	PRECEDING
 	PRECISION
 	PRIMARY
+	RANGE
 	READ
+	RECURSIVE
+	REFERENCES
 	REINDEX
 	RENAME
+	RESTRICT
 	RETURNING
 	ROLLBACK
+	ROW
+	ROWS
 	SELECT
 	SET
+	STORED
 	TABLE
+	THEN
 	TO
 	TRANSACTION
+	UNBOUNDED
+	UNION
 	UNIQUE
+	UNNEST
 	UNSET
 	UPDATE
 	VALUES
+	WHEN
 	WHERE
+	WITH
 	WRITE
 
 	// Aliases
@@ -132,6 +173,7 @@ const (
 	TYPEBOOL
 	TYPEBYTES
 	TYPECHARACTER
+	TYPEDATE
 	TYPEDOCUMENT
 	TYPEDOUBLE
 	TYPEINT
@@ -141,6 +183,7 @@ const (
 	TYPEMEDIUMINT
 	TYPESMALLINT
 	TYPETEXT
+	TYPETIMESTAMP
 	TYPETINYINT
 	TYPEREAL
 	TYPEVARCHAR
@@ -202,52 +245,93 @@ var tokens = [...]string{
 	DOT:         ".",
 
 	ADD_KEYWORD: "ADD",
+	ALL:         "ALL",
 	ALTER:       "ALTER",
+	ANY:         "ANY",
 	AS:          "AS",
 	ASC:         "ASC",
 	BEGIN:       "BEGIN",
 	COMMIT:      "COMMIT",
+	CONFLICT:    "CONFLICT",
 	GROUP:       "GROUP",
+	HAVING:      "HAVING",
 	BY:          "BY",
 	CREATE:      "CREATE",
+	CASCADE:     "CASCADE",
+	CASE:        "CASE",
 	CAST:        "CAST",
+	CHECK:       "CHECK",
+	CONSTRAINT:  "CONSTRAINT",
+	CROSS:       "CROSS",
+	CURRENT:     "CURRENT",
 	DEFAULT:     "DEFAULT",
 	DELETE:      "DELETE",
 	DESC:        "DESC",
 	DISTINCT:    "DISTINCT",
+	DO:          "DO",
 	DROP:        "DROP",
+	ELSE:        "ELSE",
+	END:         "END",
+	EXCEPT:      "EXCEPT",
 	EXISTS:      "EXISTS",
 	EXPLAIN:     "EXPLAIN",
+	EXTRACT:     "EXTRACT",
+	FOLLOWING:   "FOLLOWING",
 	KEY:         "KEY",
 	FIELD:       "FIELD",
+	FOREIGN:     "FOREIGN",
 	FROM:        "FROM",
 	IF:          "IF",
 	INDEX:       "INDEX",
+	INNER:       "INNER",
 	INSERT:      "INSERT",
+	INTERSECT:   "INTERSECT",
+	INTERVAL:    "INTERVAL",
 	INTO:        "INTO",
+	JOIN:        "JOIN",
+	LEFT:        "LEFT",
 	LIMIT:       "LIMIT",
 	NOT:         "NOT",
+	NOTHING:     "NOTHING",
 	OFFSET:      "OFFSET",
 	ON:          "ON",
 	ONLY:        "ONLY",
 	ORDER:       "ORDER",
+	OUTER:       "OUTER",
+	OVER:        "OVER",
+	PARTITION:   "PARTITION",
+	PATCH:       "PATCH",
+	PRECEDING:   "PRECEDING",
 	PRECISION:   "PRECISION",
 	PRIMARY:     "PRIMARY",
+	RANGE:       "RANGE",
 	READ:        "READ",
+	RECURSIVE:   "RECURSIVE",
+	REFERENCES:  "REFERENCES",
 	REINDEX:     "REINDEX",
 	RENAME:      "RENAME",
+	RESTRICT:    "RESTRICT",
 	RETURNING:   "RETURNING",
 	ROLLBACK:    "ROLLBACK",
+	ROW:         "ROW",
+	ROWS:        "ROWS",
 	SELECT:      "SELECT",
 	SET:         "SET",
+	STORED:      "STORED",
 	TABLE:       "TABLE",
+	THEN:        "THEN",
 	TO:          "TO",
 	TRANSACTION: "TRANSACTION",
+	UNBOUNDED:   "UNBOUNDED",
+	UNION:       "UNION",
 	UNIQUE:      "UNIQUE",
+	UNNEST:      "UNNEST",
 	UNSET:       "UNSET",
 	UPDATE:      "UPDATE",
 	VALUES:      "VALUES",
+	WHEN:        "WHEN",
 	WHERE:       "WHERE",
+	WITH:        "WITH",
 	WRITE:       "WRITE",
 
 	TYPEARRAY:     "ARRAY",
@@ -256,6 +340,7 @@ var tokens = [...]string{
 	TYPEBOOL:      "BOOL",
 	TYPEBYTES:     "BYTES",
 	TYPECHARACTER: "CHARACTER",
+	TYPEDATE:      "DATE",
 	TYPEDOCUMENT:  "DOCUMENT",
 	TYPEDOUBLE:    "DOUBLE",
 	TYPEINT:       "INT",
@@ -265,6 +350,7 @@ var tokens = [...]string{
 	TYPEMEDIUMINT: "MEDIUMINT",
 	TYPESMALLINT:  "SMALLINT",
 	TYPETEXT:      "TEXT",
+	TYPETIMESTAMP: "TIMESTAMP",
 	TYPETINYINT:   "TINYINT",
 	TYPEREAL:      "REAL",
 	TYPEVARCHAR:   "VARCHAR",
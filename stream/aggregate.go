@@ -138,7 +138,6 @@ func newGroupEncoder() (func(env *expr.Environment) (string, error), error) {
 // result of the aggregation.
 type groupAggregator struct {
 	group       document.Value
-	groupExpr   string
 	env         *expr.Environment
 	aggregators []expr.Aggregator
 }
@@ -165,12 +164,8 @@ func newGroupAggregator(outerEnv *expr.Environment, builders []expr.AggregatorBu
 	ga.group, ok = outerEnv.Get(document.NewPath(groupEnvKey))
 	if !ok {
 		ga.group = document.NewNullValue()
-		return &ga
 	}
 
-	groupExprValue, _ := outerEnv.Get(document.NewPath(groupExprEnvKey))
-	ga.groupExpr = groupExprValue.V.(string)
-
 	return &ga
 }
 
@@ -188,9 +183,15 @@ func (g *groupAggregator) Aggregate(env *expr.Environment) error {
 func (g *groupAggregator) Flush(env *expr.Environment) (*expr.Environment, error) {
 	fb := document.NewFieldBuffer()
 
-	// add the current group to the document
-	if g.groupExpr != "" {
-		fb.Add(g.groupExpr, g.group)
+	// add the values of the current group to the document
+	if g.group.Type == document.DocumentValue {
+		err := g.group.V.(document.Document).Iterate(func(field string, v document.Value) error {
+			fb.Add(field, v)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	for _, agg := range g.aggregators {
@@ -14,7 +14,7 @@ import (
 func TestAggregate(t *testing.T) {
 	tests := []struct {
 		name     string
-		groupBy  expr.Expr
+		groupBy  []expr.Expr
 		builders []expr.AggregatorBuilder
 		in       []document.Document
 		want     []document.Document
@@ -38,12 +38,24 @@ func TestAggregate(t *testing.T) {
 		},
 		{
 			"count/groupBy",
-			parser.MustParseExpr("a % 2"),
+			[]expr.Expr{parser.MustParseExpr("a % 2")},
 			[]expr.AggregatorBuilder{&expr.CountFunc{Expr: parser.MustParseExpr("a")}, &expr.AvgFunc{Expr: parser.MustParseExpr("a")}},
 			generateSeqDocs(t, 10),
 			[]document.Document{testutil.MakeDocument(t, `{"a % 2": 0, "COUNT(a)": 5, "AVG(a)": 4.0}`), testutil.MakeDocument(t, `{"a % 2": 1, "COUNT(a)": 5, "AVG(a)": 5.0}`)},
 			false,
 		},
+		{
+			"count/groupBy multiple",
+			[]expr.Expr{parser.MustParseExpr("a % 2"), parser.MustParseExpr("b.c")},
+			[]expr.AggregatorBuilder{&expr.CountFunc{Wildcard: true}},
+			testutil.MakeDocuments(t, `{"a": 1, "b": {"c": 1}}`, `{"a": 2, "b": {"c": 1}}`, `{"a": 3, "b": {"c": 1}}`, `{"a": 3, "b": {"c": 2}}`),
+			[]document.Document{
+				testutil.MakeDocument(t, `{"a % 2": 1, "b": {"c": 1}, "COUNT(*)": 2}`),
+				testutil.MakeDocument(t, `{"a % 2": 0, "b": {"c": 1}, "COUNT(*)": 1}`),
+				testutil.MakeDocument(t, `{"a % 2": 1, "b": {"c": 2}, "COUNT(*)": 1}`),
+			},
+			false,
+		},
 		{
 			"count/noInput",
 			nil,
@@ -58,7 +70,7 @@ func TestAggregate(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			s := stream.New(stream.Documents(test.in...))
 			if test.groupBy != nil {
-				s = s.Pipe(stream.GroupBy(test.groupBy))
+				s = s.Pipe(stream.GroupBy(test.groupBy...))
 			}
 
 			s = s.Pipe(stream.HashAggregate(test.builders...))
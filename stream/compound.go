@@ -0,0 +1,299 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// encodeValues encodes the values of d, in order, ignoring the name of the fields.
+// Two documents with the same values in the same order produce the same output.
+func encodeValues(enc *document.ValueEncoder, d document.Document) error {
+	return d.Iterate(func(field string, value document.Value) error {
+		return enc.Encode(value)
+	})
+}
+
+// compoundDocuments combines the documents of the left and right streams of
+// a set operation. The documents of the right stream are renamed using the fields
+// of the first document of the left stream, so that each document of the result
+// has the same fields.
+type compoundDocuments struct {
+	fields []string
+	fb     document.FieldBuffer
+	env    expr.Environment
+}
+
+// left records the fields of the first document of the left stream.
+func (c *compoundDocuments) left(d document.Document) error {
+	if c.fields != nil {
+		return nil
+	}
+
+	fields := []string{}
+	err := d.Iterate(func(field string, _ document.Value) error {
+		fields = append(fields, field)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.fields = fields
+	return nil
+}
+
+// right returns an environment containing d, renamed using the fields of the left stream.
+// If the left stream didn't return any document, d is returned as is.
+// The number of fields is checked by the parser, unless one of the sides
+// projects a wildcard, in which case it can only be checked here.
+func (c *compoundDocuments) right(out *expr.Environment, d document.Document) (*expr.Environment, error) {
+	if c.fields == nil {
+		return out, nil
+	}
+
+	c.fb.Reset()
+	var i int
+	err := d.Iterate(func(field string, value document.Value) error {
+		if i >= len(c.fields) {
+			return errors.New("each side of a set operation must have the same number of fields")
+		}
+
+		c.fb.Add(c.fields[i], value)
+		i++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if i != len(c.fields) {
+		return nil, errors.New("each side of a set operation must have the same number of fields")
+	}
+
+	c.env.Outer = out
+	c.env.SetDocument(&c.fb)
+	return &c.env, nil
+}
+
+// A UnionOperator returns the documents of a stream followed by those of another stream.
+// Like the other set operations, it doesn't have any input and must be the
+// first operator of a stream.
+type UnionOperator struct {
+	baseOperator
+	Left  *Stream
+	Right *Stream
+	// All indicates that duplicate documents must be returned (UNION ALL).
+	All bool
+}
+
+// Union returns the documents of left followed by the documents of right,
+// removing duplicates.
+func Union(left, right *Stream) *UnionOperator {
+	return &UnionOperator{Left: left, Right: right}
+}
+
+// UnionAll returns the documents of left followed by the documents of right,
+// including duplicates.
+func UnionAll(left, right *Stream) *UnionOperator {
+	return &UnionOperator{Left: left, Right: right, All: true}
+}
+
+// Iterate implements the Operator interface.
+func (op *UnionOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
+	var c compoundDocuments
+	var buf bytes.Buffer
+	enc := document.NewValueEncoder(&buf)
+	m := make(map[string]struct{})
+
+	// emit returns the document unless it was already returned.
+	emit := func(out *expr.Environment) error {
+		if op.All {
+			return f(out)
+		}
+
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		buf.Reset()
+		err := encodeValues(enc, d)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := m[string(buf.Bytes())]; ok {
+			return nil
+		}
+		m[buf.String()] = struct{}{}
+
+		return f(out)
+	}
+
+	err := op.Left.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		err := c.left(d)
+		if err != nil {
+			return err
+		}
+
+		return emit(out)
+	})
+	if err != nil {
+		return err
+	}
+
+	return op.Right.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		out, err := c.right(out, d)
+		if err != nil {
+			return err
+		}
+
+		return emit(out)
+	})
+}
+
+func (op *UnionOperator) String() string {
+	if op.All {
+		return stringutil.Sprintf("unionAll(%s, %s)", op.Left, op.Right)
+	}
+
+	return stringutil.Sprintf("union(%s, %s)", op.Left, op.Right)
+}
+
+// An IntersectOperator returns the documents of a stream that are also returned by another stream.
+type IntersectOperator struct {
+	baseOperator
+	Left  *Stream
+	Right *Stream
+}
+
+// Intersect returns the distinct documents of left that are also returned by right.
+func Intersect(left, right *Stream) *IntersectOperator {
+	return &IntersectOperator{Left: left, Right: right}
+}
+
+// Iterate implements the Operator interface.
+func (op *IntersectOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
+	var buf bytes.Buffer
+	enc := document.NewValueEncoder(&buf)
+
+	right, err := encodeStream(op.Right, in, &buf, enc)
+	if err != nil {
+		return err
+	}
+
+	// documents are only returned the first time they are found
+	emitted := make(map[string]struct{})
+
+	return op.Left.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		buf.Reset()
+		err := encodeValues(enc, d)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := right[string(buf.Bytes())]; !ok {
+			return nil
+		}
+		if _, ok := emitted[string(buf.Bytes())]; ok {
+			return nil
+		}
+		emitted[buf.String()] = struct{}{}
+
+		return f(out)
+	})
+}
+
+func (op *IntersectOperator) String() string {
+	return stringutil.Sprintf("intersect(%s, %s)", op.Left, op.Right)
+}
+
+// An ExceptOperator returns the documents of a stream that are not returned by another stream.
+type ExceptOperator struct {
+	baseOperator
+	Left  *Stream
+	Right *Stream
+}
+
+// Except returns the distinct documents of left that are not returned by right.
+func Except(left, right *Stream) *ExceptOperator {
+	return &ExceptOperator{Left: left, Right: right}
+}
+
+// Iterate implements the Operator interface.
+func (op *ExceptOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
+	var buf bytes.Buffer
+	enc := document.NewValueEncoder(&buf)
+
+	// documents of the right stream are never returned, which also
+	// applies to the documents of the left stream once they have been returned.
+	m, err := encodeStream(op.Right, in, &buf, enc)
+	if err != nil {
+		return err
+	}
+
+	return op.Left.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		buf.Reset()
+		err := encodeValues(enc, d)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := m[string(buf.Bytes())]; ok {
+			return nil
+		}
+		m[buf.String()] = struct{}{}
+
+		return f(out)
+	})
+}
+
+func (op *ExceptOperator) String() string {
+	return stringutil.Sprintf("except(%s, %s)", op.Left, op.Right)
+}
+
+// encodeStream returns the set of the encoded values of every document of s.
+func encodeStream(s *Stream, in *expr.Environment, buf *bytes.Buffer, enc *document.ValueEncoder) (map[string]struct{}, error) {
+	m := make(map[string]struct{})
+
+	err := s.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		buf.Reset()
+		err := encodeValues(enc, d)
+		if err != nil {
+			return err
+		}
+
+		m[buf.String()] = struct{}{}
+		return nil
+	})
+
+	return m, err
+}
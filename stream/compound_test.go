@@ -0,0 +1,89 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/stream"
+	"github.com/tie/genji-release-test/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOperations(t *testing.T) {
+	left := testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`, `{"a": 2}`, `{"a": 3}`)
+	right := testutil.MakeDocuments(t, `{"b": 2}`, `{"b": 4}`, `{"b": 4}`)
+
+	tests := []struct {
+		name  string
+		op    stream.Operator
+		want  testutil.Docs
+		fails bool
+	}{
+		{
+			"union",
+			stream.Union(stream.New(stream.Documents(left...)), stream.New(stream.Documents(right...))),
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`, `{"a": 3}`, `{"a": 4}`),
+			false,
+		},
+		{
+			"union all",
+			stream.UnionAll(stream.New(stream.Documents(left...)), stream.New(stream.Documents(right...))),
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`, `{"a": 2}`, `{"a": 3}`, `{"a": 2}`, `{"a": 4}`, `{"a": 4}`),
+			false,
+		},
+		{
+			"union with empty left",
+			stream.Union(stream.New(stream.Documents()), stream.New(stream.Documents(right...))),
+			testutil.MakeDocuments(t, `{"b": 2}`, `{"b": 4}`),
+			false,
+		},
+		{
+			"intersect",
+			stream.Intersect(stream.New(stream.Documents(left...)), stream.New(stream.Documents(right...))),
+			testutil.MakeDocuments(t, `{"a": 2}`),
+			false,
+		},
+		{
+			"except",
+			stream.Except(stream.New(stream.Documents(left...)), stream.New(stream.Documents(right...))),
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 3}`),
+			false,
+		},
+		{
+			"different number of fields",
+			stream.Union(stream.New(stream.Documents(left...)), stream.New(stream.Documents(testutil.MakeDocuments(t, `{"b": 2, "c": 3}`)...))),
+			nil,
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []document.Document
+			err := stream.New(test.op).Iterate(new(expr.Environment), func(env *expr.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				var fb document.FieldBuffer
+				err := fb.Copy(d)
+				require.NoError(t, err)
+				got = append(got, &fb)
+				return nil
+			})
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			test.want.RequireEqual(t, got)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		foo, bar := stream.New(stream.SeqScan("foo")), stream.New(stream.SeqScan("bar"))
+		require.Equal(t, `union(seqScan(foo), seqScan(bar))`, stream.Union(foo, bar).String())
+		require.Equal(t, `unionAll(seqScan(foo), seqScan(bar))`, stream.UnionAll(foo, bar).String())
+		require.Equal(t, `intersect(seqScan(foo), seqScan(bar))`, stream.Intersect(foo, bar).String())
+		require.Equal(t, `except(seqScan(foo), seqScan(bar))`, stream.Except(foo, bar).String())
+	})
+}
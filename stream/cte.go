@@ -0,0 +1,208 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// workingTableVar is the name of the variable holding, for each recursive
+// common table expression being evaluated, the documents returned by its previous iteration.
+const workingTableVar = "_working"
+
+// A CTEOperator iterates over the documents of a common table expression.
+type CTEOperator struct {
+	baseOperator
+	Name   string
+	Stream *Stream
+}
+
+// CTE creates an operator that iterates over the documents returned by s,
+// which is the stream of the common table expression called name.
+func CTE(name string, s *Stream) *CTEOperator {
+	return &CTEOperator{Name: name, Stream: s}
+}
+
+// Iterate implements the Operator interface.
+func (op *CTEOperator) Iterate(in *expr.Environment, fn func(out *expr.Environment) error) error {
+	return op.Stream.Iterate(in, fn)
+}
+
+func (op *CTEOperator) String() string {
+	return stringutil.Sprintf("cte(%s, %s)", op.Name, op.Stream)
+}
+
+// A RecursiveCTEOperator iterates over the documents of a recursive common table expression.
+// It returns the documents of the anchor stream, then runs the recursive stream
+// using the documents it returned during the previous iteration, until no new document is returned.
+type RecursiveCTEOperator struct {
+	baseOperator
+	Name      string
+	Anchor    *Stream
+	Recursive *Stream
+	// All indicates that duplicate documents must be returned and used
+	// by the next iteration (UNION ALL).
+	All bool
+	// MaxDepth is the maximum number of iterations of the recursive stream.
+	// If zero, the number of iterations is not limited.
+	MaxDepth int
+	// MaxRows is the maximum number of documents returned by the operator.
+	// If zero, the number of documents is not limited.
+	MaxRows int
+}
+
+// RecursiveCTE creates an operator that evaluates the recursive common table expression
+// called name, removing duplicate documents.
+func RecursiveCTE(name string, anchor, recursive *Stream) *RecursiveCTEOperator {
+	return &RecursiveCTEOperator{Name: name, Anchor: anchor, Recursive: recursive}
+}
+
+// RecursiveCTEAll creates an operator that evaluates the recursive common table expression
+// called name, including duplicate documents.
+func RecursiveCTEAll(name string, anchor, recursive *Stream) *RecursiveCTEOperator {
+	return &RecursiveCTEOperator{Name: name, Anchor: anchor, Recursive: recursive, All: true}
+}
+
+// Iterate implements the Operator interface.
+func (op *RecursiveCTEOperator) Iterate(in *expr.Environment, fn func(out *expr.Environment) error) error {
+	var c compoundDocuments
+	var buf bytes.Buffer
+	enc := document.NewValueEncoder(&buf)
+	seen := make(map[string]struct{})
+	var rows int
+	next := document.NewValueBuffer()
+
+	// emit returns the document and stores it for the next iteration,
+	// unless it was already returned.
+	emit := func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		if !op.All {
+			buf.Reset()
+			err := encodeValues(enc, d)
+			if err != nil {
+				return err
+			}
+
+			if _, ok := seen[string(buf.Bytes())]; ok {
+				return nil
+			}
+			seen[buf.String()] = struct{}{}
+		}
+
+		rows++
+		if op.MaxRows > 0 && rows > op.MaxRows {
+			return stringutil.Errorf("recursive query %q returned more than %d documents", op.Name, op.MaxRows)
+		}
+
+		var fb document.FieldBuffer
+		err := fb.Copy(d)
+		if err != nil {
+			return err
+		}
+		next = next.Append(document.NewDocumentValue(&fb))
+
+		return fn(out)
+	}
+
+	err := op.Anchor.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		err := c.left(d)
+		if err != nil {
+			return err
+		}
+
+		return emit(out)
+	})
+	if err != nil {
+		return err
+	}
+
+	for depth := 1; next.Len() > 0; depth++ {
+		if op.MaxDepth > 0 && depth > op.MaxDepth {
+			return stringutil.Errorf("recursive query %q exceeded the maximum recursion depth of %d", op.Name, op.MaxDepth)
+		}
+
+		var workingTable document.FieldBuffer
+		workingTable.Add(op.Name, document.NewArrayValue(next))
+		next = document.NewValueBuffer()
+
+		var newEnv expr.Environment
+		newEnv.Outer = in
+		newEnv.Set(workingTableVar, document.NewDocumentValue(&workingTable))
+
+		err = op.Recursive.Iterate(&newEnv, func(out *expr.Environment) error {
+			d, ok := out.GetDocument()
+			if !ok {
+				return errors.New("missing document")
+			}
+
+			out, err := c.right(out, d)
+			if err != nil {
+				return err
+			}
+
+			return emit(out)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op *RecursiveCTEOperator) String() string {
+	if op.All {
+		return stringutil.Sprintf("recursiveCteAll(%s, %s, %s)", op.Name, op.Anchor, op.Recursive)
+	}
+
+	return stringutil.Sprintf("recursiveCte(%s, %s, %s)", op.Name, op.Anchor, op.Recursive)
+}
+
+// A WorkingTableOperator iterates over the documents returned by the previous
+// iteration of a recursive common table expression.
+type WorkingTableOperator struct {
+	baseOperator
+	Name string
+}
+
+// WorkingTable creates an operator that iterates over the documents returned by the
+// previous iteration of the recursive common table expression called name.
+// It must be used by the recursive stream of a RecursiveCTEOperator.
+func WorkingTable(name string) *WorkingTableOperator {
+	return &WorkingTableOperator{Name: name}
+}
+
+// Iterate implements the Operator interface.
+func (op *WorkingTableOperator) Iterate(in *expr.Environment, fn func(out *expr.Environment) error) error {
+	v, ok := in.Get(document.Path{
+		document.PathFragment{FieldName: workingTableVar},
+		document.PathFragment{FieldName: op.Name},
+	})
+	if !ok || v.Type != document.ArrayValue {
+		return stringutil.Errorf("working table of %q not found", op.Name)
+	}
+
+	var newEnv expr.Environment
+	newEnv.Outer = in
+
+	return v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+		newEnv.SetDocument(v.V.(document.Document))
+		return fn(&newEnv)
+	})
+}
+
+func (op *WorkingTableOperator) String() string {
+	return stringutil.Sprintf("workingTable(%s)", op.Name)
+}
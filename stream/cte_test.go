@@ -0,0 +1,72 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/sql/parser"
+	"github.com/tie/genji-release-test/stream"
+	"github.com/tie/genji-release-test/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecursiveCTE(t *testing.T) {
+	anchor := func() *stream.Stream {
+		return stream.New(stream.Documents(testutil.MakeDocuments(t, `{"a": 1}`)...))
+	}
+	next := func(cond string) *stream.Stream {
+		return stream.New(stream.WorkingTable("n")).
+			Pipe(stream.Filter(parser.MustParseExpr(cond))).
+			Pipe(stream.Project(testutil.ParseNamedExpr(t, "a + 1", "b")))
+	}
+	same := func() *stream.Stream {
+		return stream.New(stream.WorkingTable("n")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a")))
+	}
+
+	tests := []struct {
+		name  string
+		op    *stream.RecursiveCTEOperator
+		want  testutil.Docs
+		fails bool
+	}{
+		{"union all", stream.RecursiveCTEAll("n", anchor(), next("a < 3")), testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`, `{"a": 3}`), false},
+		{"union", stream.RecursiveCTE("n", anchor(), same()), testutil.MakeDocuments(t, `{"a": 1}`), false},
+		{"max depth", &stream.RecursiveCTEOperator{Name: "n", Anchor: anchor(), Recursive: next("a < 10"), All: true, MaxDepth: 3}, nil, true},
+		{"max rows", &stream.RecursiveCTEOperator{Name: "n", Anchor: anchor(), Recursive: same(), All: true, MaxRows: 100}, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []document.Document
+			err := stream.New(test.op).Iterate(new(expr.Environment), func(env *expr.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				var fb document.FieldBuffer
+				err := fb.Copy(d)
+				require.NoError(t, err)
+				got = append(got, &fb)
+				return nil
+			})
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			test.want.RequireEqual(t, got)
+		})
+	}
+
+	t.Run("missing working table", func(t *testing.T) {
+		err := stream.New(stream.WorkingTable("n")).Iterate(new(expr.Environment), func(env *expr.Environment) error {
+			return nil
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `cte(t, seqScan(foo))`, stream.CTE("t", stream.New(stream.SeqScan("foo"))).String())
+		require.Equal(t, `recursiveCte(n, seqScan(foo), workingTable(n))`, stream.RecursiveCTE("n", stream.New(stream.SeqScan("foo")), stream.New(stream.WorkingTable("n"))).String())
+		require.Equal(t, `recursiveCteAll(n, seqScan(foo), workingTable(n))`, stream.RecursiveCTEAll("n", stream.New(stream.SeqScan("foo")), stream.New(stream.WorkingTable("n"))).String())
+	})
+}
@@ -0,0 +1,343 @@
+package stream
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// A JoinedDocument is a document made of the documents of one or more tables,
+// each of them stored in a field named after the alias of its table.
+// A NULL value is used to represent a missing match on the right side of a LEFT JOIN.
+// Unnested arrays store each of their values, which are not necessarily documents.
+type JoinedDocument struct {
+	Aliases []string
+	Values  []document.Value
+}
+
+// With returns a copy of the joined document with v appended under the given alias.
+func (j *JoinedDocument) With(alias string, v document.Value) *JoinedDocument {
+	aliases := make([]string, len(j.Aliases), len(j.Aliases)+1)
+	copy(aliases, j.Aliases)
+	values := make([]document.Value, len(j.Values), len(j.Values)+1)
+	copy(values, j.Values)
+
+	return &JoinedDocument{
+		Aliases: append(aliases, alias),
+		Values:  append(values, v),
+	}
+}
+
+// GetByField returns the value stored under the given alias.
+// If field is not an alias, it is looked up in the aliased documents,
+// which allows paths to omit the alias of their table.
+// An error is returned if more than one of the documents contain the field.
+func (j *JoinedDocument) GetByField(field string) (document.Value, error) {
+	for i, alias := range j.Aliases {
+		if alias == field {
+			return j.Values[i], nil
+		}
+	}
+
+	var found bool
+	var v document.Value
+	for _, av := range j.Values {
+		if av.Type != document.DocumentValue {
+			continue
+		}
+
+		fv, err := av.V.(document.Document).GetByField(field)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return document.Value{}, err
+		}
+		if found {
+			return document.Value{}, stringutil.Errorf("field %q is ambiguous", field)
+		}
+
+		found = true
+		v = fv
+	}
+
+	if !found {
+		return document.Value{}, document.ErrFieldNotFound
+	}
+
+	return v, nil
+}
+
+// Iterate over each aliased value.
+func (j *JoinedDocument) Iterate(fn func(field string, value document.Value) error) error {
+	for i, alias := range j.Aliases {
+		err := fn(alias, j.Values[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j *JoinedDocument) String() string {
+	b, _ := document.MarshalJSON(j)
+	return string(b)
+}
+
+func (j *JoinedDocument) MarshalJSON() ([]byte, error) {
+	return document.MarshalJSON(j)
+}
+
+// An AliasOperator stores every incoming document in a JoinedDocument, under the given name.
+type AliasOperator struct {
+	baseOperator
+	Name string
+}
+
+// Alias makes the documents of the stream addressable by name
+// (i.e. name.a.b). Paths without that prefix still resolve (i.e. a.b).
+func Alias(name string) *AliasOperator {
+	return &AliasOperator{Name: name}
+}
+
+// Iterate implements the Operator interface.
+func (op *AliasOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
+	var newEnv expr.Environment
+
+	return op.Prev.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		newEnv.Outer = out
+		newEnv.SetDocument(&JoinedDocument{
+			Aliases: []string{op.Name},
+			Values:  []document.Value{document.NewDocumentValue(d)},
+		})
+		return f(&newEnv)
+	})
+}
+
+func (op *AliasOperator) String() string {
+	return stringutil.Sprintf("alias(%s)", op.Name)
+}
+
+// A JoinOperator combines every document of the stream with the documents of another stream.
+type JoinOperator struct {
+	baseOperator
+	// Right is the stream joined to the incoming documents.
+	// It is iterated once per incoming document, using an environment
+	// containing that document, which allows it to refer to the left side.
+	Right *Stream
+	// Alias under which the documents of the right stream are stored.
+	Alias string
+	// On is the join condition. If nil, every pair of documents matches.
+	On expr.Expr
+	// Left indicates that incoming documents without a match must still be
+	// returned, with a NULL value in place of the right document (LEFT JOIN).
+	Left bool
+}
+
+// Join combines each incoming document with every document of right
+// that satisfies the on condition. Incoming documents must be JoinedDocuments.
+func Join(right *Stream, alias string, on expr.Expr) *JoinOperator {
+	return &JoinOperator{Right: right, Alias: alias, On: on}
+}
+
+// LeftJoin does the same as Join but also returns incoming documents that
+// don't match any document of right.
+func LeftJoin(right *Stream, alias string, on expr.Expr) *JoinOperator {
+	return &JoinOperator{Right: right, Alias: alias, On: on, Left: true}
+}
+
+// Iterate implements the Operator interface.
+func (op *JoinOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
+	var leftEnv, newEnv expr.Environment
+
+	return op.Prev.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		left, ok := d.(*JoinedDocument)
+		if !ok {
+			return errors.New("joined documents must be aliased")
+		}
+
+		leftEnv.Outer = out
+		leftEnv.SetDocument(left)
+
+		var matched bool
+		err := op.Right.Iterate(&leftEnv, func(rout *expr.Environment) error {
+			rd, ok := rout.GetDocument()
+			if !ok {
+				return errors.New("missing document")
+			}
+
+			newEnv.Outer = rout
+			newEnv.SetDocument(left.With(op.Alias, document.NewDocumentValue(rd)))
+
+			if op.On != nil {
+				v, err := op.On.Eval(&newEnv)
+				if err != nil {
+					return err
+				}
+
+				ok, err := v.IsTruthy()
+				if err != nil || !ok {
+					return err
+				}
+			}
+
+			matched = true
+			return f(&newEnv)
+		})
+		if err != nil {
+			return err
+		}
+
+		if op.Left && !matched {
+			newEnv.Outer = &leftEnv
+			newEnv.SetDocument(left.With(op.Alias, document.NewNullValue()))
+			return f(&newEnv)
+		}
+
+		return nil
+	})
+}
+
+func (op *JoinOperator) String() string {
+	var sb strings.Builder
+
+	if op.Left {
+		sb.WriteString("leftJoin(")
+	} else {
+		sb.WriteString("join(")
+	}
+
+	sb.WriteString(op.Right.String())
+	sb.WriteString(" AS ")
+	sb.WriteString(op.Alias)
+
+	if op.On != nil {
+		sb.WriteString(", ")
+		sb.WriteString(op.On.(stringutil.Stringer).String())
+	}
+
+	sb.WriteByte(')')
+
+	return sb.String()
+}
+
+// An UnnestOperator combines every document of the stream with each of the values
+// of an array computed from that document.
+type UnnestOperator struct {
+	baseOperator
+	// E is evaluated for every incoming document.
+	// If it doesn't return an array, the document has no match.
+	E expr.Expr
+	// Alias under which the values of the array are stored.
+	Alias string
+	// On is the join condition. If nil, every value matches.
+	On expr.Expr
+	// Left indicates that incoming documents without a match must still be
+	// returned, with a NULL value in place of the array value.
+	Left bool
+}
+
+// Unnest combines each incoming document with every value of the array returned by e
+// that satisfies the on condition. Incoming documents must be JoinedDocuments.
+func Unnest(e expr.Expr, alias string, on expr.Expr) *UnnestOperator {
+	return &UnnestOperator{E: e, Alias: alias, On: on}
+}
+
+// LeftUnnest does the same as Unnest but also returns incoming documents that
+// don't match any value of the array.
+func LeftUnnest(e expr.Expr, alias string, on expr.Expr) *UnnestOperator {
+	return &UnnestOperator{E: e, Alias: alias, On: on, Left: true}
+}
+
+// Iterate implements the Operator interface.
+func (op *UnnestOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
+	var newEnv expr.Environment
+
+	return op.Prev.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		left, ok := d.(*JoinedDocument)
+		if !ok {
+			return errors.New("unnested documents must be aliased")
+		}
+
+		v, err := op.E.Eval(out)
+		if err != nil {
+			return err
+		}
+
+		newEnv.Outer = out
+
+		var matched bool
+		if v.Type == document.ArrayValue {
+			err = v.V.(document.Array).Iterate(func(i int, value document.Value) error {
+				newEnv.SetDocument(left.With(op.Alias, value))
+
+				if op.On != nil {
+					v, err := op.On.Eval(&newEnv)
+					if err != nil {
+						return err
+					}
+
+					ok, err := v.IsTruthy()
+					if err != nil || !ok {
+						return err
+					}
+				}
+
+				matched = true
+				return f(&newEnv)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if op.Left && !matched {
+			newEnv.SetDocument(left.With(op.Alias, document.NewNullValue()))
+			return f(&newEnv)
+		}
+
+		return nil
+	})
+}
+
+func (op *UnnestOperator) String() string {
+	var sb strings.Builder
+
+	if op.Left {
+		sb.WriteString("leftUnnest(")
+	} else {
+		sb.WriteString("unnest(")
+	}
+
+	sb.WriteString(op.E.(stringutil.Stringer).String())
+	sb.WriteString(" AS ")
+	sb.WriteString(op.Alias)
+
+	if op.On != nil {
+		sb.WriteString(", ")
+		sb.WriteString(op.On.(stringutil.Stringer).String())
+	}
+
+	sb.WriteByte(')')
+
+	return sb.String()
+}
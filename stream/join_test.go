@@ -0,0 +1,177 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/sql/parser"
+	"github.com/tie/genji-release-test/stream"
+	"github.com/tie/genji-release-test/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoin(t *testing.T) {
+	left := testutil.MakeDocuments(t, `{"id": 1}`, `{"id": 2}`, `{"id": 3}`)
+	right := testutil.MakeDocuments(t, `{"ref": 1, "v": "a"}`, `{"ref": 1, "v": "b"}`, `{"ref": 3, "v": "c"}`)
+
+	tests := []struct {
+		name string
+		op   stream.Operator
+		want testutil.Docs
+	}{
+		{
+			"inner",
+			stream.Join(stream.New(stream.Documents(right...)), "r", parser.MustParseExpr("l.id = r.ref")),
+			testutil.MakeDocuments(t,
+				`{"l": {"id": 1}, "r": {"ref": 1, "v": "a"}}`,
+				`{"l": {"id": 1}, "r": {"ref": 1, "v": "b"}}`,
+				`{"l": {"id": 3}, "r": {"ref": 3, "v": "c"}}`,
+			),
+		},
+		{
+			"left",
+			stream.LeftJoin(stream.New(stream.Documents(right...)), "r", parser.MustParseExpr("l.id = r.ref")),
+			testutil.MakeDocuments(t,
+				`{"l": {"id": 1}, "r": {"ref": 1, "v": "a"}}`,
+				`{"l": {"id": 1}, "r": {"ref": 1, "v": "b"}}`,
+				`{"l": {"id": 2}, "r": null}`,
+				`{"l": {"id": 3}, "r": {"ref": 3, "v": "c"}}`,
+			),
+		},
+		{
+			"cross",
+			stream.Join(stream.New(stream.Documents(right[:1]...)), "r", nil),
+			testutil.MakeDocuments(t,
+				`{"l": {"id": 1}, "r": {"ref": 1, "v": "a"}}`,
+				`{"l": {"id": 2}, "r": {"ref": 1, "v": "a"}}`,
+				`{"l": {"id": 3}, "r": {"ref": 1, "v": "a"}}`,
+			),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := stream.New(stream.Documents(left...)).Pipe(stream.Alias("l")).Pipe(test.op)
+
+			var got []document.Document
+			err := s.Iterate(new(expr.Environment), func(env *expr.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				var fb document.FieldBuffer
+				err := fb.Copy(d)
+				require.NoError(t, err)
+				got = append(got, &fb)
+				return nil
+			})
+			require.NoError(t, err)
+			test.want.RequireEqual(t, got)
+		})
+	}
+
+	t.Run("not aliased", func(t *testing.T) {
+		s := stream.New(stream.Documents(left...)).Pipe(stream.Join(stream.New(stream.Documents(right...)), "r", nil))
+		err := s.Iterate(new(expr.Environment), func(env *expr.Environment) error {
+			return nil
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `alias(a)`, stream.Alias("a").String())
+		require.Equal(t, `join(seqScan(foo) AS f, a.b = f.c)`, stream.Join(stream.New(stream.SeqScan("foo")), "f", parser.MustParseExpr("a.b = f.c")).String())
+		require.Equal(t, `leftJoin(indexLookup("idx", a.b) AS f)`, stream.LeftJoin(stream.New(stream.IndexLookup("idx", parser.MustParseExpr("a.b"))), "f", nil).String())
+	})
+}
+
+func TestUnnest(t *testing.T) {
+	left := testutil.MakeDocuments(t, `{"id": 1, "a": [{"b": 1}, {"b": 2}]}`, `{"id": 2, "a": []}`, `{"id": 3, "a": [3, "c"]}`, `{"id": 4}`)
+
+	tests := []struct {
+		name string
+		op   stream.Operator
+		want testutil.Docs
+	}{
+		{
+			"inner",
+			stream.Unnest(parser.MustParseExpr("l.a"), "v", nil),
+			testutil.MakeDocuments(t,
+				`{"l": {"id": 1, "a": [{"b": 1}, {"b": 2}]}, "v": {"b": 1}}`,
+				`{"l": {"id": 1, "a": [{"b": 1}, {"b": 2}]}, "v": {"b": 2}}`,
+				`{"l": {"id": 3, "a": [3, "c"]}, "v": 3}`,
+				`{"l": {"id": 3, "a": [3, "c"]}, "v": "c"}`,
+			),
+		},
+		{
+			"condition",
+			stream.Unnest(parser.MustParseExpr("l.a"), "v", parser.MustParseExpr("v.b > 1 OR v = 'c'")),
+			testutil.MakeDocuments(t,
+				`{"l": {"id": 1, "a": [{"b": 1}, {"b": 2}]}, "v": {"b": 2}}`,
+				`{"l": {"id": 3, "a": [3, "c"]}, "v": "c"}`,
+			),
+		},
+		{
+			"left",
+			stream.LeftUnnest(parser.MustParseExpr("l.a"), "v", parser.MustParseExpr("v.b > 1")),
+			testutil.MakeDocuments(t,
+				`{"l": {"id": 1, "a": [{"b": 1}, {"b": 2}]}, "v": {"b": 2}}`,
+				`{"l": {"id": 2, "a": []}, "v": null}`,
+				`{"l": {"id": 3, "a": [3, "c"]}, "v": null}`,
+				`{"l": {"id": 4}, "v": null}`,
+			),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := stream.New(stream.Documents(left...)).Pipe(stream.Alias("l")).Pipe(test.op)
+
+			var got []document.Document
+			err := s.Iterate(new(expr.Environment), func(env *expr.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				var fb document.FieldBuffer
+				err := fb.Copy(d)
+				require.NoError(t, err)
+				got = append(got, &fb)
+				return nil
+			})
+			require.NoError(t, err)
+			test.want.RequireEqual(t, got)
+		})
+	}
+
+	t.Run("not aliased", func(t *testing.T) {
+		s := stream.New(stream.Documents(left...)).Pipe(stream.Unnest(parser.MustParseExpr("a"), "v", nil))
+		err := s.Iterate(new(expr.Environment), func(env *expr.Environment) error {
+			return nil
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `unnest(a.b AS v)`, stream.Unnest(parser.MustParseExpr("a.b"), "v", nil).String())
+		require.Equal(t, `leftUnnest(a.b AS v, v > 1)`, stream.LeftUnnest(parser.MustParseExpr("a.b"), "v", parser.MustParseExpr("v > 1")).String())
+	})
+}
+
+func TestJoinedDocument(t *testing.T) {
+	a := document.NewDocumentValue(testutil.MakeDocument(t, `{"id": 1, "name": "x"}`))
+	b := document.NewDocumentValue(testutil.MakeDocument(t, `{"id": 2, "label": "y"}`))
+	d := (&stream.JoinedDocument{Aliases: []string{"a"}, Values: []document.Value{a}}).With("b", b)
+
+	v, err := d.GetByField("a")
+	require.NoError(t, err)
+	require.Equal(t, a, v)
+
+	// paths may omit the alias of their table
+	v, err = d.GetByField("label")
+	require.NoError(t, err)
+	require.Equal(t, document.NewTextValue("y"), v)
+
+	_, err = d.GetByField("id")
+	require.EqualError(t, err, `field "id" is ambiguous`)
+
+	_, err = d.GetByField("other")
+	require.Equal(t, document.ErrFieldNotFound, err)
+}
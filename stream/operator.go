@@ -14,9 +14,8 @@ import (
 )
 
 const (
-	groupEnvKey     = "_group"
-	groupExprEnvKey = "_group_expr"
-	accEnvKey       = "_acc"
+	groupEnvKey = "_group"
+	accEnvKey   = "_acc"
 )
 
 // ErrInvalidResult is returned when an expression supposed to evaluate to a document
@@ -199,17 +198,20 @@ func (op *SkipOperator) String() string {
 	return stringutil.Sprintf("skip(%d)", op.N)
 }
 
-// A GroupByOperator applies an expression on each value of the stream and stores the result in the _group
-// variable in the output stream.
+// A GroupByOperator applies a list of expressions on each value of the stream and stores the results
+// in the _group variable in the output stream.
 type GroupByOperator struct {
 	baseOperator
-	E expr.Expr
+	Exprs []expr.Expr
 }
 
-// GroupBy applies e on each value of the stream and stores the result in the _group
+// GroupBy applies the given expressions on each value of the stream and stores the results in the _group
 // variable in the output stream.
-func GroupBy(e expr.Expr) *GroupByOperator {
-	return &GroupByOperator{E: e}
+// The _group variable is a document containing one field per expression. Paths are stored at the same path
+// in the document, other expressions are stored in a field named after the expression.
+// This ensures that evaluating any of the expressions on that document returns the value of the group.
+func GroupBy(exprs ...expr.Expr) *GroupByOperator {
+	return &GroupByOperator{Exprs: exprs}
 }
 
 // Iterate implements the Operator interface.
@@ -217,47 +219,111 @@ func (op *GroupByOperator) Iterate(in *expr.Environment, f func(out *expr.Enviro
 	var newEnv expr.Environment
 
 	return op.Prev.Iterate(in, func(out *expr.Environment) error {
-		v, err := op.E.Eval(out)
-		if err != nil {
-			return err
+		// the group document is kept by the aggregation operators,
+		// a new one must be created for every document.
+		fb := document.NewFieldBuffer()
+
+		for _, e := range op.Exprs {
+			v, err := e.Eval(out)
+			if err != nil {
+				return err
+			}
+
+			err = setGroupValue(fb, e, v)
+			if err != nil {
+				return err
+			}
 		}
 
-		newEnv.Set(groupEnvKey, v)
-		newEnv.Set(groupExprEnvKey, document.NewTextValue(stringutil.Sprintf("%s", op.E)))
+		newEnv.Set(groupEnvKey, document.NewDocumentValue(fb))
 		newEnv.Outer = out
 		return f(&newEnv)
 	})
 }
 
+// setGroupValue stores the value of e in the group document.
+// If e is a path made of field names, nested documents are created as needed,
+// otherwise the value is stored in a field named after e.
+func setGroupValue(fb *document.FieldBuffer, e expr.Expr, v document.Value) error {
+	p, ok := e.(expr.Path)
+	if !ok {
+		fb.Add(stringutil.Sprintf("%s", e), v)
+		return nil
+	}
+
+	for _, f := range p {
+		if f.FieldName == "" {
+			fb.Add(p.String(), v)
+			return nil
+		}
+	}
+
+	for _, f := range p[:len(p)-1] {
+		inner, err := fb.GetByField(f.FieldName)
+		if err == document.ErrFieldNotFound {
+			nfb := document.NewFieldBuffer()
+			fb.Add(f.FieldName, document.NewDocumentValue(nfb))
+			fb = nfb
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// the parent path is also a grouping expression,
+		// its value already contains the value of e.
+		nfb, ok := inner.V.(*document.FieldBuffer)
+		if !ok {
+			return nil
+		}
+		fb = nfb
+	}
+
+	return fb.Set(document.Path{p[len(p)-1]}, v)
+}
+
 func (op *GroupByOperator) String() string {
-	return stringutil.Sprintf("groupBy(%s)", op.E)
+	var sb strings.Builder
+
+	for i, e := range op.Exprs {
+		sb.WriteString(stringutil.Sprintf("%s", e))
+		if i+1 < len(op.Exprs) {
+			sb.WriteString(", ")
+		}
+	}
+
+	return stringutil.Sprintf("groupBy(%s)", sb.String())
 }
 
 // A SortOperator consumes every value of the stream and outputs them in order.
 type SortOperator struct {
 	baseOperator
-	Expr expr.Expr
-	Desc bool
+	Keys []expr.SortKey
 }
 
 // Sort consumes every value of the stream and outputs them in order.
 // It operates a partial sort on the iterator using a heap.
 // This ensures a O(k+n log n) time complexity, where k is the sum of
 // Take() + Skip() operators, if provided, otherwise k = n.
-// If the sorting is in ascending order, a min-heap will be used
-// otherwise a max-heap will be used instead.
 // Once the heap is filled entirely with the content of the incoming stream, a stream is returned.
-// During iteration, the stream will pop the k-smallest or k-largest elements, depending on
-// the chosen sorting order (ASC or DESC).
+// During iteration, the stream will pop the k-smallest elements, according to the
+// sorting order of each key (ASC or DESC).
 // This function is not memory efficient as it is loading the entire stream in memory before
-// returning the k-smallest or k-largest elements.
+// returning the k-smallest elements.
 func Sort(e expr.Expr) *SortOperator {
-	return &SortOperator{Expr: e}
+	return SortBy(expr.SortKey{Expr: e})
 }
 
 // SortReverse does the same as Sort but in descending order.
 func SortReverse(e expr.Expr) *SortOperator {
-	return &SortOperator{Expr: e, Desc: true}
+	return SortBy(expr.SortKey{Expr: e, Desc: true})
+}
+
+// SortBy does the same as Sort but sorts the values using multiple keys.
+// Values are compared using the first key, then the next key is used
+// to order the values that are equal, and so on.
+func SortBy(keys ...expr.SortKey) *SortOperator {
+	return &SortOperator{Keys: keys}
 }
 
 func (op *SortOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
@@ -278,111 +344,188 @@ func (op *SortOperator) Iterate(in *expr.Environment, f func(out *expr.Environme
 }
 
 func (op *SortOperator) sortStream(prev Operator, in *expr.Environment) (heap.Interface, error) {
-	var h heap.Interface
-	if op.Desc {
-		h = new(maxHeap)
-	} else {
-		h = new(minHeap)
+	h := sortHeap{desc: make([]bool, len(op.Keys))}
+	for i, k := range op.Keys {
+		h.desc[i] = k.Desc
 	}
 
-	heap.Init(h)
+	heap.Init(&h)
 
-	getValue := op.Expr.Eval
-	if p, ok := op.Expr.(expr.Path); ok {
-		getValue = func(env *expr.Environment) (document.Value, error) {
-			for env != nil {
-				d, ok := env.GetDocument()
-				if !ok {
-					env = env.Outer
-					continue
-				}
+	// keys are evaluated against the current document,
+	// falling back to the documents it was built from.
+	// This allows sorting projected documents on fields that were not selected.
+	var doc sortDocument
+	var sortEnv expr.Environment
+	sortEnv.SetDocument(&doc)
 
-				v, err := document.Path(p).GetValueFromDocument(d)
-				if err == document.ErrFieldNotFound {
-					env = env.Outer
-					continue
-				}
-				return v, err
-			}
+	return &h, prev.Iterate(in, func(env *expr.Environment) error {
+		doc.env = env
+		sortEnv.Outer = env
 
-			return document.NewNullValue(), nil
+		node := heapNode{
+			values: make([][]byte, len(op.Keys)),
 		}
-	}
 
-	return h, prev.Iterate(in, func(env *expr.Environment) error {
-		sortV, err := getValue(env)
-		if err != nil {
-			return err
-		}
+		for i, k := range op.Keys {
+			sortV, err := k.Expr.Eval(&sortEnv)
+			if err != nil {
+				return err
+			}
 
-		// We need to make sure sort behaviour
-		// is the same with or without indexes.
-		// To achieve that, the value must be encoded using the same method
-		// as what the index package would do.
-		var buf bytes.Buffer
+			// We need to make sure sort behaviour
+			// is the same with or without indexes.
+			// To achieve that, the value must be encoded using the same method
+			// as what the index package would do.
+			var buf bytes.Buffer
 
-		err = document.NewValueEncoder(&buf).Encode(sortV)
-		if err != nil {
-			return err
-		}
+			err = document.NewValueEncoder(&buf).Encode(sortV)
+			if err != nil {
+				return err
+			}
 
-		node := heapNode{
-			value: buf.Bytes(),
+			node.values[i] = buf.Bytes()
 		}
+
+		var err error
 		node.data, err = env.Clone()
 		if err != nil {
 			return err
 		}
 
-		heap.Push(h, node)
+		heap.Push(&h, node)
 
 		return nil
 	})
 }
 
 func (op *SortOperator) String() string {
-	if op.Desc {
-		return stringutil.Sprintf("sortReverse(%s)", op.Expr)
+	if len(op.Keys) == 1 {
+		if op.Keys[0].Desc {
+			return stringutil.Sprintf("sortReverse(%s)", op.Keys[0].Expr)
+		}
+
+		return stringutil.Sprintf("sort(%s)", op.Keys[0].Expr)
+	}
+
+	var sb strings.Builder
+	for i, k := range op.Keys {
+		sb.WriteString(k.String())
+		if i+1 < len(op.Keys) {
+			sb.WriteString(", ")
+		}
 	}
 
-	return stringutil.Sprintf("sort(%s)", op.Expr)
+	return stringutil.Sprintf("sort(%s)", sb.String())
 }
 
-type heapNode struct {
-	value []byte
-	data  *expr.Environment
+// sortDocument looks up fields in the document of an environment,
+// then in the documents of its outer environments.
+type sortDocument struct {
+	env *expr.Environment
 }
 
-type minHeap []heapNode
+func (d *sortDocument) GetByField(field string) (document.Value, error) {
+	for env := d.env; env != nil; env = env.Outer {
+		if env.Doc == nil {
+			continue
+		}
 
-func (h minHeap) Len() int           { return len(h) }
-func (h minHeap) Less(i, j int) bool { return bytes.Compare(h[i].value, h[j].value) < 0 }
-func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+		v, err := env.Doc.GetByField(field)
+		if err != document.ErrFieldNotFound {
+			return v, err
+		}
+	}
 
-func (h *minHeap) Push(x interface{}) {
-	*h = append(*h, x.(heapNode))
+	return document.Value{}, document.ErrFieldNotFound
 }
 
-func (h *minHeap) Pop() interface{} {
-	old := *h
-	n := len(old)
-	x := old[n-1]
-	*h = old[0 : n-1]
-	return x
+func (d *sortDocument) Iterate(fn func(field string, value document.Value) error) error {
+	doc, ok := d.env.GetDocument()
+	if !ok {
+		return nil
+	}
+
+	return doc.Iterate(fn)
 }
 
-type maxHeap struct {
-	minHeap
+// keyer returns the first document implementing the document.Keyer interface, if any.
+func (d *sortDocument) keyer() (document.Keyer, bool) {
+	for env := d.env; env != nil; env = env.Outer {
+		if k, ok := env.Doc.(document.Keyer); ok {
+			return k, true
+		}
+	}
+
+	return nil, false
 }
 
-func (h maxHeap) Less(i, j int) bool {
-	return bytes.Compare(h.minHeap[i].value, h.minHeap[j].value) > 0
+func (d *sortDocument) RawKey() []byte {
+	k, ok := d.keyer()
+	if !ok {
+		return nil
+	}
+
+	return k.RawKey()
+}
+
+func (d *sortDocument) Key() (document.Value, error) {
+	k, ok := d.keyer()
+	if !ok {
+		return document.NewNullValue(), nil
+	}
+
+	return k.Key()
+}
+
+type heapNode struct {
+	values [][]byte
+	data   *expr.Environment
+}
+
+// sortHeap is a min-heap of nodes, ordered by each of their values.
+// The order of each value is reversed if the corresponding desc field is true.
+type sortHeap struct {
+	nodes []heapNode
+	desc  []bool
+}
+
+func (h sortHeap) Len() int      { return len(h.nodes) }
+func (h sortHeap) Swap(i, j int) { h.nodes[i], h.nodes[j] = h.nodes[j], h.nodes[i] }
+
+func (h sortHeap) Less(i, j int) bool {
+	for k := range h.desc {
+		c := bytes.Compare(h.nodes[i].values[k], h.nodes[j].values[k])
+		if h.desc[k] {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+
+	return false
+}
+
+func (h *sortHeap) Push(x interface{}) {
+	h.nodes = append(h.nodes, x.(heapNode))
+}
+
+func (h *sortHeap) Pop() interface{} {
+	old := h.nodes
+	n := len(old)
+	x := old[n-1]
+	h.nodes = old[0 : n-1]
+	return x
 }
 
 // A TableInsertOperator inserts incoming documents to the table.
 type TableInsertOperator struct {
 	baseOperator
 	Name string
+
+	// OnConflict determines what to do when an incoming document conflicts
+	// with an existing one. If nil, the insertion fails.
+	OnConflict *OnConflict
 }
 
 // TableInsert inserts incoming documents to the table.
@@ -390,6 +533,12 @@ func TableInsert(tableName string) *TableInsertOperator {
 	return &TableInsertOperator{Name: tableName}
 }
 
+// TableUpsert inserts incoming documents to the table and resolves conflicts
+// with existing documents as specified by onConflict.
+func TableUpsert(tableName string, onConflict *OnConflict) *TableInsertOperator {
+	return &TableInsertOperator{Name: tableName, OnConflict: onConflict}
+}
+
 // Iterate implements the Operator interface.
 func (op *TableInsertOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
 	var newEnv expr.Environment
@@ -409,6 +558,27 @@ func (op *TableInsertOperator) Iterate(in *expr.Environment, f func(out *expr.En
 			}
 		}
 
+		if op.OnConflict != nil {
+			key, err := table.Conflict(d, op.OnConflict.Paths...)
+			if err != nil {
+				return err
+			}
+
+			if key != nil {
+				if op.OnConflict.DoNothing {
+					return nil
+				}
+
+				newEnv.Doc, err = op.OnConflict.update(env, table, key, d)
+				if err != nil {
+					return err
+				}
+
+				newEnv.Outer = env
+				return f(&newEnv)
+			}
+		}
+
 		newEnv.Doc, err = table.Insert(d)
 		if err != nil {
 			return err
@@ -420,9 +590,109 @@ func (op *TableInsertOperator) Iterate(in *expr.Environment, f func(out *expr.En
 }
 
 func (op *TableInsertOperator) String() string {
+	if op.OnConflict != nil {
+		return stringutil.Sprintf("tableInsert('%s', %s)", op.Name, op.OnConflict)
+	}
+
 	return stringutil.Sprintf("tableInsert('%s')", op.Name)
 }
 
+// OnConflict describes how to resolve a conflict between an incoming document
+// and an existing one, either on the primary key or on a unique index.
+type OnConflict struct {
+	// Paths of the primary key or unique index to check for conflicts.
+	// If empty, the primary key and all the unique indexes are checked.
+	Paths []document.Path
+
+	// DoNothing skips the conflicting documents.
+	DoNothing bool
+
+	// Updates are applied to the existing document if DoNothing is false.
+	// The incoming document can be referred to using the excluded variable,
+	// e.g. excluded.a.
+	Updates []ConflictUpdate
+}
+
+// ConflictUpdate sets the result of E at Path in the existing document.
+type ConflictUpdate struct {
+	Path document.Path
+	E    expr.Expr
+}
+
+// ExcludedVar is the name of the variable that holds the incoming document
+// when resolving a conflict.
+const ExcludedVar = "excluded"
+
+// update applies the updates to the existing document stored at key
+// and returns the new version of the document.
+func (oc *OnConflict) update(env *expr.Environment, table *database.Table, key []byte, d document.Document) (document.Document, error) {
+	old, err := table.GetDocument(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var fb document.FieldBuffer
+	err = fb.Copy(old)
+	if err != nil {
+		return nil, err
+	}
+
+	var updateEnv expr.Environment
+	updateEnv.Outer = env
+	updateEnv.SetDocument(old)
+	updateEnv.Set(ExcludedVar, document.NewDocumentValue(d))
+
+	for _, u := range oc.Updates {
+		v, err := u.E.Eval(&updateEnv)
+		if err != nil && err != document.ErrFieldNotFound {
+			return nil, err
+		}
+
+		err = fb.Set(u.Path, v)
+		if err != nil && err != document.ErrFieldNotFound {
+			return nil, err
+		}
+	}
+
+	err = table.Replace(key, &fb)
+	if err != nil {
+		return nil, err
+	}
+
+	return table.GetDocument(key)
+}
+
+func (oc *OnConflict) String() string {
+	var b strings.Builder
+
+	b.WriteString("ON CONFLICT")
+	if len(oc.Paths) > 0 {
+		b.WriteString(" (")
+		for i, p := range oc.Paths {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(p.String())
+		}
+		b.WriteString(")")
+	}
+
+	if oc.DoNothing {
+		b.WriteString(" DO NOTHING")
+		return b.String()
+	}
+
+	b.WriteString(" DO UPDATE SET ")
+	for i, u := range oc.Updates {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(stringutil.Sprintf("%s = %s", u.Path, u.E))
+	}
+
+	return b.String()
+}
+
 // A TableReplaceOperator replaces documents in the table
 type TableReplaceOperator struct {
 	baseOperator
@@ -700,6 +970,59 @@ func (op *UnsetOperator) String() string {
 	return stringutil.Sprintf("unset(%s)", op.Field)
 }
 
+// A PatchOperator applies a JSON merge patch to every document of the stream.
+type PatchOperator struct {
+	baseOperator
+	E expr.Expr
+}
+
+// Patch applies the document returned by e as a JSON merge patch
+// to every document of the stream.
+func Patch(e expr.Expr) *PatchOperator {
+	return &PatchOperator{E: e}
+}
+
+// Iterate implements the Operator interface.
+func (op *PatchOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
+	var fb document.FieldBuffer
+	var newEnv expr.Environment
+
+	return op.Prev.Iterate(in, func(out *expr.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		v, err := op.E.Eval(out)
+		if err != nil {
+			return err
+		}
+		if v.Type != document.DocumentValue {
+			return stringutil.Errorf("patch must be a document, got %s", v.Type)
+		}
+
+		fb.Reset()
+		err = fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		err = fb.Patch(v.V.(document.Document))
+		if err != nil {
+			return err
+		}
+
+		newEnv.Outer = out
+		newEnv.SetDocument(&fb)
+
+		return f(&newEnv)
+	})
+}
+
+func (op *PatchOperator) String() string {
+	return stringutil.Sprintf("patch(%s)", op.E)
+}
+
 // An IterRenameOperator iterates over all fields of the incoming document in order and renames them.
 type IterRenameOperator struct {
 	baseOperator
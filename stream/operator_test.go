@@ -212,47 +212,57 @@ func TestSkip(t *testing.T) {
 
 func TestGroupBy(t *testing.T) {
 	tests := []struct {
-		e     expr.Expr
+		exprs []expr.Expr
 		in    []document.Document
-		group document.Value
+		group document.Document
 		fails bool
 	}{
 		{
-			parser.MustParseExpr("10"),
+			[]expr.Expr{parser.MustParseExpr("10")},
 			testutil.MakeDocuments(t, `{"a": 10}`),
-			document.NewIntegerValue(10),
+			testutil.MakeDocument(t, `{"10": 10}`),
 			false,
 		},
 		{
-			parser.MustParseExpr("null"),
+			[]expr.Expr{parser.MustParseExpr("null")},
 			testutil.MakeDocuments(t, `{"a": 10}`),
-			document.NewNullValue(),
+			testutil.MakeDocument(t, `{"NULL": null}`),
 			false,
 		},
 		{
-			parser.MustParseExpr("a"),
+			[]expr.Expr{parser.MustParseExpr("a")},
 			testutil.MakeDocuments(t, `{"a": 10}`),
-			document.NewIntegerValue(10),
+			testutil.MakeDocument(t, `{"a": 10}`),
 			false,
 		},
 		{
-			parser.MustParseExpr("b"),
+			[]expr.Expr{parser.MustParseExpr("b")},
 			testutil.MakeDocuments(t, `{"a": 10}`),
-			document.NewNullValue(),
+			testutil.MakeDocument(t, `{"b": null}`),
+			false,
+		},
+		{
+			[]expr.Expr{parser.MustParseExpr("a"), parser.MustParseExpr("b.c"), parser.MustParseExpr("b.d"), parser.MustParseExpr("a + 1")},
+			testutil.MakeDocuments(t, `{"a": 10, "b": {"c": 1, "d": 2, "e": 3}}`),
+			testutil.MakeDocument(t, `{"a": 10, "b": {"c": 1, "d": 2}, "a + 1": 11}`),
+			false,
+		},
+		{
+			[]expr.Expr{parser.MustParseExpr("b"), parser.MustParseExpr("b.c")},
+			testutil.MakeDocuments(t, `{"b": {"c": 1, "d": 2}}`),
+			testutil.MakeDocument(t, `{"b": {"c": 1, "d": 2}}`),
 			false,
 		},
 	}
 
 	for _, test := range tests {
-		t.Run(fmt.Sprintf("%s", test.e), func(t *testing.T) {
-			var want expr.Environment
-			want.Set("_group", test.group)
-			want.Set("_group_expr", document.NewTextValue(fmt.Sprintf("%s", test.e)))
-
-			s := stream.New(stream.Documents(test.in...)).Pipe(stream.GroupBy(test.e))
+		t.Run(stream.GroupBy(test.exprs...).String(), func(t *testing.T) {
+			s := stream.New(stream.Documents(test.in...)).Pipe(stream.GroupBy(test.exprs...))
 			err := s.Iterate(nil, func(out *expr.Environment) error {
 				out.Outer = nil
-				require.Equal(t, &want, out)
+				v, ok := out.Get(document.NewPath("_group"))
+				require.True(t, ok)
+				testutil.RequireDocEqual(t, test.group, v.V.(document.Document))
 				return nil
 			})
 			if test.fails {
@@ -265,6 +275,7 @@ func TestGroupBy(t *testing.T) {
 
 	t.Run("String", func(t *testing.T) {
 		require.Equal(t, stream.GroupBy(parser.MustParseExpr("1")).String(), "groupBy(1)")
+		require.Equal(t, stream.GroupBy(parser.MustParseExpr("a"), parser.MustParseExpr("b.c")).String(), "groupBy(a, b.c)")
 	})
 }
 
@@ -346,8 +357,32 @@ func TestSort(t *testing.T) {
 		})
 	}
 
+	t.Run("Multiple keys", func(t *testing.T) {
+		s := stream.New(stream.Documents(testutil.MakeDocuments(t,
+			`{"a": 1, "b": 1}`, `{"a": 2, "b": 1}`, `{"a": 1, "b": 2}`, `{"a": 2, "b": 3}`,
+		)...)).Pipe(stream.SortBy(
+			expr.SortKey{Expr: parser.MustParseExpr("a"), Desc: true},
+			expr.SortKey{Expr: parser.MustParseExpr("b * -1")},
+		))
+
+		var got []document.Document
+		err := s.Iterate(new(expr.Environment), func(env *expr.Environment) error {
+			d, ok := env.GetDocument()
+			require.True(t, ok)
+			got = append(got, d)
+			return nil
+		})
+		require.NoError(t, err)
+		testutil.MakeDocuments(t, `{"a": 2, "b": 3}`, `{"a": 2, "b": 1}`, `{"a": 1, "b": 2}`, `{"a": 1, "b": 1}`).RequireEqual(t, got)
+	})
+
 	t.Run("String", func(t *testing.T) {
 		require.Equal(t, `sort(a)`, stream.Sort(parser.MustParseExpr("a")).String())
+		require.Equal(t, `sortReverse(a)`, stream.SortReverse(parser.MustParseExpr("a")).String())
+		require.Equal(t, `sort(a DESC, b + 1)`, stream.SortBy(
+			expr.SortKey{Expr: parser.MustParseExpr("a"), Desc: true},
+			expr.SortKey{Expr: parser.MustParseExpr("b + 1")},
+		).String())
 	})
 }
 
@@ -709,6 +744,55 @@ func TestUnset(t *testing.T) {
 	})
 }
 
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		e       expr.Expr
+		in, out []document.Document
+		fails   bool
+	}{
+		{
+			parser.MustParseExpr(`{b: 30, c: NULL}`),
+			testutil.MakeDocuments(t, `{"a": 10, "c": 20}`),
+			testutil.MakeDocuments(t, `{"a": 10, "b": 30}`),
+			false,
+		},
+		{
+			parser.MustParseExpr(`{a: {b: NULL, d: [1]}}`),
+			testutil.MakeDocuments(t, `{"a": {"b": 1, "c": 2}}`, `{"a": 1}`),
+			testutil.MakeDocuments(t, `{"a": {"c": 2, "d": [1]}}`, `{"a": {"d": [1]}}`),
+			false,
+		},
+		{
+			parser.MustParseExpr(`1`),
+			testutil.MakeDocuments(t, `{"a": 10}`),
+			nil,
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.e.String(), func(t *testing.T) {
+			s := stream.New(stream.Documents(test.in...)).Pipe(stream.Patch(test.e))
+			i := 0
+			err := s.Iterate(nil, func(out *expr.Environment) error {
+				d, _ := out.GetDocument()
+				testutil.RequireDocEqual(t, test.out[i], d)
+				i++
+				return nil
+			})
+			if test.fails {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, stream.Patch(parser.MustParseExpr("{a: 1}")).String(), `patch({"a": 1})`)
+	})
+}
+
 func TestIterRename(t *testing.T) {
 	tests := []struct {
 		fieldNames []string
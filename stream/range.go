@@ -26,9 +26,16 @@ type ValueRange struct {
 
 	encodedMin, encodedMax []byte
 	rangeType              document.ValueType
+	// set once the range has been encoded, which types its boundaries.
+	// the range can then be used again without being encoded.
+	encoded bool
 }
 
 func (r *ValueRange) encode(encoder ValueEncoder, env *expr.Environment) error {
+	if r.encoded {
+		return nil
+	}
+
 	var err error
 
 	// first we evaluate Min and Max
@@ -63,6 +70,7 @@ func (r *ValueRange) encode(encoder ValueEncoder, env *expr.Environment) error {
 		panic("exclusive and exact cannot both be true")
 	}
 
+	r.encoded = true
 	return nil
 }
 
@@ -71,18 +79,19 @@ func (r *ValueRange) String() string {
 		return stringutil.Sprintf("%v", r.Min)
 	}
 
-	if r.Min.Type.IsAny() {
-		r.Min = document.NewIntegerValue(-1)
+	min, max := r.Min, r.Max
+	if min.Type.IsAny() {
+		min = document.NewIntegerValue(-1)
 	}
-	if r.Max.Type.IsAny() {
-		r.Max = document.NewIntegerValue(-1)
+	if max.Type.IsAny() {
+		max = document.NewIntegerValue(-1)
 	}
 
 	if r.Exclusive {
-		return stringutil.Sprintf("[%v, %v, true]", r.Min, r.Max)
+		return stringutil.Sprintf("[%v, %v, true]", min, max)
 	}
 
-	return stringutil.Sprintf("[%v, %v]", r.Min, r.Max)
+	return stringutil.Sprintf("[%v, %v]", min, max)
 }
 
 func (r *ValueRange) IsEqual(other *ValueRange) bool {
@@ -254,9 +263,16 @@ type IndexRange struct {
 
 	encodedMin, encodedMax []byte
 	rangeTypes             []document.ValueType
+	// set once the range has been encoded, which types its boundaries.
+	// the range can then be used again without being encoded.
+	encoded bool
 }
 
 func (r *IndexRange) encode(encoder ValueBufferEncoder, env *expr.Environment) error {
+	if r.encoded {
+		return nil
+	}
+
 	var err error
 
 	// first we evaluate Min and Max
@@ -314,6 +330,7 @@ func (r *IndexRange) encode(encoder ValueBufferEncoder, env *expr.Environment) e
 		panic("exclusive and exact cannot both be true")
 	}
 
+	r.encoded = true
 	return nil
 }
 
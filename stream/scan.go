@@ -419,3 +419,110 @@ func (it *IndexScanOperator) Iterate(in *expr.Environment, fn func(out *expr.Env
 
 	return nil
 }
+
+// A PkLookupOperator iterates over the documents of a table whose primary key
+// equals the result of an expression.
+type PkLookupOperator struct {
+	baseOperator
+	TableName string
+	E         expr.Expr
+}
+
+// PkLookup creates an iterator that evaluates e using the incoming environment
+// and returns the document of the given table whose primary key equals the result.
+func PkLookup(tableName string, e expr.Expr) *PkLookupOperator {
+	return &PkLookupOperator{TableName: tableName, E: e}
+}
+
+// Iterate implements the Operator interface.
+func (it *PkLookupOperator) Iterate(in *expr.Environment, fn func(out *expr.Environment) error) error {
+	table, err := in.GetTx().GetTable(it.TableName)
+	if err != nil {
+		return err
+	}
+
+	info := table.Info()
+	pk := info.GetPrimaryKey()
+	if pk == nil {
+		return stringutil.Errorf("table %q has no primary key", it.TableName)
+	}
+
+	v, ok, err := lookupValue(it.E, in, pk.Path, pk.Type, info.FieldConstraints)
+	if err != nil || !ok {
+		return err
+	}
+
+	return PkScan(it.TableName, ValueRange{Min: v, Exact: true}).Iterate(in, fn)
+}
+
+func (it *PkLookupOperator) String() string {
+	return stringutil.Sprintf("pkLookup(%s, %s)", strconv.Quote(it.TableName), it.E)
+}
+
+// A IndexLookupOperator iterates over the documents of an index whose indexed value
+// equals the result of an expression.
+type IndexLookupOperator struct {
+	baseOperator
+	IndexName string
+	E         expr.Expr
+}
+
+// IndexLookup creates an iterator that evaluates e using the incoming environment
+// and returns the documents whose value indexed by the given index equals the result.
+// The index must not be composite.
+func IndexLookup(name string, e expr.Expr) *IndexLookupOperator {
+	return &IndexLookupOperator{IndexName: name, E: e}
+}
+
+// Iterate implements the Operator interface.
+func (it *IndexLookupOperator) Iterate(in *expr.Environment, fn func(out *expr.Environment) error) error {
+	index, err := in.GetTx().GetIndex(it.IndexName)
+	if err != nil {
+		return err
+	}
+	if index.IsComposite() {
+		return stringutil.Errorf("cannot lookup composite index %q", it.IndexName)
+	}
+
+	table, err := in.GetTx().GetTable(index.Info.TableName)
+	if err != nil {
+		return err
+	}
+
+	v, ok, err := lookupValue(it.E, in, index.Info.Paths[0], index.Info.Types[0], table.Info().FieldConstraints)
+	if err != nil || !ok {
+		return err
+	}
+
+	return IndexScan(it.IndexName, IndexRange{Min: document.NewValueBuffer(v), Exact: true}).Iterate(in, fn)
+}
+
+func (it *IndexLookupOperator) String() string {
+	return stringutil.Sprintf("indexLookup(%s, %s)", strconv.Quote(it.IndexName), it.E)
+}
+
+// lookupValue evaluates e and converts the result so that it can be compared
+// with values stored at the given path.
+// It returns false if no stored value can be equal to the result.
+func lookupValue(e expr.Expr, env *expr.Environment, path document.Path, typ document.ValueType, fcs database.FieldConstraints) (document.Value, bool, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return v, false, err
+	}
+
+	// NULL is never equal to anything
+	if v.Type == document.NullValue {
+		return v, false, nil
+	}
+
+	v, err = fcs.ConvertValueAtPath(path, v, database.LosslessNumbersConversion)
+	if err != nil {
+		return v, false, err
+	}
+
+	if !typ.IsAny() && typ != v.Type {
+		return v, false, nil
+	}
+
+	return v, true, nil
+}
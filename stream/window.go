@@ -0,0 +1,157 @@
+package stream
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/stringutil"
+)
+
+// A WindowOperator consumes the given stream and computes the value of window functions
+// for each document. The documents are returned partitioned and sorted using the window
+// of the first function, and the values are read by the corresponding expr.Over expressions.
+type WindowOperator struct {
+	baseOperator
+	Exprs []*expr.Over
+}
+
+// Window consumes the incoming stream and computes the value of the given window functions
+// for each document.
+// Like Sort, it loads the entire stream in memory before returning any document.
+func Window(exprs ...*expr.Over) *WindowOperator {
+	return &WindowOperator{Exprs: exprs}
+}
+
+// Iterate implements the Operator interface.
+func (op *WindowOperator) Iterate(in *expr.Environment, f func(out *expr.Environment) error) error {
+	var rows []*expr.Environment
+
+	err := op.Prev.Iterate(in, func(out *expr.Environment) error {
+		env, err := out.Clone()
+		if err != nil {
+			return err
+		}
+
+		rows = append(rows, env)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	values := make(map[*expr.Environment]*document.FieldBuffer, len(rows))
+	for _, env := range rows {
+		values[env] = document.NewFieldBuffer()
+	}
+
+	// functions using the same window share the same partitions
+	var windows []*expr.Window
+	functions := make(map[string][]*expr.Over)
+	for _, o := range op.Exprs {
+		w := o.Window.String()
+		if _, ok := functions[w]; !ok {
+			windows = append(windows, o.Window)
+		}
+		functions[w] = append(functions[w], o)
+	}
+
+	var sorted []*expr.Environment
+	for i, w := range windows {
+		partitions, err := partition(w, rows)
+		if err != nil {
+			return err
+		}
+
+		for _, prows := range partitions {
+			p, err := expr.NewWindowPartition(w, prows)
+			if err != nil {
+				return err
+			}
+
+			for j, env := range p.Rows {
+				for _, o := range functions[w.String()] {
+					v, err := o.EvalWindow(p, j)
+					if err != nil {
+						return err
+					}
+
+					values[env].Add(o.String(), v)
+				}
+			}
+
+			if i == 0 {
+				sorted = append(sorted, p.Rows...)
+			}
+		}
+	}
+
+	var newEnv expr.Environment
+	for _, env := range sorted {
+		newEnv.Vars = nil
+		expr.SetWindowValues(&newEnv, values[env])
+		newEnv.Outer = env
+
+		err := f(&newEnv)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// partition splits the rows into groups sharing the same PARTITION BY values,
+// in the order they appear.
+func partition(w *expr.Window, rows []*expr.Environment) ([][]*expr.Environment, error) {
+	if len(w.PartitionBy) == 0 {
+		return [][]*expr.Environment{rows}, nil
+	}
+
+	var buf bytes.Buffer
+	enc := document.NewValueEncoder(&buf)
+
+	var keys []string
+	partitions := make(map[string][]*expr.Environment)
+	for _, env := range rows {
+		buf.Reset()
+		for _, e := range w.PartitionBy {
+			v, err := e.Eval(env)
+			if err != nil {
+				return nil, err
+			}
+
+			err = enc.Encode(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		key := buf.String()
+		if _, ok := partitions[key]; !ok {
+			keys = append(keys, key)
+		}
+		partitions[key] = append(partitions[key], env)
+	}
+
+	result := make([][]*expr.Environment, len(keys))
+	for i, key := range keys {
+		result[i] = partitions[key]
+	}
+
+	return result, nil
+}
+
+func (op *WindowOperator) String() string {
+	var sb strings.Builder
+
+	for i, o := range op.Exprs {
+		sb.WriteString(o.String())
+		if i+1 < len(op.Exprs) {
+			sb.WriteString(", ")
+		}
+	}
+
+	return stringutil.Sprintf("window(%s)", sb.String())
+}
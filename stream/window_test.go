@@ -0,0 +1,68 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/tie/genji-release-test/document"
+	"github.com/tie/genji-release-test/expr"
+	"github.com/tie/genji-release-test/sql/parser"
+	"github.com/tie/genji-release-test/stream"
+	"github.com/tie/genji-release-test/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow(t *testing.T) {
+	over := func(s string) *expr.Over {
+		return parser.MustParseExpr(s).(*expr.Over)
+	}
+	docs := func() *stream.Stream {
+		return stream.New(stream.Documents(testutil.MakeDocuments(t,
+			`{"g": 1, "v": 3}`, `{"g": 2, "v": 1}`, `{"g": 1, "v": 1}`, `{"g": 1, "v": 3}`,
+		)...))
+	}
+
+	tests := []struct {
+		name  string
+		exprs []*expr.Over
+		want  []string
+	}{
+		{"row number", []*expr.Over{over("ROW_NUMBER() OVER (ORDER BY v)")}, []string{
+			`{"g": 2, "v": 1, "n": 1}`, `{"g": 1, "v": 1, "n": 2}`, `{"g": 1, "v": 3, "n": 3}`, `{"g": 1, "v": 3, "n": 4}`,
+		}},
+		{"rank", []*expr.Over{over("RANK() OVER (PARTITION BY g ORDER BY v DESC)")}, []string{
+			`{"g": 1, "v": 3, "n": 1}`, `{"g": 1, "v": 3, "n": 1}`, `{"g": 1, "v": 1, "n": 3}`, `{"g": 2, "v": 1, "n": 1}`,
+		}},
+		{"sum", []*expr.Over{over("SUM(v) OVER (PARTITION BY g)")}, []string{
+			`{"g": 1, "v": 3, "n": 7}`, `{"g": 1, "v": 1, "n": 7}`, `{"g": 1, "v": 3, "n": 7}`, `{"g": 2, "v": 1, "n": 1}`,
+		}},
+		{"multiple windows", []*expr.Over{over("LEAD(v) OVER (ORDER BY v)"), over("COUNT(*) OVER ()")}, []string{
+			`{"g": 2, "v": 1, "n": 1}`, `{"g": 1, "v": 1, "n": 3}`, `{"g": 1, "v": 3, "n": 3}`, `{"g": 1, "v": 3, "n": null}`,
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := docs().
+				Pipe(stream.Window(test.exprs...)).
+				Pipe(stream.Project(expr.Wildcard{}, &expr.NamedExpr{Expr: test.exprs[0], ExprName: "n"}))
+
+			var got []document.Document
+			err := s.Iterate(new(expr.Environment), func(env *expr.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				var fb document.FieldBuffer
+				err := fb.Copy(d)
+				require.NoError(t, err)
+				got = append(got, &fb)
+				return nil
+			})
+			require.NoError(t, err)
+			testutil.MakeDocuments(t, test.want...).RequireEqual(t, got)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `window(RANK() OVER (PARTITION BY a ORDER BY b DESC), SUM(c) OVER ())`,
+			stream.Window(over("RANK() OVER (PARTITION BY a ORDER BY b DESC)"), over("SUM(c) OVER ()")).String())
+	})
+}